@@ -1,14 +1,25 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"go-server/internal/app/jobs"
 	"go-server/internal/app/router"
 	"go-server/internal/pkg/migrations"
+	"go-server/pkg/shared/auth"
 	"go-server/pkg/shared/database"
 	"go-server/pkg/shared/logging"
 	"go-server/pkg/shared/logging/hooks"
+	"go-server/pkg/shared/realtime"
+	"go-server/pkg/shared/utils"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/robfig/cron/v3"
 
 	_ "github.com/joho/godotenv/autoload"
 )
@@ -36,17 +47,79 @@ func main() {
 	}
 	logger.Info("Migrate Database Success")
 
+	logger.Info("Load Signing Keys")
+	_, err = auth.Keys()
+	if err != nil {
+		logger.Fatalln("Failed to load signing keys.")
+		panic(err)
+	}
+	logger.Info("Load Signing Keys Success")
+
+	hub := realtime.NewHub(realtime.ConfigFromEnv(), logger)
+
+	logger.Info("Init Jobs")
+	cronConfig, err := jobs.LoadConfig(cronConfigPath())
+	if err != nil {
+		logger.Fatalln("Failed to load jobs config.")
+		panic(err)
+	}
+	scheduler := cron.New()
+	err = jobs.InitJobs(scheduler, cronConfig, db, hub, logger)
+	if err != nil {
+		logger.Fatalln("Failed to init jobs.")
+		panic(err)
+	}
+	scheduler.Start()
+	defer scheduler.Stop()
+	logger.Info("Init Jobs Success")
+
 	engine := gin.New()
 	router := &router.Router{
 		Engine: engine,
 		DB:     db,
+		Hub:    hub,
 	}
 	router.InitializeRouter(logger)
 	router.SetupHandler()
 
-	err = engine.Run(":" + os.Getenv("API_PORT"))
+	server := &http.Server{
+		Addr:    ":" + os.Getenv("API_PORT"),
+		Handler: engine,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go func() {
+		err := server.ListenAndServe()
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Fatalln("Failed to run server.")
+			panic(err)
+		}
+	}()
+
+	<-ctx.Done()
+	stop()
+
+	// websockets are hijacked from the server, the hub closes them
+	logger.Info("Shutdown Server")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), utils.DurationFromEnv("SHUTDOWN_TIMEOUT", 10*time.Second))
+	defer cancel()
+	err = server.Shutdown(shutdownCtx)
 	if err != nil {
-		logger.Fatalln("Failed to run server.")
-		panic(err)
+		logger.Errorf("Failed to shutdown server: %v", err)
+	}
+	err = hub.Shutdown(shutdownCtx)
+	if err != nil {
+		logger.Errorf("Failed to close websockets: %v", err)
+	}
+	logger.Info("Shutdown Server Success")
+}
+
+// cronConfigPath is the schedule of the background jobs, configured by CRON_CONFIG
+func cronConfigPath() string {
+	if path := os.Getenv("CRON_CONFIG"); path != "" {
+		return path
 	}
+	return "configs/cron.yaml"
 }
@@ -1,9 +1,11 @@
 package jobs
 
 import (
+	"go-server/pkg/shared/realtime"
 	"os"
 
 	"github.com/robfig/cron/v3"
+	"github.com/sirupsen/logrus"
 	"gopkg.in/yaml.v3"
 	"gorm.io/gorm"
 )
@@ -35,20 +37,26 @@ func InitJobs(
 	c *cron.Cron,
 	cfg *CronJob,
 	db *gorm.DB,
+	hub *realtime.Hub,
+	logger *logrus.Logger,
 ) error {
-	var err error
 	for _, job := range cfg.JobConfigs {
+		var err error
 		switch job.Func {
 		case "abc":
 			_, err = c.AddFunc(job.Schedule, func() {
 				Job1(db)
 			})
+		case "purge_deleted_users":
+			_, err = c.AddFunc(job.Schedule, func() {
+				PurgeDeletedUsersJob(db, hub, logger)
+			})
 		default:
 			continue
 		}
-	}
-	if err != nil {
-		return err
+		if err != nil {
+			return err
+		}
 	}
 	return nil
 }
@@ -0,0 +1,32 @@
+package jobs
+
+import (
+	"context"
+	"go-server/internal/pkg/repositories"
+	"go-server/internal/pkg/services"
+	"go-server/internal/pkg/usecases"
+	"go-server/pkg/shared/realtime"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// PurgeDeletedUsersJob erases the accounts whose deletion grace period is over
+func PurgeDeletedUsersJob(db *gorm.DB, sessions realtime.SessionCloser, logger *logrus.Logger) {
+	userRepo := repositories.NewUserRepository(db, logger)
+	userTokenRepo := repositories.NewUserTokenRepository(db, logger)
+	accountRepo := repositories.NewAccountRepository(db, logger)
+	commentRepo := repositories.NewCommentRepository(db, logger)
+	loginAttemptRepo := repositories.NewLoginAttemptRepository(db, logger)
+	mailService := services.NewMailService()
+	accountUsecase := usecases.NewAccountUsecase(userRepo, userTokenRepo, accountRepo, commentRepo, loginAttemptRepo, mailService, sessions, logger)
+
+	erased, err := accountUsecase.PurgeDeletedAccounts(context.Background(), db)
+	if err != nil {
+		logger.Errorf("purge deleted users failed: %v", err)
+		return
+	}
+	if erased > 0 {
+		logger.Infof("purged %d deleted users", erased)
+	}
+}
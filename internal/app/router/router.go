@@ -2,8 +2,10 @@ package router
 
 import (
 	"go-server/internal/pkg/domains/models/dtos"
+	"go-server/internal/pkg/domains/models/entities"
 	"go-server/internal/pkg/handlers"
 	"go-server/pkg/shared/middleware"
+	"go-server/pkg/shared/realtime"
 	"go-server/pkg/shared/validator"
 	"net/http"
 	"os"
@@ -19,10 +21,11 @@ type Router struct {
 	Engine *gin.Engine
 	DB     *gorm.DB
 	Logger *logrus.Logger
+	Hub    *realtime.Hub
 }
 
 func (r *Router) InitializeRouter(logger *logrus.Logger) {
-	r.Engine.Use(gin.Logger())
+	r.Engine.Use(middleware.Logger())
 	r.Engine.Use(gin.Recovery())
 	r.Engine.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"*"},
@@ -56,13 +59,25 @@ func (r *Router) SetupHandler() {
 
 	r.Engine.Use(middleware.RequestID())
 
-	userHandler := handlers.NewUserHandler(r.Logger, r.DB)
+	userHandler := handlers.NewUserHandler(r.Logger, r.DB, r.Hub)
+	sessionHandler := handlers.NewSessionHandler(r.Logger, r.DB, r.Hub)
+	oidcHandler := handlers.NewOIDCHandler(r.Logger, r.DB, r.Hub)
+	twoFactorHandler := handlers.NewTwoFactorHandler(r.Logger, r.DB, r.Hub)
 	uploadHandler := handlers.NewUploadHandler(cld, r.Logger)
 	bannerHandler := handlers.NewBannerHandler(r.Logger, r.DB)
 	categoryHandler := handlers.NewCategoryHandler(r.Logger, r.DB)
-	placeHandler := handlers.NewPlaceHandler(r.Logger, r.DB)
-	tripHandler := handlers.NewTripHandler(r.Logger, r.DB)
-	commentHandler := handlers.NewCommentHandler(r.DB, r.Logger)
+	placeHandler := handlers.NewPlaceHandler(r.Logger, r.DB, cld, r.Hub)
+	tripHandler := handlers.NewTripHandler(r.Logger, r.DB, r.Hub)
+	commentHandler := handlers.NewCommentHandler(r.DB, r.Logger, cld, r.Hub)
+	commentModerationHandler := handlers.NewCommentModerationHandler(r.Logger, r.DB, r.Hub)
+	analyticsHandler := handlers.NewAnalyticsHandler(r.Logger, r.DB)
+	roleHandler := handlers.NewRoleHandler(r.Logger, r.DB)
+	jwksHandler := handlers.NewJWKSHandler(r.Logger)
+	apiKeyHandler := handlers.NewAPIKeyHandler(r.Logger, r.DB)
+	accountHandler := handlers.NewAccountHandler(r.Logger, r.DB, r.Hub)
+	profileHandler := handlers.NewProfileHandler(r.Logger, r.DB)
+	notificationHandler := handlers.NewNotificationHandler(r.Logger, r.DB, r.Hub)
+	realtimeHandler := handlers.NewRealtimeHandler(r.Logger, r.DB, r.Hub)
 
 	// health check
 	r.Engine.GET("/", func(c *gin.Context) {
@@ -74,6 +89,12 @@ func (r *Router) SetupHandler() {
 		c.JSON(http.StatusOK, data)
 	})
 
+	// public keys of our JWTs
+	r.Engine.GET("/.well-known/jwks.json", jwksHandler.JWKS)
+
+	// pushes of notifications and trip changes, the access token may be sent as access_token
+	r.Engine.GET("/ws", middleware.CheckAuthentication(r.DB), realtimeHandler.Connect)
+
 	// router api
 	publicApi := r.Engine.Group("/api")
 	{
@@ -83,11 +104,20 @@ func (r *Router) SetupHandler() {
 			authApi.POST("/register", userHandler.Register)
 			authApi.POST("/login", userHandler.Login)
 			authApi.POST("/admin/login", userHandler.AdminLogin)
+			authApi.POST("/login/2fa", twoFactorHandler.Login)
 			authApi.GET("/google/login", userHandler.GoogleLogin)
 			authApi.GET("/google/callback", userHandler.GoogleCallback)
+			authApi.GET("/oidc/:provider/login", oidcHandler.Login)
+			authApi.GET("/oidc/:provider/callback", oidcHandler.Callback)
+			authApi.GET("/verify_email", userHandler.VerifyEmail)
+			authApi.POST("/resend_verification", userHandler.ResendVerification)
 			authApi.POST("/forgot_password", userHandler.ForgotPassword)
-			authApi.POST("/logout", userHandler.Logout)
-			authApi.POST("/admin/logout", userHandler.Logout)
+			authApi.POST("/reset_password", userHandler.ResetPassword)
+			authApi.POST("/refresh", userHandler.RefreshToken)
+			authApi.GET("/confirm_deletion", accountHandler.ConfirmDeletionPage)
+			authApi.POST("/confirm_deletion", accountHandler.ConfirmDeletion)
+			authApi.POST("/logout", middleware.CheckAuthentication(r.DB), userHandler.Logout)
+			authApi.POST("/admin/logout", middleware.CheckAuthentication(r.DB), userHandler.Logout)
 		}
 
 		//
@@ -98,7 +128,10 @@ func (r *Router) SetupHandler() {
 	}
 
 	privateApi := r.Engine.Group("/api")
-	privateApi.Use(middleware.CheckAuthentication(r.DB))
+	// app responses are localized, the CMS works on the default content and its translations
+	privateApi.Use(middleware.CheckAuthentication(r.DB), middleware.Locale())
+	// the catalog is also open to partner servers, with an API key granted the scope of the group
+	catalogApi := r.Engine.Group("/api")
 	adminApi := r.Engine.Group("/api")
 	adminApi.Use(middleware.CheckAuthentication(r.DB), middleware.CheckRole())
 
@@ -108,24 +141,59 @@ func (r *Router) SetupHandler() {
 			userApi.PATCH("/:user_id", userHandler.Update)
 			userApi.GET("/info", userHandler.DetailUser)
 			userApi.POST("/change_password", userHandler.ChangePassword)
+			userApi.GET("/sessions", sessionHandler.ListMySessions)
+			userApi.DELETE("/sessions/:session_id", sessionHandler.RevokeMySession)
+			userApi.POST("/sessions/revoke_others", sessionHandler.RevokeMyOtherSessions)
+			userApi.GET("/2fa", twoFactorHandler.Status)
+			userApi.POST("/2fa/enroll", twoFactorHandler.Enroll)
+			userApi.POST("/2fa/enable", twoFactorHandler.Enable)
+			userApi.POST("/2fa/disable", twoFactorHandler.Disable)
+			userApi.POST("/2fa/recovery_codes", twoFactorHandler.RegenerateRecoveryCodes)
+			userApi.GET("/export", accountHandler.Export)
+			userApi.POST("/deletion", accountHandler.RequestDeletion)
+			userApi.DELETE("/deletion", accountHandler.CancelDeletion)
 		}
 
-		bannerApi := adminApi.Group("/banner")
+		profileApi := privateApi.Group("/app/profile")
+		{
+			profileApi.GET("/:user_id", profileHandler.DetailProfile)
+			profileApi.POST("/:user_id/follow", profileHandler.Follow)
+			profileApi.DELETE("/:user_id/follow", profileHandler.Unfollow)
+			profileApi.GET("/:user_id/followers", profileHandler.ListFollowers)
+			profileApi.GET("/:user_id/following", profileHandler.ListFollowing)
+		}
+
+		feedApi := privateApi.Group("/app/feed")
+		{
+			feedApi.GET("/", profileHandler.Feed)
+		}
+
+		notificationApi := privateApi.Group("/app/notification")
+		{
+			notificationApi.GET("/", notificationHandler.ListNotification)
+			notificationApi.POST("/:notification_id/read", notificationHandler.MarkRead)
+			notificationApi.POST("/read_all", notificationHandler.MarkAllRead)
+			notificationApi.GET("/preferences", notificationHandler.ListPreferences)
+			notificationApi.PUT("/preferences", notificationHandler.UpdatePreferences)
+		}
+
+		bannerApi := adminApi.Group("/banner", middleware.CheckPermission(r.DB, entities.PermissionContentManage))
 		{
 			bannerApi.POST("/", bannerHandler.CreateBanner)
 			bannerApi.GET("/", bannerHandler.ListBanner)
 			bannerApi.PATCH("/:banner_id", bannerHandler.Update)
 			bannerApi.GET("/:banner_id", bannerHandler.DetailBanner)
 			bannerApi.DELETE("/:banner_id", bannerHandler.DeleteBanner)
+			bannerApi.GET("/:banner_id/report", analyticsHandler.BannerReport)
 		}
 
 		bannerAppApi := privateApi.Group("/app/banner")
 		{
-			bannerAppApi.GET("/", bannerHandler.ListBanner)
-			bannerAppApi.GET("/:banner_id", bannerHandler.DetailBanner)
+			bannerAppApi.GET("/", bannerHandler.ListAppBanner)
+			bannerAppApi.GET("/:banner_id", bannerHandler.DetailAppBanner)
 		}
 
-		categoryApi := adminApi.Group("/category")
+		categoryApi := adminApi.Group("/category", middleware.CheckPermission(r.DB, entities.PermissionContentManage))
 		{
 			categoryApi.POST("/", categoryHandler.CreateCategory)
 			categoryApi.GET("/", categoryHandler.ListCategory)
@@ -134,13 +202,13 @@ func (r *Router) SetupHandler() {
 			categoryApi.DELETE("/:category_id", categoryHandler.DeleteCategory)
 		}
 
-		categoryAppApi := privateApi.Group("/app/category")
+		categoryAppApi := catalogApi.Group("/app/category", middleware.CheckAuthenticationOrAPIKey(r.DB, entities.APIKeyScopeCategoriesRead), middleware.Locale())
 		{
 			categoryAppApi.GET("/", categoryHandler.ListCategory)
 			categoryAppApi.GET("/:category_id", categoryHandler.DetailCategory)
 		}
 
-		placeApi := adminApi.Group("/place")
+		placeApi := adminApi.Group("/place", middleware.CheckPermission(r.DB, entities.PermissionContentManage))
 		{
 			placeApi.POST("/", placeHandler.CreatePlace)
 			placeApi.GET("/", placeHandler.ListPlacePaginate)
@@ -148,17 +216,24 @@ func (r *Router) SetupHandler() {
 			placeApi.GET("/:place_id", placeHandler.DetailPlace)
 			placeApi.DELETE("/:place_id", placeHandler.DeletePlace)
 			placeApi.GET("/all_places", placeHandler.ListAllPlace)
+			placeApi.POST("/:place_id/merge", placeHandler.MergePlace)
 		}
 
-		placeAppApi := privateApi.Group("/app/place")
+		placeAppApi := catalogApi.Group("/app/place", middleware.CheckAuthenticationOrAPIKey(r.DB, entities.APIKeyScopePlacesRead), middleware.Locale())
 		{
 			placeAppApi.GET("/", placeHandler.ListPlacePaginate)
 			placeAppApi.GET("/:place_id", placeHandler.DetailPlace)
 			placeAppApi.GET("/all_places", placeHandler.ListAllPlace)
-			placeAppApi.GET("/:place_id/comments", placeHandler.ListComment)
 			placeAppApi.GET("/suggest", placeHandler.ListSuggestPlace)
 		}
 
+		// reviews and visitor photos carry user content, not granted to API keys
+		placeCommunityAppApi := privateApi.Group("/app/place")
+		{
+			placeCommunityAppApi.GET("/:place_id/comments", placeHandler.ListComment)
+			placeCommunityAppApi.GET("/:place_id/photos", placeHandler.ListVisitorPhotos)
+		}
+
 		tripApi := privateApi.Group("/app/trip")
 		{
 			tripApi.POST("/", tripHandler.CreateTrip)
@@ -173,12 +248,50 @@ func (r *Router) SetupHandler() {
 			commentAppApi.POST("/", commentHandler.Create)
 			commentAppApi.PATCH("/:comment_id", commentHandler.Update)
 			commentAppApi.DELETE("/:comment_id", commentHandler.Delete)
+			commentAppApi.GET("/:comment_id/replies", commentHandler.ListReplies)
+			commentAppApi.PUT("/:comment_id/vote", commentHandler.Vote)
+			commentAppApi.DELETE("/:comment_id/vote", commentHandler.DeleteVote)
+			commentAppApi.POST("/:comment_id/report", commentModerationHandler.Report)
+		}
+
+		commentApi := adminApi.Group("/comment", middleware.CheckPermission(r.DB, entities.PermissionCommentsModerate))
+		{
+			commentApi.POST("/:comment_id/response", commentHandler.CreateOfficialResponse)
+			commentApi.GET("/moderation", commentModerationHandler.ListQueue)
+			commentApi.POST("/:comment_id/hide", commentModerationHandler.Hide)
+			commentApi.POST("/:comment_id/restore", commentModerationHandler.Restore)
+			commentApi.DELETE("/:comment_id", commentModerationHandler.Delete)
+			commentApi.GET("/:comment_id/moderation_logs", commentModerationHandler.ListLogs)
+		}
+
+		trackingAppApi := privateApi.Group("/app/tracking")
+		{
+			trackingAppApi.POST("/", analyticsHandler.Track)
 		}
 
-		userCmsApi := adminApi.Group("/user")
+		userCmsApi := adminApi.Group("/user", middleware.CheckPermission(r.DB, entities.PermissionUsersManage))
 		{
 			userCmsApi.GET("/", userHandler.ListUserPaginate)
 			userCmsApi.POST("/change_status", userHandler.UpdateStatus)
+			userCmsApi.POST("/:user_id/unlock", userHandler.UnlockUser)
+			userCmsApi.GET("/:user_id/sessions", sessionHandler.ListUserSessions)
+			userCmsApi.DELETE("/:user_id/sessions/:session_id", sessionHandler.RevokeUserSession)
+			userCmsApi.DELETE("/:user_id/sessions", sessionHandler.RevokeUserSessions)
+		}
+
+		roleApi := adminApi.Group("/role", middleware.CheckPermission(r.DB, entities.PermissionRolesManage))
+		{
+			roleApi.GET("/", roleHandler.ListRoles)
+			roleApi.GET("/user/:user_id", roleHandler.ListUserRoles)
+			roleApi.PUT("/user/:user_id", roleHandler.AssignUserRoles)
+		}
+
+		apiKeyApi := adminApi.Group("/api_key", middleware.CheckPermission(r.DB, entities.PermissionAPIKeysManage))
+		{
+			apiKeyApi.POST("/", apiKeyHandler.CreateAPIKey)
+			apiKeyApi.GET("/", apiKeyHandler.ListAPIKeys)
+			apiKeyApi.DELETE("/:api_key_id", apiKeyHandler.RevokeAPIKey)
+			apiKeyApi.GET("/:api_key_id/usage", apiKeyHandler.APIKeyUsage)
 		}
 	}
 }
@@ -0,0 +1,75 @@
+package interfaces
+
+import (
+	"context"
+	"go-server/internal/pkg/domains/models/dtos"
+	"go-server/internal/pkg/domains/models/entities"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// AccountRepository reads and erases everything stored about a user
+type AccountRepository interface {
+	FindTrips(
+		ctx context.Context,
+		userID int,
+	) ([]entities.Trip, error)
+	FindComments(
+		ctx context.Context,
+		userID int,
+	) ([]entities.Comment, error)
+	FindCommentVotes(
+		ctx context.Context,
+		userID int,
+	) ([]entities.CommentVote, error)
+	FindCommentReports(
+		ctx context.Context,
+		userID int,
+	) ([]entities.CommentReport, error)
+	FindIdentities(
+		ctx context.Context,
+		userID int,
+	) ([]entities.UserIdentity, error)
+	FindFollows(
+		ctx context.Context,
+		userID int,
+	) ([]entities.Follow, error)
+	FindNotifications(
+		ctx context.Context,
+		userID int,
+	) ([]entities.Notification, error)
+	FindDueForDeletion(
+		ctx context.Context,
+		before time.Time,
+		limit int,
+	) ([]entities.User, error)
+	// EraseWithTx anonymizes the comments of the user, deletes the rest of their data and the user
+	EraseWithTx(
+		tx *gorm.DB,
+		user entities.User,
+	) error
+}
+
+type AccountUsecase interface {
+	Export(
+		ctx context.Context,
+		userID int,
+	) (dtos.AccountExportDto, error)
+	RequestDeletion(
+		ctx context.Context,
+		userID int,
+	) error
+	ConfirmDeletion(
+		ctx context.Context,
+		token string,
+	) (entities.User, error)
+	CancelDeletion(
+		ctx context.Context,
+		userID int,
+	) error
+	PurgeDeletedAccounts(
+		ctx context.Context,
+		db *gorm.DB,
+	) (int, error)
+}
@@ -0,0 +1,49 @@
+package interfaces
+
+import (
+	"context"
+	"go-server/internal/pkg/domains/models/dtos"
+	"go-server/internal/pkg/domains/models/entities"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type AnalyticsRepository interface {
+	CreateEventWithTx(
+		tx *gorm.DB,
+		event entities.ContentEvent,
+	) (entities.ContentEvent, error)
+	IncrementDailyStatWithTx(
+		tx *gorm.DB,
+		stat entities.ContentDailyStat,
+	) error
+	FindDailyStats(
+		ctx context.Context,
+		contentType string,
+		contentID int,
+		from time.Time,
+		to time.Time,
+	) ([]entities.ContentDailyStat, error)
+	ContentExists(
+		ctx context.Context,
+		contentType string,
+		contentID int,
+	) (bool, error)
+}
+
+type AnalyticsUsecase interface {
+	Track(
+		ctx context.Context,
+		db *gorm.DB,
+		userID int,
+		req dtos.TrackEventsRequestDto,
+	) error
+	Report(
+		ctx context.Context,
+		contentType string,
+		contentID int,
+		from time.Time,
+		to time.Time,
+	) (dtos.ContentReportDto, error)
+}
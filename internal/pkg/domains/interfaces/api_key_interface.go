@@ -0,0 +1,54 @@
+package interfaces
+
+import (
+	"context"
+	"go-server/internal/pkg/domains/models/dtos"
+	"go-server/internal/pkg/domains/models/entities"
+	"time"
+)
+
+type APIKeyRepository interface {
+	Create(
+		ctx context.Context,
+		apiKey entities.APIKey,
+	) (entities.APIKey, error)
+	FindAll(
+		ctx context.Context,
+	) ([]entities.APIKey, error)
+	TakeByConditions(
+		ctx context.Context,
+		conditions map[string]interface{},
+	) (entities.APIKey, error)
+	UpdateColumns(
+		ctx context.Context,
+		apiKey entities.APIKey,
+		columns map[string]interface{},
+	) error
+	FindDailyUsages(
+		ctx context.Context,
+		apiKeyID int,
+		from time.Time,
+		to time.Time,
+	) ([]entities.APIKeyDailyUsage, error)
+}
+
+type APIKeyUsecase interface {
+	Create(
+		ctx context.Context,
+		createdBy int,
+		req dtos.CreateAPIKeyRequestDto,
+	) (dtos.CreateAPIKeyResponseDto, error)
+	FindAll(
+		ctx context.Context,
+	) ([]entities.APIKey, error)
+	Revoke(
+		ctx context.Context,
+		apiKeyID int,
+	) error
+	Usage(
+		ctx context.Context,
+		apiKeyID int,
+		from time.Time,
+		to time.Time,
+	) (dtos.APIKeyUsageReportDto, error)
+}
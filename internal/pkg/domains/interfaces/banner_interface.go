@@ -4,19 +4,22 @@ import (
 	"context"
 	"go-server/internal/pkg/domains/models/dtos"
 	"go-server/internal/pkg/domains/models/entities"
+	"time"
+
+	"gorm.io/gorm"
 )
 
 type BannerRepository interface {
-	Create(
-		ctx context.Context,
+	CreateWithTx(
+		tx *gorm.DB,
 		banner entities.Banner,
 	) (entities.Banner, error)
 	FindByConditions(
 		ctx context.Context,
 		conditions map[string]interface{},
 	) ([]entities.Banner, error)
-	Update(
-		ctx context.Context,
+	UpdateWithTx(
+		tx *gorm.DB,
 		banner entities.Banner,
 		newBanner entities.Banner,
 	) (entities.Banner, error)
@@ -28,11 +31,37 @@ type BannerRepository interface {
 		ctx context.Context,
 		conditions map[string]interface{},
 	) error
+	FindLive(
+		ctx context.Context,
+		now time.Time,
+	) ([]entities.Banner, error)
+	TakeLive(
+		ctx context.Context,
+		bannerID int,
+		now time.Time,
+	) (entities.Banner, error)
+	UpdateColumnsWithTx(
+		tx *gorm.DB,
+		banner entities.Banner,
+		columns map[string]interface{},
+	) (entities.Banner, error)
+	TargetExists(
+		ctx context.Context,
+		targetType string,
+		targetID int,
+	) (bool, error)
+	RetargetWithTx(
+		tx *gorm.DB,
+		targetType string,
+		fromTargetID int,
+		toTargetID int,
+	) error
 }
 
 type BannerUsecase interface {
 	Create(
 		ctx context.Context,
+		db *gorm.DB,
 		req dtos.CreateBannerRequestDto,
 	) (entities.Banner, error)
 	FindByConditions(
@@ -41,6 +70,7 @@ type BannerUsecase interface {
 	) ([]entities.Banner, error)
 	Update(
 		ctx context.Context,
+		db *gorm.DB,
 		req dtos.UpdateBannerRequestDto,
 		conditions map[string]interface{},
 	) (entities.Banner, error)
@@ -52,4 +82,12 @@ type BannerUsecase interface {
 		ctx context.Context,
 		conditions map[string]interface{},
 	) error
+	FindLive(
+		ctx context.Context,
+	) ([]entities.Banner, error)
+	// TakeLive is the banner if it is currently displayed in the app
+	TakeLive(
+		ctx context.Context,
+		bannerID int,
+	) (entities.Banner, error)
 }
@@ -4,19 +4,21 @@ import (
 	"context"
 	"go-server/internal/pkg/domains/models/dtos"
 	"go-server/internal/pkg/domains/models/entities"
+
+	"gorm.io/gorm"
 )
 
 type CategoryRepository interface {
-	Create(
-		ctx context.Context,
+	CreateWithTx(
+		tx *gorm.DB,
 		category entities.Category,
 	) (entities.Category, error)
 	FindByConditions(
 		ctx context.Context,
 		conditions map[string]interface{},
 	) ([]entities.Category, error)
-	Update(
-		ctx context.Context,
+	UpdateWithTx(
+		tx *gorm.DB,
 		category entities.Category,
 		updatedCategory entities.Category,
 	) (entities.Category, error)
@@ -36,11 +38,17 @@ type CategoryRepository interface {
 		ctx context.Context,
 		conditions map[string]interface{},
 	) ([]int, error)
+	UpdateParentWithTx(
+		tx *gorm.DB,
+		category entities.Category,
+		parentID *int,
+	) (entities.Category, error)
 }
 
 type CategoryUsecase interface {
 	Create(
 		ctx context.Context,
+		db *gorm.DB,
 		req dtos.CreateCategoryRequestDto,
 	) (entities.Category, error)
 	FindByConditions(
@@ -49,6 +57,7 @@ type CategoryUsecase interface {
 	) ([]entities.Category, error)
 	Update(
 		ctx context.Context,
+		db *gorm.DB,
 		req dtos.UpdateCategoryRequestDto,
 		conditions map[string]interface{},
 	) (entities.Category, error)
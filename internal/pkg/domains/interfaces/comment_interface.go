@@ -0,0 +1,150 @@
+package interfaces
+
+import (
+	"context"
+	"go-server/internal/pkg/domains/models/dtos"
+	"go-server/internal/pkg/domains/models/entities"
+
+	"gorm.io/gorm"
+)
+
+type CommentRepository interface {
+	Create(
+		ctx context.Context,
+		comment entities.Comment,
+	) (entities.Comment, error)
+	CreateWithTx(
+		tx *gorm.DB,
+		comment entities.Comment,
+	) (entities.Comment, error)
+	TakeByConditions(
+		ctx context.Context,
+		conditions map[string]interface{},
+	) (entities.Comment, error)
+	UpdateColumnsWithTx(
+		tx *gorm.DB,
+		comment entities.Comment,
+		columns map[string]interface{},
+	) (entities.Comment, error)
+	UpdateColumns(
+		ctx context.Context,
+		comment entities.Comment,
+		columns map[string]interface{},
+	) (entities.Comment, error)
+	DeleteThreadWithTx(
+		tx *gorm.DB,
+		commentID int,
+	) error
+	ReplaceImagesWithTx(
+		tx *gorm.DB,
+		commentID int,
+		urls []string,
+	) error
+	FindPlaceImagesPaginate(
+		ctx context.Context,
+		placeID int,
+		pageData map[string]int,
+	) ([]entities.CommentImage, int64, error)
+	FindListPaginate(
+		ctx context.Context,
+		pageData map[string]int,
+		conditions map[string]interface{},
+		order string,
+	) ([]entities.Comment, int64, error)
+	UpsertVoteWithTx(
+		tx *gorm.DB,
+		vote entities.CommentVote,
+	) error
+	DeleteVoteWithTx(
+		tx *gorm.DB,
+		commentID int,
+		userID int,
+	) error
+	RefreshVoteCountsWithTx(
+		tx *gorm.DB,
+		commentID int,
+	) error
+	FindVotesByUser(
+		ctx context.Context,
+		userID int,
+		commentIDs []int,
+	) ([]entities.CommentVote, error)
+	// CountReplies counts the published replies of each review, official responses excluded
+	CountReplies(
+		ctx context.Context,
+		reviewIDs []int,
+	) (map[int]int64, error)
+	// FindFirstReplies returns up to limit published replies of each review, in the order
+	// of the conversation
+	FindFirstReplies(
+		ctx context.Context,
+		reviewIDs []int,
+		limit int,
+	) ([]entities.Comment, error)
+	FindOfficialResponses(
+		ctx context.Context,
+		reviewIDs []int,
+	) ([]entities.Comment, error)
+}
+
+type CommentUsecase interface {
+	Create(
+		ctx context.Context,
+		db *gorm.DB,
+		userID int,
+		req dtos.CreateCommentRequestDto,
+	) (entities.Comment, bool, error)
+	Update(
+		ctx context.Context,
+		db *gorm.DB,
+		userID int,
+		moderator bool,
+		commentID int,
+		req dtos.UpdateCommentRequestDto,
+	) (entities.Comment, error)
+	Delete(
+		ctx context.Context,
+		db *gorm.DB,
+		userID int,
+		moderator bool,
+		commentID int,
+	) error
+	FindReviewsPaginate(
+		ctx context.Context,
+		userID int,
+		pageData map[string]int,
+		conditions map[string]interface{},
+		order string,
+		repliesPerPage int,
+	) ([]entities.Comment, int64, error)
+	FindRepliesPaginate(
+		ctx context.Context,
+		userID int,
+		commentID int,
+		pageData map[string]int,
+	) ([]entities.Comment, int64, error)
+	FindPlacePhotosPaginate(
+		ctx context.Context,
+		placeID int,
+		pageData map[string]int,
+	) ([]dtos.VisitorPhotoDto, int64, error)
+	RespondOfficially(
+		ctx context.Context,
+		userID int,
+		commentID int,
+		req dtos.OfficialResponseRequestDto,
+	) (entities.Comment, error)
+	Vote(
+		ctx context.Context,
+		db *gorm.DB,
+		userID int,
+		commentID int,
+		req dtos.VoteCommentRequestDto,
+	) (entities.Comment, error)
+	DeleteVote(
+		ctx context.Context,
+		db *gorm.DB,
+		userID int,
+		commentID int,
+	) (entities.Comment, error)
+}
@@ -0,0 +1,77 @@
+package interfaces
+
+import (
+	"context"
+	"go-server/internal/pkg/domains/models/dtos"
+	"go-server/internal/pkg/domains/models/entities"
+
+	"gorm.io/gorm"
+)
+
+type CommentModerationRepository interface {
+	UpsertReport(
+		ctx context.Context,
+		report entities.CommentReport,
+	) error
+	ResolveReportsWithTx(
+		tx *gorm.DB,
+		commentID int,
+	) error
+	UpdateStatusWithTx(
+		tx *gorm.DB,
+		commentID int,
+		status string,
+	) error
+	CreateLogWithTx(
+		tx *gorm.DB,
+		log entities.CommentModerationLog,
+	) error
+	FindLogs(
+		ctx context.Context,
+		commentID int,
+	) ([]entities.CommentModerationLog, error)
+	FindQueuePaginate(
+		ctx context.Context,
+		pageData map[string]int,
+		queue string,
+	) ([]entities.Comment, int64, error)
+}
+
+type CommentModerationUsecase interface {
+	Report(
+		ctx context.Context,
+		userID int,
+		commentID int,
+		req dtos.ReportCommentRequestDto,
+	) error
+	FindQueuePaginate(
+		ctx context.Context,
+		pageData map[string]int,
+		queue string,
+	) ([]entities.Comment, int64, error)
+	Hide(
+		ctx context.Context,
+		db *gorm.DB,
+		adminID int,
+		commentID int,
+		req dtos.ModerateCommentRequestDto,
+	) error
+	Restore(
+		ctx context.Context,
+		db *gorm.DB,
+		adminID int,
+		commentID int,
+		req dtos.ModerateCommentRequestDto,
+	) error
+	Delete(
+		ctx context.Context,
+		db *gorm.DB,
+		adminID int,
+		commentID int,
+		req dtos.ModerateCommentRequestDto,
+	) error
+	FindLogs(
+		ctx context.Context,
+		commentID int,
+	) ([]entities.CommentModerationLog, error)
+}
@@ -0,0 +1,62 @@
+package interfaces
+
+import (
+	"context"
+	"go-server/internal/pkg/domains/models/entities"
+	"time"
+)
+
+// LoginAttemptStore keeps the failed login counters, in memory for a single instance
+// or in the database when they are shared by several
+type LoginAttemptStore interface {
+	// Take returns the counters of the key, the zero value when there are none
+	Take(
+		ctx context.Context,
+		key string,
+	) (entities.LoginAttempt, error)
+	// AddFailure counts a failure, failures before since are forgotten
+	AddFailure(
+		ctx context.Context,
+		key string,
+		since time.Time,
+	) (entities.LoginAttempt, error)
+	Lock(
+		ctx context.Context,
+		key string,
+		until time.Time,
+	) error
+	Reset(
+		ctx context.Context,
+		key string,
+	) error
+}
+
+type LoginAttemptUsecase interface {
+	Check(
+		ctx context.Context,
+		email string,
+		ip string,
+	) error
+	RegisterFailure(
+		ctx context.Context,
+		email string,
+		ip string,
+	) error
+	RegisterSuccess(
+		ctx context.Context,
+		email string,
+	) error
+	Lock(
+		ctx context.Context,
+		email string,
+	) error
+	Unlock(
+		ctx context.Context,
+		userID int,
+	) error
+	RegisterPasswordReset(
+		ctx context.Context,
+		email string,
+		ip string,
+	) error
+}
@@ -0,0 +1,80 @@
+package interfaces
+
+import (
+	"context"
+	"go-server/internal/pkg/domains/models/dtos"
+	"go-server/internal/pkg/domains/models/entities"
+)
+
+type NotificationRepository interface {
+	Create(
+		ctx context.Context,
+		notification entities.Notification,
+	) (entities.Notification, error)
+	FindPaginate(
+		ctx context.Context,
+		userID int,
+		pageData map[string]int,
+		unreadOnly bool,
+	) ([]entities.Notification, int64, error)
+	CountUnread(
+		ctx context.Context,
+		userID int,
+	) (int64, error)
+	TakeByConditions(
+		ctx context.Context,
+		conditions map[string]interface{},
+	) (entities.Notification, error)
+	// MarkRead marks the unread notifications of the user as read, all of them when ids is empty
+	MarkRead(
+		ctx context.Context,
+		userID int,
+		ids []int,
+	) (int64, error)
+	FindPreferences(
+		ctx context.Context,
+		userID int,
+	) ([]entities.NotificationPreference, error)
+	UpsertPreferences(
+		ctx context.Context,
+		preferences []entities.NotificationPreference,
+	) error
+}
+
+type NotificationUsecase interface {
+	// Notify delivers the notification to the user in the app and by email as their
+	// preference for its type decides
+	Notify(
+		ctx context.Context,
+		userID int,
+		notification entities.Notification,
+	) error
+	FindPaginate(
+		ctx context.Context,
+		userID int,
+		pageData map[string]int,
+		unreadOnly bool,
+	) ([]entities.Notification, int64, error)
+	CountUnread(
+		ctx context.Context,
+		userID int,
+	) (int64, error)
+	MarkRead(
+		ctx context.Context,
+		userID int,
+		notificationID int,
+	) (entities.Notification, error)
+	MarkAllRead(
+		ctx context.Context,
+		userID int,
+	) (int64, error)
+	FindPreferences(
+		ctx context.Context,
+		userID int,
+	) ([]entities.NotificationPreference, error)
+	UpdatePreferences(
+		ctx context.Context,
+		userID int,
+		req dtos.UpdateNotificationPreferencesRequestDto,
+	) ([]entities.NotificationPreference, error)
+}
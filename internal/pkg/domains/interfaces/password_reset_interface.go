@@ -0,0 +1,27 @@
+package interfaces
+
+import (
+	"context"
+	"go-server/internal/pkg/domains/models/entities"
+
+	"gorm.io/gorm"
+)
+
+type PasswordResetTokenRepository interface {
+	CreateWithTx(
+		tx *gorm.DB,
+		token entities.PasswordResetToken,
+	) (entities.PasswordResetToken, error)
+	TakeByConditions(
+		ctx context.Context,
+		conditions map[string]interface{},
+	) (entities.PasswordResetToken, error)
+	MarkUsedWithTx(
+		tx *gorm.DB,
+		token entities.PasswordResetToken,
+	) (bool, error)
+	InvalidateByUserWithTx(
+		tx *gorm.DB,
+		userID int,
+	) error
+}
@@ -15,6 +15,11 @@ type PlaceCategoryRepository interface {
 		tx *gorm.DB,
 		conditions map[string]interface{},
 	) error
+	MovePlaceWithTx(
+		tx *gorm.DB,
+		fromPlaceID int,
+		toPlaceID int,
+	) error
 }
 
 type PlaceCategoryUsecase interface{}
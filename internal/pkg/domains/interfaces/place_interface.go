@@ -39,6 +39,27 @@ type PlaceRepository interface {
 		ctx context.Context,
 		conditions map[string]interface{},
 	) ([]entities.Place, error)
+	FindInBoundingBox(
+		ctx context.Context,
+		minLatitude float64,
+		maxLatitude float64,
+		minLongitude float64,
+		maxLongitude float64,
+	) ([]entities.Place, error)
+	MoveCommentsWithTx(
+		tx *gorm.DB,
+		fromPlaceID int,
+		toPlaceID int,
+	) error
+	FindDaysByPlaceIDWithTx(
+		tx *gorm.DB,
+		placeID int,
+	) ([]entities.Day, error)
+	UpdateDayPlacesWithTx(
+		tx *gorm.DB,
+		day entities.Day,
+		places string,
+	) error
 }
 
 type PlaceUsecase interface {
@@ -71,4 +92,14 @@ type PlaceUsecase interface {
 		ctx context.Context,
 		conditions map[string]interface{},
 	) ([]entities.Place, error)
+	FindDuplicates(
+		ctx context.Context,
+		place entities.Place,
+	) ([]dtos.DuplicatePlaceDto, error)
+	Merge(
+		ctx context.Context,
+		db *gorm.DB,
+		survivorID int,
+		duplicateID int,
+	) (entities.Place, error)
 }
@@ -0,0 +1,107 @@
+package interfaces
+
+import (
+	"context"
+	"go-server/internal/pkg/domains/models/dtos"
+	"go-server/internal/pkg/domains/models/entities"
+)
+
+type ProfileRepository interface {
+	TakeActiveUser(
+		ctx context.Context,
+		userID int,
+	) (entities.User, error)
+	CountReviews(
+		ctx context.Context,
+		userID int,
+	) (int64, error)
+	FindPublicTrips(
+		ctx context.Context,
+		userID int,
+	) ([]entities.Trip, error)
+	Follow(
+		ctx context.Context,
+		followerID int,
+		followeeID int,
+	) error
+	Unfollow(
+		ctx context.Context,
+		followerID int,
+		followeeID int,
+	) error
+	IsFollowing(
+		ctx context.Context,
+		followerID int,
+		followeeID int,
+	) (bool, error)
+	CountFollowers(
+		ctx context.Context,
+		userID int,
+	) (int64, error)
+	CountFollowing(
+		ctx context.Context,
+		userID int,
+	) (int64, error)
+	FindFollowersPaginate(
+		ctx context.Context,
+		userID int,
+		pageData map[string]int,
+	) ([]entities.User, int64, error)
+	FindFollowingPaginate(
+		ctx context.Context,
+		userID int,
+		pageData map[string]int,
+	) ([]entities.User, int64, error)
+	// FindFeedTrips and FindFeedReviews return the items of the followed users after the cursor,
+	// in the order of the feed
+	FindFeedTrips(
+		ctx context.Context,
+		followerID int,
+		cursor *dtos.FeedCursor,
+		limit int,
+	) ([]entities.Trip, error)
+	FindFeedReviews(
+		ctx context.Context,
+		followerID int,
+		cursor *dtos.FeedCursor,
+		limit int,
+	) ([]entities.Comment, error)
+	FindUsersByIDs(
+		ctx context.Context,
+		userIDs []int,
+	) ([]entities.User, error)
+}
+
+type ProfileUsecase interface {
+	Detail(
+		ctx context.Context,
+		viewerID int,
+		userID int,
+	) (dtos.ProfileDto, error)
+	Follow(
+		ctx context.Context,
+		followerID int,
+		followeeID int,
+	) error
+	Unfollow(
+		ctx context.Context,
+		followerID int,
+		followeeID int,
+	) error
+	FindFollowersPaginate(
+		ctx context.Context,
+		userID int,
+		pageData map[string]int,
+	) ([]dtos.PublicUserDto, int64, error)
+	FindFollowingPaginate(
+		ctx context.Context,
+		userID int,
+		pageData map[string]int,
+	) ([]dtos.PublicUserDto, int64, error)
+	Feed(
+		ctx context.Context,
+		userID int,
+		cursor string,
+		limit int,
+	) (dtos.FeedDto, error)
+}
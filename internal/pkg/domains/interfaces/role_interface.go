@@ -0,0 +1,48 @@
+package interfaces
+
+import (
+	"context"
+	"go-server/internal/pkg/domains/models/dtos"
+	"go-server/internal/pkg/domains/models/entities"
+
+	"gorm.io/gorm"
+)
+
+type RoleRepository interface {
+	FindAll(
+		ctx context.Context,
+	) ([]entities.Role, error)
+	FindByNames(
+		ctx context.Context,
+		names []string,
+	) ([]entities.Role, error)
+	FindByUser(
+		ctx context.Context,
+		userID int,
+	) ([]entities.Role, error)
+	LockUserIDsWithRoleWithTx(
+		tx *gorm.DB,
+		roleName string,
+	) ([]int, error)
+	ReplaceUserRolesWithTx(
+		tx *gorm.DB,
+		user entities.User,
+		roles []entities.Role,
+	) error
+}
+
+type RoleUsecase interface {
+	FindAll(
+		ctx context.Context,
+	) ([]entities.Role, error)
+	FindUserRoles(
+		ctx context.Context,
+		userID int,
+	) ([]entities.Role, error)
+	AssignUserRoles(
+		ctx context.Context,
+		db *gorm.DB,
+		userID int,
+		req dtos.AssignRolesRequestDto,
+	) ([]entities.Role, error)
+}
@@ -0,0 +1,28 @@
+package interfaces
+
+import (
+	"context"
+	"go-server/internal/pkg/domains/models/entities"
+
+	"gorm.io/gorm"
+)
+
+type TranslationRepository interface {
+	ReplaceWithTx(
+		tx *gorm.DB,
+		entityType string,
+		entityID int,
+		translations []entities.Translation,
+	) error
+	MergeWithTx(
+		tx *gorm.DB,
+		entityType string,
+		fromEntityID int,
+		toEntityID int,
+	) error
+	FindByEntities(
+		ctx context.Context,
+		entityType string,
+		entityIDs []int,
+	) ([]entities.Translation, error)
+}
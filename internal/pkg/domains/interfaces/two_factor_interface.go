@@ -0,0 +1,68 @@
+package interfaces
+
+import (
+	"context"
+	"go-server/internal/pkg/domains/models/dtos"
+	"go-server/internal/pkg/domains/models/entities"
+
+	"gorm.io/gorm"
+)
+
+type UserRecoveryCodeRepository interface {
+	ReplaceWithTx(
+		tx *gorm.DB,
+		userID int,
+		codeHashes []string,
+	) error
+	DeleteByUserWithTx(
+		tx *gorm.DB,
+		userID int,
+	) error
+	Use(
+		ctx context.Context,
+		userID int,
+		codeHash string,
+	) (bool, error)
+	CountUnused(
+		ctx context.Context,
+		userID int,
+	) (int64, error)
+}
+
+type TwoFactorUsecase interface {
+	Status(
+		ctx context.Context,
+		userID int,
+	) (dtos.TwoFactorStatusDto, error)
+	Enroll(
+		ctx context.Context,
+		userID int,
+	) (dtos.TwoFactorEnrollmentDto, error)
+	Enable(
+		ctx context.Context,
+		db *gorm.DB,
+		userID int,
+		req dtos.TwoFactorCodeRequestDto,
+	) ([]string, error)
+	Disable(
+		ctx context.Context,
+		db *gorm.DB,
+		userID int,
+		req dtos.TwoFactorProofDto,
+	) error
+	RegenerateRecoveryCodes(
+		ctx context.Context,
+		db *gorm.DB,
+		userID int,
+		req dtos.TwoFactorCodeRequestDto,
+	) ([]string, error)
+	Challenge(
+		ctx context.Context,
+		user entities.User,
+		admin bool,
+	) (dtos.TwoFactorChallengeDto, error)
+	VerifyChallenge(
+		ctx context.Context,
+		req dtos.TwoFactorLoginRequestDto,
+	) (entities.User, error)
+}
@@ -7,4 +7,7 @@ type UploadUsecase interface {
 		ctx context.Context,
 		file interface{},
 	) (string, error)
+	IsUploadedURL(
+		url string,
+	) bool
 }
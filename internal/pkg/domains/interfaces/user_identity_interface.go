@@ -0,0 +1,28 @@
+package interfaces
+
+import (
+	"context"
+	"go-server/internal/pkg/domains/models/dtos"
+	"go-server/internal/pkg/domains/models/entities"
+
+	"gorm.io/gorm"
+)
+
+type UserIdentityRepository interface {
+	TakeByConditions(
+		ctx context.Context,
+		conditions map[string]interface{},
+	) (entities.UserIdentity, error)
+	CreateWithTx(
+		tx *gorm.DB,
+		identity entities.UserIdentity,
+	) (entities.UserIdentity, error)
+}
+
+type OAuthUsecase interface {
+	Login(
+		ctx context.Context,
+		db *gorm.DB,
+		identity dtos.OAuthIdentityDto,
+	) (entities.User, error)
+}
@@ -4,16 +4,40 @@ import (
 	"context"
 	"go-server/internal/pkg/domains/models/dtos"
 	"go-server/internal/pkg/domains/models/entities"
+	"time"
+
+	"gorm.io/gorm"
 )
 
 type UserRepository interface {
 	Create(ctx context.Context, user entities.User) (entities.User, error)
+	CreateWithTx(tx *gorm.DB, user entities.User) (entities.User, error)
 	TakeByConditions(ctx context.Context, conditions map[string]interface{}) (entities.User, error)
 	Update(
 		ctx context.Context,
 		user entities.User,
 		req dtos.UpdateUserRequestDto,
 	) (entities.User, error)
+	UpdateColumns(
+		ctx context.Context,
+		user entities.User,
+		columns map[string]interface{},
+	) (entities.User, error)
+	UpdateColumnsWithTx(
+		tx *gorm.DB,
+		user entities.User,
+		columns map[string]interface{},
+	) (entities.User, error)
+	AdvanceTOTPStep(
+		ctx context.Context,
+		userID int,
+		step int64,
+	) (bool, error)
+	ConsumeTwoFactorChallenge(
+		ctx context.Context,
+		userID int,
+		challengeHash string,
+	) (bool, error)
 }
 
 type UserUsecase interface {
@@ -22,10 +46,38 @@ type UserUsecase interface {
 	Login(
 		ctx context.Context,
 		req dtos.LoginRequestDto,
-	) (entities.User, string, string, error)
+	) (entities.User, error)
 	Update(
 		ctx context.Context,
 		conditions map[string]interface{},
 		req dtos.UpdateUserRequestDto,
 	) (entities.User, error)
+	SendVerificationEmail(
+		ctx context.Context,
+		user entities.User,
+	) error
+	ResendVerificationEmail(
+		ctx context.Context,
+		req dtos.ResendVerificationRequestDto,
+	) (time.Duration, error)
+	VerifyEmail(
+		ctx context.Context,
+		token string,
+	) (entities.User, error)
+	ForgotPassword(
+		ctx context.Context,
+		db *gorm.DB,
+		req dtos.ForgotPasswordRequestDto,
+	) error
+	ResetPassword(
+		ctx context.Context,
+		db *gorm.DB,
+		req dtos.ResetPasswordRequestDto,
+	) error
+	UpdateStatus(
+		ctx context.Context,
+		db *gorm.DB,
+		user entities.User,
+		active bool,
+	) error
 }
@@ -0,0 +1,86 @@
+package interfaces
+
+import (
+	"context"
+	"go-server/internal/pkg/domains/models/dtos"
+	"go-server/internal/pkg/domains/models/entities"
+
+	"gorm.io/gorm"
+)
+
+type UserTokenRepository interface {
+	Create(
+		ctx context.Context,
+		token entities.UserToken,
+	) (entities.UserToken, error)
+	CreateWithTx(
+		tx *gorm.DB,
+		token entities.UserToken,
+	) (entities.UserToken, error)
+	TakeByConditions(
+		ctx context.Context,
+		conditions map[string]interface{},
+	) (entities.UserToken, error)
+	FindActiveByUser(
+		ctx context.Context,
+		userID int,
+	) ([]entities.UserToken, error)
+	MarkRotatedWithTx(
+		tx *gorm.DB,
+		token entities.UserToken,
+	) (bool, error)
+	FindTokenIDsOfFamily(
+		ctx context.Context,
+		familyID string,
+	) ([]string, error)
+	DeleteFamily(
+		ctx context.Context,
+		familyID string,
+	) error
+	DeleteByConditions(
+		ctx context.Context,
+		conditions map[string]interface{},
+	) error
+	DeleteByConditionsWithTx(
+		tx *gorm.DB,
+		conditions map[string]interface{},
+	) error
+}
+
+type TokenUsecase interface {
+	Issue(
+		ctx context.Context,
+		user entities.User,
+		client dtos.ClientDto,
+	) (dtos.TokenPairDto, error)
+	IssueTwoFactor(
+		ctx context.Context,
+		user entities.User,
+		client dtos.ClientDto,
+	) (dtos.TokenPairDto, error)
+	Refresh(
+		ctx context.Context,
+		db *gorm.DB,
+		req dtos.RefreshTokenRequestDto,
+		client dtos.ClientDto,
+	) (dtos.TokenPairDto, error)
+	Revoke(
+		ctx context.Context,
+		tokenID string,
+	) error
+	FindSessions(
+		ctx context.Context,
+		userID int,
+		currentTokenID string,
+	) ([]dtos.SessionDto, error)
+	RevokeSession(
+		ctx context.Context,
+		userID int,
+		sessionID int,
+	) error
+	RevokeOtherSessions(
+		ctx context.Context,
+		userID int,
+		currentTokenID string,
+	) error
+}
@@ -0,0 +1,23 @@
+package dtos
+
+import "go-server/internal/pkg/domains/models/entities"
+
+// AccountExportDto is the personal data of a user, downloaded by them as JSON or as a ZIP
+// with one file per field
+type AccountExportDto struct {
+	ExportedAt     int64                    `json:"exported_at"`
+	Profile        entities.User            `json:"profile"`
+	Trips          []entities.Trip          `json:"trips"`
+	Comments       []entities.Comment       `json:"comments"`
+	CommentVotes   []entities.CommentVote   `json:"comment_votes"`
+	CommentReports []entities.CommentReport `json:"comment_reports"`
+	Sessions       []SessionDto             `json:"sessions"`
+	Identities     []entities.UserIdentity  `json:"identities"`
+	Following      []entities.Follow        `json:"following"`
+	Notifications  []entities.Notification  `json:"notifications"`
+}
+
+// ConfirmDeletionRequestDto is posted by the page of the emailed deletion link, as a form or as JSON
+type ConfirmDeletionRequestDto struct {
+	Token string `form:"token" json:"token" binding:"required"`
+}
@@ -0,0 +1,29 @@
+package dtos
+
+type TrackEventsRequestDto struct {
+	Events []TrackEventDto `json:"events" binding:"required,min=1,max=100,dive"`
+}
+
+type TrackEventDto struct {
+	ContentType string `json:"content_type" binding:"required,oneof=banner"`
+	ContentID   int    `json:"content_id" binding:"required,min=1"`
+	Event       string `json:"event" binding:"required,oneof=impression click"`
+}
+
+type ContentReportDto struct {
+	ContentType string                  `json:"content_type"`
+	ContentID   int                     `json:"content_id"`
+	From        string                  `json:"from"`
+	To          string                  `json:"to"`
+	Impressions int64                   `json:"impressions"`
+	Clicks      int64                   `json:"clicks"`
+	CTR         float64                 `json:"ctr"`
+	Days        []ContentDailyReportDto `json:"days"`
+}
+
+type ContentDailyReportDto struct {
+	Date        string  `json:"date"`
+	Impressions int64   `json:"impressions"`
+	Clicks      int64   `json:"clicks"`
+	CTR         float64 `json:"ctr"`
+}
@@ -0,0 +1,31 @@
+package dtos
+
+import (
+	"go-server/internal/pkg/domains/models/entities"
+	"time"
+)
+
+type CreateAPIKeyRequestDto struct {
+	Name      string     `json:"name" binding:"required,max=255"`
+	Scopes    []string   `json:"scopes" binding:"required,min=1,dive,required"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// CreateAPIKeyResponseDto carries the key itself, it is only ever shown here
+type CreateAPIKeyResponseDto struct {
+	APIKey entities.APIKey `json:"api_key"`
+	Key    string          `json:"key"`
+}
+
+type APIKeyUsageReportDto struct {
+	APIKeyID int                   `json:"api_key_id"`
+	From     string                `json:"from"`
+	To       string                `json:"to"`
+	Requests int64                 `json:"requests"`
+	Days     []APIKeyDailyUsageDto `json:"days"`
+}
+
+type APIKeyDailyUsageDto struct {
+	Date     string `json:"date"`
+	Requests int64  `json:"requests"`
+}
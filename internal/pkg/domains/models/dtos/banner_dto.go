@@ -3,19 +3,48 @@ package dtos
 import "go-server/internal/pkg/domains/models/entities"
 
 type CreateBannerRequestDto struct {
-	Name  string `json:"name" binding:"required"`
-	Image string `json:"image" binding:"required"`
+	Name       string `json:"name" binding:"required"`
+	Image      string `json:"image" binding:"required"`
+	StartAt    int    `json:"start_at" binding:"min=0"` // unix time, 0: displayed right away
+	EndAt      int    `json:"end_at" binding:"min=0"`   // unix time, 0: never ends
+	Position   int    `json:"position"`
+	Active     *bool  `json:"active"` // default true
+	TargetType string `json:"target_type" binding:"omitempty,oneof=place category trip_template url"`
+	TargetID   int    `json:"target_id"`
+	TargetURL  string `json:"target_url" binding:"omitempty,url"`
+	// translations by locale, e.g. {"en": {"name": "..."}}
+	Translations map[string]BannerTranslationDto `json:"translations"`
 }
 
 type CreateBannerResponseDto struct {
 	Banner entities.Banner `json:"banner"`
 }
 
+// The pointer fields are left unchanged when not sent
 type UpdateBannerRequestDto struct {
-	Name  string `json:"name"`
-	Image string `json:"image"`
+	Name       string  `json:"name"`
+	Image      string  `json:"image"`
+	StartAt    *int    `json:"start_at" binding:"omitempty,min=0"` // 0 removes the start
+	EndAt      *int    `json:"end_at" binding:"omitempty,min=0"`   // 0 removes the end
+	Position   *int    `json:"position"`
+	Active     *bool   `json:"active"`
+	TargetType *string `json:"target_type" binding:"omitempty,oneof=none place category trip_template url"` // "none" removes the target
+	TargetID   int     `json:"target_id"`
+	TargetURL  string  `json:"target_url" binding:"omitempty,url"`
+	// translations by locale, e.g. {"en": {"name": "..."}}
+	Translations map[string]BannerTranslationDto `json:"translations"`
 }
 
 type UpdateBannerResponseDto struct {
 	Banner entities.Banner `json:"banner"`
 }
+
+type BannerTranslationDto struct {
+	Name string `json:"name"`
+}
+
+func (d BannerTranslationDto) Fields() map[string]string {
+	return map[string]string{
+		entities.TranslationFieldName: d.Name,
+	}
+}
@@ -1,13 +1,34 @@
 package dtos
 
+import "go-server/internal/pkg/domains/models/entities"
+
 type CreateCategoryRequestDto struct {
 	Name        string `json:"name" binding:"required"`
 	Icon        string `json:"icon" binding:"required"`
 	Description string `json:"description"`
+	ParentID    *int   `json:"parent_id"`
+	// translations by locale, e.g. {"en": {"name": "...", "description": "..."}}
+	Translations map[string]CategoryTranslationDto `json:"translations"`
 }
 
 type UpdateCategoryRequestDto struct {
 	Name        string `json:"name"`
 	Icon        string `json:"icon"`
 	Description string `json:"description"`
+	// not sent: parent unchanged, 0: move to the root
+	ParentID *int `json:"parent_id"`
+	// translations by locale, e.g. {"en": {"name": "...", "description": "..."}}
+	Translations map[string]CategoryTranslationDto `json:"translations"`
+}
+
+type CategoryTranslationDto struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+func (d CategoryTranslationDto) Fields() map[string]string {
+	return map[string]string{
+		entities.TranslationFieldName:        d.Name,
+		entities.TranslationFieldDescription: d.Description,
+	}
 }
@@ -1,13 +1,45 @@
 package dtos
 
+// CreateCommentRequestDto posts a review, the author is the authenticated user
 type CreateCommentRequestDto struct {
-	Rate    int `json:"rate"`
-	Comment string  `json:"comment"`
-	PlaceID int     `json:"place_id" binding:"required"`
-	UserID  int     `json:"user_id" binding:"required"`
+	Rate            int      `json:"rate"`
+	Comment         string   `json:"comment"`
+	PlaceID         int      `json:"place_id" binding:"required_without=ParentCommentID"`
+	ParentCommentID int      `json:"parent_comment_id"` // set to reply to a review, the rate is then ignored
+	Images          []string `json:"images"`            // urls returned by the upload API
 }
 
 type UpdateCommentRequestDto struct {
-	Rate    int `json:"rate"`
-	Comment string  `json:"comment"`
+	Rate    int       `json:"rate"`
+	Comment string    `json:"comment"`
+	Images  *[]string `json:"images"` // nil keeps the current images
+}
+
+type OfficialResponseRequestDto struct {
+	Comment string `json:"comment" binding:"required,min=1"`
+}
+
+type VoteCommentRequestDto struct {
+	Vote string `json:"vote" binding:"required,oneof=helpful not_helpful"`
+}
+
+type ReportCommentRequestDto struct {
+	Reason string `json:"reason" binding:"required,oneof=spam offensive harassment off_topic misleading other"`
+	Text   string `json:"text" binding:"max=1000"`
+}
+
+// ModerateCommentRequestDto is the note of the moderator kept in the audit trail
+type ModerateCommentRequestDto struct {
+	Note string `json:"note" binding:"max=1000"`
+}
+
+// VisitorPhotoDto is a photo of the visitor gallery of a place
+type VisitorPhotoDto struct {
+	ID        int    `json:"id"`
+	URL       string `json:"url"`
+	CommentID int    `json:"comment_id"`
+	UserID    int    `json:"user_id"`
+	Username  string `json:"username"`
+	Avatar    string `json:"avatar"`
+	CreatedAt int64  `json:"created_at"`
 }
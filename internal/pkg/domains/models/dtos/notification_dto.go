@@ -0,0 +1,12 @@
+package dtos
+
+type NotificationPreferenceRequestDto struct {
+	Type  string `json:"type" binding:"required"`
+	InApp *bool  `json:"in_app" binding:"required"`
+	Email *bool  `json:"email" binding:"required"`
+}
+
+// UpdateNotificationPreferencesRequestDto changes the listed types, the others are left as they are
+type UpdateNotificationPreferencesRequestDto struct {
+	Preferences []NotificationPreferenceRequestDto `json:"preferences" binding:"required,min=1,dive"`
+}
@@ -1,5 +1,7 @@
 package dtos
 
+import "go-server/internal/pkg/domains/models/entities"
+
 type CreatePlaceRequestDto struct {
 	Name        string   `json:"name" binding:"required,min=1"`
 	Address     string   `json:"address" binding:"required,min=1"`
@@ -9,6 +11,8 @@ type CreatePlaceRequestDto struct {
 	Images      []string `json:"images" binding:"required"`
 	Price       float64  `json:"price" binding:"required,min=1"`
 	Categories  []int    `json:"categories" binding:"required"`
+	// translations by locale, e.g. {"en": {"name": "...", "description": "..."}}
+	Translations map[string]PlaceTranslationDto `json:"translations"`
 }
 
 type UpdatePlaceRequestDto struct {
@@ -20,4 +24,28 @@ type UpdatePlaceRequestDto struct {
 	Images      []string `json:"images" binding:"required"`
 	Price       float64  `json:"price" binding:"required,min=1"`
 	Categories  []int    `json:"categories" binding:"required"`
+	// translations by locale, e.g. {"en": {"name": "...", "description": "..."}}
+	Translations map[string]PlaceTranslationDto `json:"translations"`
+}
+
+type PlaceTranslationDto struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+func (d PlaceTranslationDto) Fields() map[string]string {
+	return map[string]string{
+		entities.TranslationFieldName:        d.Name,
+		entities.TranslationFieldDescription: d.Description,
+	}
+}
+
+type DuplicatePlaceDto struct {
+	Place      entities.Place `json:"place"`
+	Distance   float64        `json:"distance"`
+	Similarity float64        `json:"similarity"`
+}
+
+type MergePlaceRequestDto struct {
+	DuplicateID int `json:"duplicate_id" binding:"required,min=1"`
 }
@@ -0,0 +1,47 @@
+package dtos
+
+import "go-server/internal/pkg/domains/models/entities"
+
+// PublicUserDto is what other users can see of a user
+type PublicUserDto struct {
+	ID       int    `json:"id"`
+	Username string `json:"username"`
+	Avatar   string `json:"avatar"`
+}
+
+type ProfileDto struct {
+	PublicUserDto
+	ReviewCount    int64           `json:"review_count"`
+	FollowerCount  int64           `json:"follower_count"`
+	FollowingCount int64           `json:"following_count"`
+	Following      bool            `json:"following"` // the caller follows the user
+	Trips          []entities.Trip `json:"trips"`     // public trips only
+}
+
+const (
+	FeedItemTrip   = "trip"
+	FeedItemReview = "review"
+)
+
+// FeedItemDto is a public trip or a review of a followed user, Type tells which one is set
+type FeedItemDto struct {
+	Type      string            `json:"type"`
+	CreatedAt int64             `json:"created_at"`
+	User      PublicUserDto     `json:"user"`
+	Trip      *entities.Trip    `json:"trip,omitempty"`
+	Review    *entities.Comment `json:"review,omitempty"`
+}
+
+// FeedDto is a page of the feed, NextCursor is empty on the last one
+type FeedDto struct {
+	Items      []FeedItemDto `json:"items"`
+	NextCursor string        `json:"next_cursor"`
+}
+
+// FeedCursor is the position of the last item of a page, the feed is ordered by
+// created_at descending then type then id descending
+type FeedCursor struct {
+	CreatedAt int64  `json:"t"`
+	Type      string `json:"k"`
+	ID        int    `json:"i"`
+}
@@ -0,0 +1,6 @@
+package dtos
+
+// AssignRolesRequestDto replaces the roles of a user, users with roles are admins
+type AssignRolesRequestDto struct {
+	Roles []string `json:"roles" binding:"omitempty,dive,required"`
+}
@@ -8,6 +8,7 @@ type CreateTripRequestDto struct {
 	FromDate int                   `json:"from_date" binding:"required,min=1"`
 	ToDate   int                   `json:"to_date" binding:"required,min=1"`
 	Users    int                   `json:"users"`
+	IsPublic bool                  `json:"is_public"`
 	Days     []CreateDayRequestDto `json:"days" binding:"required"`
 }
 
@@ -20,5 +21,6 @@ type UpdateTripRequestDto struct {
 	FromDate int                   `json:"from_date" binding:"required,min=1"`
 	ToDate   int                   `json:"to_date" binding:"required,min=1"`
 	Users    int                   `json:"users"`
+	IsPublic *bool                 `json:"is_public"` // unchanged when omitted
 	Days     []CreateDayRequestDto `json:"days" binding:"required"`
 }
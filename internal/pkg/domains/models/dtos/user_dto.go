@@ -4,13 +4,15 @@ import "go-server/internal/pkg/domains/models/entities"
 
 type RegisterRequestDto struct {
 	Username string `json:"username" binding:"required,alphaNumeric"`
-	Email    string `json:"email" binding:"required"`
+	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required"`
 }
 
 type RegisterResponseDto struct {
-	User        entities.User `json:"user"`
-	AccessToken string        `json:"access_token"`
+	User entities.User `json:"user"`
+	// nil while the email has to be verified before logging in
+	*TokenPairDto
+	EmailVerificationRequired bool `json:"email_verification_required"`
 }
 
 type LoginRequestDto struct {
@@ -19,8 +21,20 @@ type LoginRequestDto struct {
 }
 
 type LoginResponseDto struct {
-	User        entities.User `json:"user"`
-	AccessToken string        `json:"access_token"`
+	User entities.User `json:"user"`
+	TokenPairDto
+}
+
+// TokenPairDto is a short-lived access token and the refresh token to renew it, expiries are unix times
+type TokenPairDto struct {
+	AccessToken      string `json:"access_token"`
+	ExpiresAt        int64  `json:"expires_at"`
+	RefreshToken     string `json:"refresh_token"`
+	RefreshExpiresAt int64  `json:"refresh_expires_at"`
+}
+
+type RefreshTokenRequestDto struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
 }
 
 type UpdateUserRequestDto struct {
@@ -36,8 +50,13 @@ type UpdateUserResponseDto struct {
 }
 
 type ForgotPasswordRequestDto struct {
-	Email        string `json:"email" binding:"required,email"`
-	ReceiveEmail string `json:"receive_email" binding:"required,email"`
+	Email string `json:"email" binding:"required,email"`
+}
+
+type ResetPasswordRequestDto struct {
+	Token           string `json:"token" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required,min=1"`
+	ConfirmPassword string `json:"confirm_password" binding:"required,min=1"`
 }
 
 type ChangePasswordRequestDto struct {
@@ -50,3 +69,72 @@ type UpdateStatusRequestDto struct {
 	UserID int `json:"user_id" binding:"required,min=1"`
 	Status int `json:"status" binding:"required,min=1,max=2"`
 }
+
+// ClientDto describes the device a session is created or refreshed from
+type ClientDto struct {
+	UserAgent string
+	IP        string
+}
+
+type SessionDto struct {
+	ID         int    `json:"id"`
+	UserAgent  string `json:"user_agent"`
+	IP         string `json:"ip"`
+	CreatedAt  int64  `json:"created_at"`
+	LastUsedAt int64  `json:"last_used_at"`
+	ExpiresAt  int64  `json:"expires_at"`
+	Current    bool   `json:"current"`
+}
+
+type ResendVerificationRequestDto struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// OAuthIdentityDto is the account of an external identity provider, Subject is its stable id
+type OAuthIdentityDto struct {
+	Provider      string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	TrustEmail    bool // a verified email links to the account of that email, see OAuthUsecase.Login
+	Name          string
+	Avatar        string
+}
+
+type TwoFactorStatusDto struct {
+	Enabled           bool  `json:"enabled"`
+	RecoveryCodesLeft int64 `json:"recovery_codes_left"`
+}
+
+// TwoFactorEnrollmentDto is the pending secret, URI is the otpauth:// payload of the QR code
+type TwoFactorEnrollmentDto struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+}
+
+type TwoFactorCodeRequestDto struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// TwoFactorProofDto is either a code of the authenticator or one of the recovery codes
+type TwoFactorProofDto struct {
+	Code         string `json:"code" binding:"required_without=RecoveryCode"`
+	RecoveryCode string `json:"recovery_code" binding:"required_without=Code"`
+}
+
+type TwoFactorLoginRequestDto struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	TwoFactorProofDto
+}
+
+// TwoFactorChallengeDto answers the first login step of users with two-factor authentication,
+// the challenge token is exchanged with a code at POST /api/auth/login/2fa
+type TwoFactorChallengeDto struct {
+	TwoFactorRequired bool   `json:"two_factor_required"`
+	ChallengeToken    string `json:"challenge_token"`
+	ExpiresAt         int64  `json:"expires_at"`
+}
+
+type RecoveryCodesDto struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
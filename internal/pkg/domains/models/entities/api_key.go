@@ -0,0 +1,67 @@
+package entities
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Scopes an API key can be granted, each route group open to partners requires one
+const (
+	APIKeyScopePlacesRead     = "places:read"     // places, without their reviews and photos
+	APIKeyScopeCategoriesRead = "categories:read" // categories
+)
+
+var APIKeyScopes = []string{
+	APIKeyScopePlacesRead,
+	APIKeyScopeCategoriesRead,
+}
+
+// APIKey authenticates a partner server through the X-API-Key header instead of a user JWT.
+// Only the hash of the key is stored, the prefix identifies it in the CMS
+type APIKey struct {
+	ID           int        `gorm:"column:id;primaryKey;type:bigint;not null;autoIncrement" mapstructure:"id" json:"id"`
+	Name         string     `gorm:"type:varchar(255);not null" json:"name"`
+	Prefix       string     `gorm:"type:varchar(16);not null" json:"prefix"`
+	KeyHash      string     `gorm:"type:char(64);uniqueIndex;not null" json:"-"`
+	Scopes       string     `gorm:"type:varchar(512);not null" json:"-"` // comma separated
+	ExpiresAt    *time.Time `json:"expires_at"`
+	LastUsedAt   *time.Time `json:"last_used_at"`
+	RevokedAt    *time.Time `json:"revoked_at"`
+	RequestCount int64      `gorm:"not null;default:0" json:"request_count"`
+	CreatedBy    int        `json:"created_by"`
+	ScopeList    []string   `gorm:"-" json:"scopes"`
+	BaseEntity
+}
+
+func (k *APIKey) AfterFind(tx *gorm.DB) error {
+	k.ScopeList = k.scopeList()
+	return nil
+}
+
+func (k *APIKey) scopeList() []string {
+	if k.Scopes == "" {
+		return []string{}
+	}
+	return strings.Split(k.Scopes, ",")
+}
+
+// HasScope reports whether the key was granted the scope
+func (k *APIKey) HasScope(scope string) bool {
+	for _, granted := range k.scopeList() {
+		if granted == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// APIKeyDailyUsage counts the requests authenticated by a key per day
+type APIKeyDailyUsage struct {
+	ID       int       `gorm:"column:id;primaryKey;type:bigint;not null;autoIncrement" mapstructure:"id" json:"id"`
+	APIKeyID int       `gorm:"not null;uniqueIndex:idx_api_key_daily_usages_key_date" json:"api_key_id"`
+	Date     time.Time `gorm:"type:date;not null;uniqueIndex:idx_api_key_daily_usages_key_date" json:"date"`
+	Requests int64     `gorm:"not null;default:0" json:"requests"`
+	BaseEntity
+}
@@ -1,8 +1,73 @@
 package entities
 
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Banner target types, what the app opens when a banner is tapped
+const (
+	BannerTargetPlace        = "place"
+	BannerTargetCategory     = "category"
+	BannerTargetTripTemplate = "trip_template"
+	BannerTargetURL          = "url"
+)
+
+const (
+	BannerStateInactive  = "inactive"
+	BannerStateScheduled = "scheduled"
+	BannerStateLive      = "live"
+	BannerStateExpired   = "expired"
+)
+
 type Banner struct {
-	ID    int    `gorm:"column:id;primaryKey;type:bigint;not null;autoIncrement" mapstructure:"id" json:"id"`
-	Name  string `json:"name,omitempty"`
-	Image string `json:"image,omitempty"`
+	ID           int           `gorm:"column:id;primaryKey;type:bigint;not null;autoIncrement" mapstructure:"id" json:"id"`
+	Name         string        `json:"name,omitempty"`
+	Image        string        `json:"image,omitempty"`
+	StartAt      *time.Time    `json:"-"`
+	EndAt        *time.Time    `json:"-"`
+	Position     int           `gorm:"not null;default:0" json:"position"`
+	Active       *bool         `gorm:"not null;default:true" json:"active"`
+	TargetType   string        `gorm:"type:varchar(32)" json:"target_type,omitempty"`
+	TargetID     int           `json:"target_id,omitempty"` // place, category or trip id depending on TargetType
+	TargetURL    string        `json:"target_url,omitempty"`
+	StartAtUnix  int64         `gorm:"-" json:"start_at,omitempty"`
+	EndAtUnix    int64         `gorm:"-" json:"end_at,omitempty"`
+	State        string        `gorm:"-" json:"state,omitempty"`
+	Translations []Translation `gorm:"polymorphic:Entity" json:"translations,omitempty"`
 	BaseEntity
 }
+
+func (i *Banner) AfterFind(tx *gorm.DB) (err error) {
+	if i.StartAt != nil {
+		i.StartAtUnix = i.StartAt.Unix()
+	}
+	if i.EndAt != nil {
+		i.EndAtUnix = i.EndAt.Unix()
+	}
+	i.State = i.StateAt(time.Now())
+
+	return
+}
+
+// StateAt returns whether the banner is displayed at now according to its active flag and display window
+func (i *Banner) StateAt(now time.Time) string {
+	switch {
+	case i.Active != nil && !*i.Active:
+		return BannerStateInactive
+	case i.StartAt != nil && now.Before(*i.StartAt):
+		return BannerStateScheduled
+	case i.EndAt != nil && !now.Before(*i.EndAt):
+		return BannerStateExpired
+	default:
+		return BannerStateLive
+	}
+}
+
+// Translate resolves the name of the banner in locale
+func (i *Banner) Translate(locale string) {
+	i.Translations = translate(i.Translations, locale, map[string]*string{
+		TranslationFieldName: &i.Name,
+	})
+}
@@ -1,10 +1,81 @@
 package entities
 
 type Category struct {
-	ID          int     `gorm:"column:id;primaryKey;type:bigint;not null;autoIncrement" mapstructure:"id" json:"id"`
-	Name        string  `gorm:"not null" json:"name,omitempty"`
-	Description string  `json:"description,omitempty"`
-	Icon        string  `json:"icon,omitempty"`
-	Places      []Place `gorm:"many2many:place_categories" json:"places,omitempty"`
+	ID           int           `gorm:"column:id;primaryKey;type:bigint;not null;autoIncrement" mapstructure:"id" json:"id"`
+	Name         string        `gorm:"not null" json:"name,omitempty"`
+	Description  string        `json:"description,omitempty"`
+	Icon         string        `json:"icon,omitempty"`
+	ParentID     *int          `gorm:"index" json:"parent_id"`
+	Children     []Category    `gorm:"foreignKey:ParentID" json:"children,omitempty"`
+	Places       []Place       `gorm:"many2many:place_categories" json:"places,omitempty"`
+	Translations []Translation `gorm:"polymorphic:Entity" json:"translations,omitempty"`
 	BaseEntity
 }
+
+// Translate resolves the name and description of the category in locale
+func (i *Category) Translate(locale string) {
+	i.Translations = translate(i.Translations, locale, map[string]*string{
+		TranslationFieldName:        &i.Name,
+		TranslationFieldDescription: &i.Description,
+	})
+
+	for j := range i.Children {
+		i.Children[j].Translate(locale)
+	}
+}
+
+// BuildCategoryTree nests the categories under their parent and returns the roots.
+// Categories whose parent is not in the list are returned as roots.
+func BuildCategoryTree(categories []Category) []Category {
+	existed := make(map[int]bool)
+	for _, category := range categories {
+		existed[category.ID] = true
+	}
+
+	children := make(map[int][]Category)
+	var roots []Category
+	for _, category := range categories {
+		if category.ParentID != nil && existed[*category.ParentID] {
+			children[*category.ParentID] = append(children[*category.ParentID], category)
+			continue
+		}
+		roots = append(roots, category)
+	}
+
+	var attach func(category Category) Category
+	attach = func(category Category) Category {
+		for _, child := range children[category.ID] {
+			category.Children = append(category.Children, attach(child))
+		}
+		return category
+	}
+
+	for i := range roots {
+		roots[i] = attach(roots[i])
+	}
+
+	return roots
+}
+
+// CategoryDescendantIDs returns rootID and the ids of all categories below it
+func CategoryDescendantIDs(categories []Category, rootID int) []int {
+	children := make(map[int][]int)
+	for _, category := range categories {
+		if category.ParentID != nil {
+			children[*category.ParentID] = append(children[*category.ParentID], category.ID)
+		}
+	}
+
+	ids := []int{rootID}
+	visited := map[int]bool{rootID: true}
+	for i := 0; i < len(ids); i++ {
+		for _, child := range children[ids[i]] {
+			if !visited[child] {
+				visited[child] = true
+				ids = append(ids, child)
+			}
+		}
+	}
+
+	return ids
+}
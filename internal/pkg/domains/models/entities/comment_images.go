@@ -0,0 +1,11 @@
+package entities
+
+// CommentImage is a photo attached to a comment, uploaded beforehand through the upload API
+type CommentImage struct {
+	ID        int      `gorm:"column:id;primaryKey;type:bigint;not null;autoIncrement" mapstructure:"id" json:"id"`
+	CommentID int      `gorm:"not null;index" json:"comment_id"`
+	Comment   *Comment `gorm:"foreignKey:CommentID" json:"-"`
+	URL       string   `gorm:"type:varchar(512);not null" json:"url"`
+	Position  int      `gorm:"not null;default:0" json:"position"`
+	BaseEntity
+}
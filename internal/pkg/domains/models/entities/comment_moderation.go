@@ -0,0 +1,64 @@
+package entities
+
+// Comment statuses, only published comments are listed and rated
+const (
+	CommentStatusPublished = "published"
+	CommentStatusHeld      = "held" // waiting for a moderator, e.g. caught by the content filter
+	CommentStatusHidden    = "hidden"
+)
+
+// Reasons of a comment report
+const (
+	CommentReportReasonSpam       = "spam"
+	CommentReportReasonOffensive  = "offensive"
+	CommentReportReasonHarassment = "harassment"
+	CommentReportReasonOffTopic   = "off_topic"
+	CommentReportReasonMisleading = "misleading"
+	CommentReportReasonOther      = "other"
+)
+
+const (
+	CommentReportStatusOpen     = "open"
+	CommentReportStatusResolved = "resolved"
+)
+
+// Queues of the moderation, the pending one holds both held and reported comments
+const (
+	CommentQueuePending  = ""
+	CommentQueueHeld     = "held"
+	CommentQueueHidden   = "hidden"
+	CommentQueueReported = "reported"
+)
+
+// Actions of the moderation audit trail
+const (
+	CommentModerationHold    = "hold"
+	CommentModerationHide    = "hide"
+	CommentModerationRestore = "restore"
+	CommentModerationDelete  = "delete"
+)
+
+// CommentReport is the report of a comment by a user, a user reports a comment once
+type CommentReport struct {
+	ID        int    `gorm:"column:id;primaryKey;type:bigint;not null;autoIncrement" mapstructure:"id" json:"id"`
+	CommentID int    `gorm:"not null;uniqueIndex:idx_comment_reports_comment_user" json:"comment_id"`
+	UserID    int    `gorm:"not null;uniqueIndex:idx_comment_reports_comment_user" json:"user_id"`
+	User      User   `gorm:"foreignKey:UserID;references:ID" json:"user"`
+	Reason    string `gorm:"type:varchar(32);not null" json:"reason"`
+	Text      string `gorm:"type:text" json:"text"`
+	Status    string `gorm:"type:varchar(16);not null;index" json:"status"`
+	BaseEntity
+}
+
+// CommentModerationLog is the audit trail of the moderation of a comment,
+// ActorID is 0 for actions of the content filter
+type CommentModerationLog struct {
+	ID         int    `gorm:"column:id;primaryKey;type:bigint;not null;autoIncrement" mapstructure:"id" json:"id"`
+	CommentID  int    `gorm:"not null;index" json:"comment_id"`
+	ActorID    int    `gorm:"not null" json:"actor_id"`
+	Action     string `gorm:"type:varchar(16);not null" json:"action"`
+	FromStatus string `gorm:"type:varchar(16)" json:"from_status"`
+	ToStatus   string `gorm:"type:varchar(16)" json:"to_status"`
+	Note       string `gorm:"type:text" json:"note"`
+	BaseEntity
+}
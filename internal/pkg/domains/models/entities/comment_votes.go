@@ -0,0 +1,24 @@
+package entities
+
+// Votes of a user on a comment, exposed as Comment.MyVote
+const (
+	CommentVoteHelpful    = "helpful"
+	CommentVoteNotHelpful = "not_helpful"
+)
+
+// CommentVote is the helpful / not helpful vote of a user on a comment, a user votes once per comment
+type CommentVote struct {
+	ID        int  `gorm:"column:id;primaryKey;type:bigint;not null;autoIncrement" mapstructure:"id" json:"id"`
+	CommentID int  `gorm:"not null;uniqueIndex:idx_comment_votes_comment_user" json:"comment_id"`
+	UserID    int  `gorm:"not null;uniqueIndex:idx_comment_votes_comment_user" json:"user_id"`
+	Helpful   bool `gorm:"not null" json:"helpful"`
+	BaseEntity
+}
+
+func (i CommentVote) Vote() string {
+	if i.Helpful {
+		return CommentVoteHelpful
+	}
+
+	return CommentVoteNotHelpful
+}
@@ -7,13 +7,27 @@ import (
 )
 
 type Comment struct {
-	ID      int    `json:"id" binding:"required"`
-	Rate    int    `json:"rate"`
-	Comment string `json:"comment"`
-	UserID  int    `json:"user_id"`
-	User    User   `gorm:"foreignKey:UserID;references:ID" json:"user"`
-	PlaceID int    `json:"place_id"`
-	Place   Place  `gorm:"foreignKey:PlaceID;references:ID" json:"place"`
+	ID              int             `json:"id" binding:"required"`
+	Rate            int             `json:"rate"`
+	Comment         string          `json:"comment"`
+	UserID          int             `json:"user_id"`
+	User            User            `gorm:"foreignKey:UserID;references:ID" json:"user"`
+	PlaceID         int             `gorm:"uniqueIndex:idx_comments_place_review,priority:1" json:"place_id"`
+	Place           Place           `gorm:"foreignKey:PlaceID;references:ID" json:"place"`
+	ParentCommentID *int            `gorm:"index" json:"parent_comment_id,omitempty"`  // set on replies, which are not rated
+	IsOfficial      bool            `gorm:"not null;default:false" json:"is_official"` // response of an admin to a review
+	HelpfulCount    int64           `gorm:"not null;default:0" json:"helpful_count"`
+	NotHelpfulCount int64           `gorm:"not null;default:0" json:"not_helpful_count"`
+	MyVote          string          `gorm:"-" json:"my_vote,omitempty"` // vote of the caller, see CommentVoteHelpful
+	Status          string          `gorm:"type:varchar(16);not null;default:published;index" json:"status"`
+	Reports         []CommentReport `gorm:"foreignKey:CommentID" json:"reports,omitempty"`
+	Images          []CommentImage  `gorm:"foreignKey:CommentID" json:"images"`
+	// user_id on a live review and NULL otherwise, unique per place so a user rates a place once
+	ReviewUserID *int `gorm:"->;type:bigint GENERATED ALWAYS AS (CASE WHEN parent_comment_id IS NULL AND deleted_at IS NULL THEN user_id END) STORED;uniqueIndex:idx_comments_place_review,priority:2" json:"-"`
+	// filled for reviews only
+	Replies          []Comment `gorm:"-" json:"replies,omitempty"`
+	ReplyCount       int64     `gorm:"-" json:"reply_count"`
+	OfficialResponse *Comment  `gorm:"-" json:"official_response,omitempty"`
 	BaseEntity
 }
 
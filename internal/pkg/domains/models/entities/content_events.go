@@ -0,0 +1,34 @@
+package entities
+
+import "time"
+
+// Content types which can be tracked
+const (
+	ContentTypeBanner = "banner"
+)
+
+const (
+	ContentEventImpression = "impression"
+	ContentEventClick      = "click"
+)
+
+// ContentEvent is a raw impression or click of a user on a piece of content
+type ContentEvent struct {
+	ID          int    `gorm:"column:id;primaryKey;type:bigint;not null;autoIncrement" mapstructure:"id" json:"id"`
+	ContentType string `gorm:"type:varchar(32);not null;index:idx_content_events_content" json:"content_type"`
+	ContentID   int    `gorm:"not null;index:idx_content_events_content" json:"content_id"`
+	UserID      int    `gorm:"index" json:"user_id"`
+	Event       string `gorm:"type:varchar(16);not null" json:"event"`
+	BaseEntity
+}
+
+// ContentDailyStat holds the daily counters of a piece of content, incremented with each event
+type ContentDailyStat struct {
+	ID          int       `gorm:"column:id;primaryKey;type:bigint;not null;autoIncrement" mapstructure:"id" json:"id"`
+	ContentType string    `gorm:"type:varchar(32);not null;uniqueIndex:idx_content_daily_stats_content_date" json:"content_type"`
+	ContentID   int       `gorm:"not null;uniqueIndex:idx_content_daily_stats_content_date" json:"content_id"`
+	Date        time.Time `gorm:"type:date;not null;uniqueIndex:idx_content_daily_stats_content_date" json:"date"`
+	Impressions int64     `gorm:"not null;default:0" json:"impressions"`
+	Clicks      int64     `gorm:"not null;default:0" json:"clicks"`
+	BaseEntity
+}
@@ -0,0 +1,10 @@
+package entities
+
+// Follow subscribes the follower to the public trips and reviews of the followee,
+// unfollowing deletes the row
+type Follow struct {
+	ID         int `gorm:"column:id;primaryKey;type:bigint;not null;autoIncrement" json:"id"`
+	FollowerID int `gorm:"not null;uniqueIndex:idx_follows_follower_followee" json:"follower_id"`
+	FolloweeID int `gorm:"not null;uniqueIndex:idx_follows_follower_followee;index" json:"followee_id"`
+	BaseEntity
+}
@@ -0,0 +1,13 @@
+package entities
+
+import "time"
+
+// LoginAttempt counts the recent failed logins of a key, "account:<email>" or "ip:<address>"
+type LoginAttempt struct {
+	ID            int        `gorm:"column:id;primaryKey;type:bigint;not null;autoIncrement" json:"id"`
+	Key           string     `gorm:"type:varchar(320);uniqueIndex;not null" json:"key"`
+	Failures      int        `gorm:"not null" json:"failures"`
+	LastFailureAt *time.Time `json:"last_failure_at"`
+	LockedUntil   *time.Time `json:"locked_until"`
+	BaseEntity
+}
@@ -0,0 +1,69 @@
+package entities
+
+import (
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Types of notification, each can be turned off per user for the app and for the email
+const (
+	NotificationCommentReply      = "comment_reply"      // a reply to my review
+	NotificationOfficialResponse  = "official_response"  // the official response to my review
+	NotificationModerationOutcome = "moderation_outcome" // my comment was hidden, restored or deleted
+)
+
+// NotificationTypes maps the types to the preference of users who did not set one
+var NotificationTypes = map[string]NotificationPreference{
+	NotificationCommentReply:      {InApp: true, Email: false},
+	NotificationOfficialResponse:  {InApp: true, Email: true},
+	NotificationModerationOutcome: {InApp: true, Email: true},
+}
+
+// Notification is an entry of the notification center of a user, Data holds the ids
+// of what it is about (comment_id, place_id...) for the app to link to
+type Notification struct {
+	ID      int                    `gorm:"column:id;primaryKey;type:bigint;not null;autoIncrement" mapstructure:"id" json:"id"`
+	UserID  int                    `gorm:"not null;index:idx_notifications_user_read" json:"user_id"`
+	Type    string                 `gorm:"type:varchar(32);not null" json:"type"`
+	Title   string                 `gorm:"type:varchar(255);not null" json:"title"`
+	Body    string                 `gorm:"type:text" json:"body"`
+	RawData string                 `gorm:"column:data;type:text" json:"-"`
+	Data    map[string]interface{} `gorm:"-" json:"data"`
+	ReadAt  *time.Time             `gorm:"index:idx_notifications_user_read" json:"read_at"`
+	BaseEntity
+}
+
+func (n *Notification) BeforeSave(tx *gorm.DB) error {
+	if n.Data == nil {
+		n.RawData = ""
+		return nil
+	}
+
+	data, err := json.Marshal(n.Data)
+	if err != nil {
+		return err
+	}
+	n.RawData = string(data)
+	return nil
+}
+
+func (n *Notification) AfterFind(tx *gorm.DB) error {
+	n.Data = map[string]interface{}{}
+	if n.RawData == "" {
+		return nil
+	}
+	return json.Unmarshal([]byte(n.RawData), &n.Data)
+}
+
+// NotificationPreference decides whether the notifications of a type are stored for the app
+// and mailed to the user, a missing row falls back to NotificationTypes
+type NotificationPreference struct {
+	ID     int    `gorm:"column:id;primaryKey;type:bigint;not null;autoIncrement" json:"-"`
+	UserID int    `gorm:"not null;uniqueIndex:idx_notification_preferences_user_type" json:"-"`
+	Type   string `gorm:"type:varchar(32);not null;uniqueIndex:idx_notification_preferences_user_type" json:"type"`
+	InApp  bool   `gorm:"not null" json:"in_app"`
+	Email  bool   `gorm:"not null" json:"email"`
+	BaseEntity
+}
@@ -0,0 +1,14 @@
+package entities
+
+import "time"
+
+// PasswordResetToken is a single-use token mailed by ForgotPassword, only the sha256
+// hash of the token is stored
+type PasswordResetToken struct {
+	ID        int        `gorm:"column:id;primaryKey;type:bigint;not null;autoIncrement" json:"id"`
+	UserID    int        `gorm:"index;not null" json:"user_id"`
+	TokenHash string     `gorm:"type:char(64);uniqueIndex;not null" json:"-"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+	BaseEntity
+}
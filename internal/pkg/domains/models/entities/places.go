@@ -7,17 +7,18 @@ import (
 )
 
 type Place struct {
-	ID             int        `gorm:"column:id;primaryKey;type:bigint;not null;autoIncrement" mapstructure:"id" json:"id"`
-	Name           string     `gorm:"not null" json:"name,omitempty"`
-	Address        string     `gorm:"not null" json:"address,omitempty"`
-	Latitude       float64    `gorm:"not null" json:"latitude,omitempty"`
-	Longitude      float64    `gorm:"not null" json:"longitude,omitempty"`
-	Description    string     `json:"description,omitempty"`
-	Images         string     `gorm:"not null" json:"-"`
-	Price          float64    `json:"price,omitempty"`
-	Rate           float64    `json:"rate,omitempty"`
-	Categories     []Category `gorm:"many2many:place_categories" json:"categories,omitempty"`
-	ImagesResponse []string   `gorm:"-" json:"images,omitempty"`
+	ID             int           `gorm:"column:id;primaryKey;type:bigint;not null;autoIncrement" mapstructure:"id" json:"id"`
+	Name           string        `gorm:"not null" json:"name,omitempty"`
+	Address        string        `gorm:"not null" json:"address,omitempty"`
+	Latitude       float64       `gorm:"not null" json:"latitude,omitempty"`
+	Longitude      float64       `gorm:"not null" json:"longitude,omitempty"`
+	Description    string        `json:"description,omitempty"`
+	Images         string        `gorm:"not null" json:"-"`
+	Price          float64       `json:"price,omitempty"`
+	Rate           float64       `json:"rate,omitempty"`
+	Categories     []Category    `gorm:"many2many:place_categories" json:"categories,omitempty"`
+	ImagesResponse []string      `gorm:"-" json:"images,omitempty"`
+	Translations   []Translation `gorm:"polymorphic:Entity" json:"translations,omitempty"`
 	BaseEntity
 }
 
@@ -26,7 +27,8 @@ func (i *Place) AfterFind(tx *gorm.DB) (err error) {
 	i.ImagesResponse = i.ImagesResponse[1 : len(i.ImagesResponse)-1]
 
 	var comment []Comment
-	err = tx.Where("place_id = ?", i.ID).Find(&comment).Error
+	// replies and moderated reviews are not rated
+	err = tx.Where("place_id = ? AND parent_comment_id IS NULL AND status = ?", i.ID, CommentStatusPublished).Find(&comment).Error
 	if err != nil {
 		return
 	}
@@ -47,3 +49,15 @@ func (i *Place) AfterFind(tx *gorm.DB) (err error) {
 	}()
 	return
 }
+
+// Translate resolves the name and description of the place and its categories in locale
+func (i *Place) Translate(locale string) {
+	i.Translations = translate(i.Translations, locale, map[string]*string{
+		TranslationFieldName:        &i.Name,
+		TranslationFieldDescription: &i.Description,
+	})
+
+	for j := range i.Categories {
+		i.Categories[j].Translate(locale)
+	}
+}
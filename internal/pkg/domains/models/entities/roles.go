@@ -0,0 +1,46 @@
+package entities
+
+// Permissions checked per route group of the CMS
+const (
+	PermissionContentManage    = "content.manage"    // places, categories, banners and their reports
+	PermissionCommentsModerate = "comments.moderate" // moderation queue and official responses
+	PermissionUsersManage      = "users.manage"      // user status and sessions
+	PermissionRolesManage      = "roles.manage"      // role assignments
+	PermissionAPIKeysManage    = "api_keys.manage"   // partner API keys and their usage
+)
+
+// Built-in roles, seeded by the migrations
+const (
+	RoleContentEditor = "content_editor"
+	RoleModerator     = "moderator"
+	RoleUserAdmin     = "user_admin"
+	RoleSuperAdmin    = "super_admin"
+)
+
+// DefaultRolePermissions are the permissions of the built-in roles
+var DefaultRolePermissions = map[string][]string{
+	RoleContentEditor: {PermissionContentManage},
+	RoleModerator:     {PermissionCommentsModerate},
+	RoleUserAdmin:     {PermissionUsersManage},
+	RoleSuperAdmin: {
+		PermissionContentManage,
+		PermissionCommentsModerate,
+		PermissionUsersManage,
+		PermissionRolesManage,
+		PermissionAPIKeysManage,
+	},
+}
+
+type Permission struct {
+	ID   int    `gorm:"column:id;primaryKey;type:bigint;not null;autoIncrement" json:"id"`
+	Name string `gorm:"type:varchar(64);uniqueIndex;not null" json:"name"`
+	BaseEntity
+}
+
+// Role is a named set of permissions assigned to admins, see User.Roles
+type Role struct {
+	ID          int          `gorm:"column:id;primaryKey;type:bigint;not null;autoIncrement" json:"id"`
+	Name        string       `gorm:"type:varchar(64);uniqueIndex;not null" json:"name"`
+	Permissions []Permission `gorm:"many2many:role_permissions" json:"permissions,omitempty"`
+	BaseEntity
+}
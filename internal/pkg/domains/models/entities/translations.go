@@ -0,0 +1,44 @@
+package entities
+
+// EntityType values, the table names used by the polymorphic associations
+const (
+	TranslationEntityPlace    = "places"
+	TranslationEntityCategory = "categories"
+	TranslationEntityBanner   = "banners"
+)
+
+const (
+	TranslationFieldName        = "name"
+	TranslationFieldDescription = "description"
+)
+
+// Translation holds the value of one field of a place, category or banner in one locale.
+// EntityType is the table name of the translated entity.
+type Translation struct {
+	ID         int    `gorm:"column:id;primaryKey;type:bigint;not null;autoIncrement" mapstructure:"id" json:"-"`
+	EntityType string `gorm:"type:varchar(32);not null;uniqueIndex:idx_translations_entity_locale_field" json:"-"`
+	EntityID   int    `gorm:"not null;uniqueIndex:idx_translations_entity_locale_field" json:"-"`
+	Locale     string `gorm:"type:varchar(8);not null;uniqueIndex:idx_translations_entity_locale_field" json:"locale"`
+	Field      string `gorm:"type:varchar(32);not null;uniqueIndex:idx_translations_entity_locale_field" json:"field"`
+	Value      string `gorm:"type:text" json:"value"`
+	BaseEntity
+}
+
+// translate overwrites fields with their translation in locale, untranslated fields keep the default content.
+// The translations are dropped once resolved; with an empty locale (CMS) nothing is resolved and they are kept.
+func translate(translations []Translation, locale string, fields map[string]*string) []Translation {
+	if locale == "" {
+		return translations
+	}
+
+	for _, translation := range translations {
+		if translation.Locale != locale || translation.Value == "" {
+			continue
+		}
+		if field, ok := fields[translation.Field]; ok {
+			*field = translation.Value
+		}
+	}
+
+	return nil
+}
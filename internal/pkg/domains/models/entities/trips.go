@@ -18,6 +18,7 @@ type Trip struct {
 	UserIDs      string    `json:"-"`
 	ToDateUnix   int       `gorm:"-" json:"to_date"`
 	TripFee      float64   `gorm:"-" json:"trip_fee"`
+	IsPublic     bool      `gorm:"not null;default:false;index" json:"is_public"` // shown on the profile of the owner and in the feed of their followers
 	BaseEntity
 }
 
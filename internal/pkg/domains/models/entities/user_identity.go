@@ -0,0 +1,11 @@
+package entities
+
+// UserIdentity links an account of an external identity provider (e.g. google) to a user
+type UserIdentity struct {
+	ID       int    `gorm:"column:id;primaryKey;type:bigint;not null;autoIncrement" json:"id"`
+	UserID   int    `gorm:"index;not null" json:"user_id"`
+	Provider string `gorm:"type:varchar(64);uniqueIndex:idx_user_identities_provider_subject;not null" json:"provider"`
+	Subject  string `gorm:"type:varchar(255);uniqueIndex:idx_user_identities_provider_subject;not null" json:"-"`
+	Email    string `gorm:"type:varchar(255)" json:"email"`
+	BaseEntity
+}
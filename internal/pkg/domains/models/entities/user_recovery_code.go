@@ -0,0 +1,13 @@
+package entities
+
+import "time"
+
+// UserRecoveryCode is a one-time code replacing the TOTP code when the authenticator is lost,
+// only the sha256 hash of the code is stored
+type UserRecoveryCode struct {
+	ID       int        `gorm:"column:id;primaryKey;type:bigint;not null;autoIncrement" json:"id"`
+	UserID   int        `gorm:"index;not null" json:"user_id"`
+	CodeHash string     `gorm:"type:char(64);not null" json:"-"`
+	UsedAt   *time.Time `json:"used_at"`
+	BaseEntity
+}
@@ -1,8 +1,22 @@
 package entities
 
+import "time"
+
+// UserToken is a login session. TokenID is the token_id claim of its access tokens.
+// Refreshing rotates the row: the used one is marked RotatedAt and a new one of the
+// same FamilyID takes its place, so a rotated refresh token presented again is a reuse.
+// The live (not rotated) row of a family is what users see as a session.
 type UserToken struct {
-	ID      int    `gorm:"column:id;primaryKey;type:bigint;not null;autoIncrement" mapstructure:"id" json:"id"`
-	UserID  int    `json:"user_id"`
-	TokenID string `json:"token_id"`
+	ID               int        `gorm:"column:id;primaryKey;type:bigint;not null;autoIncrement" mapstructure:"id" json:"id"`
+	UserID           int        `json:"user_id"`
+	TokenID          string     `gorm:"index" json:"token_id"`
+	FamilyID         string     `gorm:"type:varchar(36);index" json:"-"`
+	RefreshTokenHash string     `gorm:"type:char(64);index" json:"-"`
+	ExpiresAt        *time.Time `json:"-"` // expiry of the refresh token
+	RotatedAt        *time.Time `json:"-"`
+	UserAgent        string     `gorm:"type:varchar(512)" json:"user_agent"`
+	IP               string     `gorm:"type:varchar(64)" json:"ip"`
+	LastUsedAt       *time.Time `json:"-"`
+	TwoFactor        bool       `json:"two_factor"` // logged in with a second factor
 	BaseEntity
 }
@@ -18,10 +18,28 @@ type User struct {
 	BirthDay     *time.Time `json:"-"`
 	Gender       int        `json:"gender,omitempty"` // 1: nam, 2: nữ
 	Contact      string     `json:"contact,omitempty"`
-	Password     string     `gorm:"column:password;not null" json:"password,omitempty"`
+	Password     string     `gorm:"column:password;not null" json:"-"`
 	IsAdmin      bool       `gorm:"default:false" json:"is_admin"`
 	BirthDayUnix int64      `gorm:"-" json:"birth_day,omitempty"`
 	Trips        []Trip     `gorm:"many2many:user_trips"`
+	Roles        []Role     `gorm:"many2many:user_roles" json:"roles,omitempty"`
+	// email verification, VerificationSentAt throttles resending the link
+	EmailVerifiedAt    *time.Time `json:"-"`
+	EmailVerified      bool       `gorm:"-" json:"email_verified"`
+	VerificationSentAt *time.Time `json:"-"`
+	// two-factor authentication, the secret is pending until TwoFactorEnabledAt is set.
+	// TOTPLastStep is the time step of the last accepted code, codes are not accepted twice.
+	// TwoFactorChallengeHash is the hash of the nonce of the pending login challenge, cleared once it is used
+	TOTPSecret             string     `gorm:"column:totp_secret;type:varchar(64)" json:"-"`
+	TOTPLastStep           int64      `gorm:"column:totp_last_step" json:"-"`
+	TwoFactorEnabledAt     *time.Time `json:"-"`
+	TwoFactorFailures      int        `json:"-"`
+	TwoFactorChallengeHash string     `gorm:"type:char(64)" json:"-"`
+	TwoFactorEnabled       bool       `gorm:"-" json:"two_factor_enabled"`
+	// account deletion, confirmed by email and erased by the purge job once the date has passed.
+	// DeletionNonceHash is the hash of the nonce of the pending link, cleared once it is used
+	DeletionNonceHash   string     `gorm:"type:char(64)" json:"-"`
+	DeletionScheduledAt *time.Time `gorm:"index" json:"deletion_scheduled_at,omitempty"`
 	BaseEntity
 }
 
@@ -34,6 +52,8 @@ func (i *User) AfterFind(tx *gorm.DB) (err error) {
 	if i.BirthDay != nil {
 		i.BirthDayUnix = i.BirthDay.Unix()
 	}
+	i.EmailVerified = i.EmailVerifiedAt != nil
+	i.TwoFactorEnabled = i.TwoFactorEnabledAt != nil
 
 	return
 }
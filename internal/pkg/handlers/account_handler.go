@@ -0,0 +1,269 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-server/internal/pkg/domains/interfaces"
+	"go-server/internal/pkg/domains/models/dtos"
+	"go-server/internal/pkg/domains/models/entities"
+	"go-server/internal/pkg/repositories"
+	"go-server/internal/pkg/services"
+	"go-server/internal/pkg/usecases"
+	"go-server/pkg/shared/realtime"
+	"html/template"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type accountHandler struct {
+	accountUsecase interfaces.AccountUsecase
+	logger         *logrus.Logger
+	db             *gorm.DB
+}
+
+func NewAccountHandler(logger *logrus.Logger, db *gorm.DB, sessions realtime.SessionCloser) *accountHandler {
+	userRepo := repositories.NewUserRepository(db, logger)
+	userTokenRepo := repositories.NewUserTokenRepository(db, logger)
+	accountRepo := repositories.NewAccountRepository(db, logger)
+	commentRepo := repositories.NewCommentRepository(db, logger)
+	loginAttemptRepo := repositories.NewLoginAttemptRepository(db, logger)
+	mailService := services.NewMailService()
+	accountUsecase := usecases.NewAccountUsecase(userRepo, userTokenRepo, accountRepo, commentRepo, loginAttemptRepo, mailService, sessions, logger)
+
+	return &accountHandler{
+		accountUsecase,
+		logger,
+		db,
+	}
+}
+
+// Export downloads the personal data of the caller, as JSON or with format=zip as a ZIP
+// holding one JSON file per kind of data
+func (h *accountHandler) Export(c *gin.Context) {
+	userID, _ := userIDFromContext(c)
+	export, err := h.accountUsecase.Export(c, userID)
+	if err != nil {
+		h.error(c, err)
+		return
+	}
+
+	filename := fmt.Sprintf("travelix-export-%d", userID)
+	if c.Query("format") != "zip" {
+		c.Header("Content-Disposition", `attachment; filename="`+filename+`.json"`)
+		c.IndentedJSON(http.StatusOK, export)
+		return
+	}
+
+	archive, err := exportZip(export)
+	if err != nil {
+		h.error(c, err)
+		return
+	}
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`.zip"`)
+	c.Data(http.StatusOK, "application/zip", archive)
+}
+
+func exportZip(export dtos.AccountExportDto) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	archive := zip.NewWriter(buf)
+	for name, data := range map[string]interface{}{
+		"profile.json":         export.Profile,
+		"trips.json":           export.Trips,
+		"comments.json":        export.Comments,
+		"comment_votes.json":   export.CommentVotes,
+		"comment_reports.json": export.CommentReports,
+		"sessions.json":        export.Sessions,
+		"identities.json":      export.Identities,
+		"following.json":       export.Following,
+		"notifications.json":   export.Notifications,
+	} {
+		file, err := archive.Create(name)
+		if err != nil {
+			return nil, err
+		}
+		encoder := json.NewEncoder(file)
+		encoder.SetIndent("", "    ")
+		err = encoder.Encode(data)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err := archive.Close()
+	return buf.Bytes(), err
+}
+
+// RequestDeletion mails the caller the link confirming the deletion of their account
+func (h *accountHandler) RequestDeletion(c *gin.Context) {
+	userID, _ := userIDFromContext(c)
+	err := h.accountUsecase.RequestDeletion(c, userID)
+	if err != nil {
+		h.error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dtos.BaseResponse{
+		Code:    0,
+		Message: "Confirmation email sent",
+	})
+}
+
+// confirmDeletionPage is opened from the emailed link. Opening it changes nothing, the deletion
+// is confirmed by posting its form, which link scanners and prefetchers do not do
+var confirmDeletionPage = template.Must(template.New("confirm_deletion").Parse(`<!DOCTYPE html>
+<html lang="en">
+
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>Delete your account</title>
+</head>
+
+<body>
+    <h2>Delete your Travelix account</h2>
+    {{ if .message }}<p>{{ .message }}</p>{{ end }}
+    {{ if .token }}
+    <p>Your account and its data will be permanently deleted {{ .grace_period }} days after the confirmation.
+        Until then you can log in and cancel the deletion.</p>
+    <form method="POST" action="/api/auth/confirm_deletion">
+        <input type="hidden" name="token" value="{{ .token }}">
+        <button type="submit">Delete my account</button>
+    </form>
+    {{ end }}
+</body>
+
+</html>
+`))
+
+// ConfirmDeletionPage is opened from the emailed link and asks to confirm the deletion
+func (h *accountHandler) ConfirmDeletionPage(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		h.deletionPage(c, http.StatusBadRequest, gin.H{"message": usecases.ConfirmDeletionTokenInvalid.Error()})
+		return
+	}
+
+	h.deletionPage(c, http.StatusOK, gin.H{
+		"token":        token,
+		"grace_period": int(usecases.AccountDeletionGracePeriod().Hours() / 24),
+	})
+}
+
+// ConfirmDeletion is posted by the page of the emailed link, the account is erased after the
+// grace period. The form of the page gets a page back, JSON requests get JSON
+func (h *accountHandler) ConfirmDeletion(c *gin.Context) {
+	form := c.ContentType() == binding.MIMEPOSTForm
+
+	req := dtos.ConfirmDeletionRequestDto{}
+	err := c.ShouldBind(&req)
+	if err != nil {
+		if form {
+			h.deletionPage(c, http.StatusBadRequest, gin.H{"message": usecases.ConfirmDeletionTokenInvalid.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, dtos.BaseResponse{
+			Code:    400,
+			Message: BadRequest,
+			Error: &dtos.ErrorResponse{
+				ErrorDetails: err.Error(),
+			},
+		})
+		return
+	}
+
+	user, err := h.accountUsecase.ConfirmDeletion(c, req.Token)
+	if form {
+		switch {
+		case errors.Is(err, usecases.ConfirmDeletionTokenInvalid):
+			h.deletionPage(c, http.StatusBadRequest, gin.H{"message": err.Error()})
+		case err != nil:
+			h.logger.Errorf("confirm deletion failed: %v", err)
+			h.deletionPage(c, http.StatusInternalServerError, gin.H{"message": InternalServerError})
+		default:
+			h.deletionPage(c, http.StatusOK, gin.H{
+				"message": "Your account will be deleted on " + user.DeletionScheduledAt.Format("02/01/2006") + ", we emailed you how to cancel it.",
+			})
+		}
+		return
+	}
+	if err != nil {
+		h.error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dtos.BaseResponse{
+		Code:    0,
+		Message: "Account deletion scheduled",
+		Data: gin.H{
+			"user": entities.User{
+				ID:                  user.ID,
+				Username:            user.Username,
+				Email:               user.Email,
+				DeletionScheduledAt: user.DeletionScheduledAt,
+			},
+		},
+	})
+}
+
+// deletionPage renders confirmDeletionPage, the token in its url is neither cached nor sent on
+func (h *accountHandler) deletionPage(c *gin.Context, status int, data gin.H) {
+	var page bytes.Buffer
+	err := confirmDeletionPage.Execute(&page, data)
+	if err != nil {
+		h.logger.Errorf("render deletion page failed: %v", err)
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Header("Referrer-Policy", "no-referrer")
+	c.Data(status, "text/html; charset=utf-8", page.Bytes())
+}
+
+func (h *accountHandler) CancelDeletion(c *gin.Context) {
+	userID, _ := userIDFromContext(c)
+	err := h.accountUsecase.CancelDeletion(c, userID)
+	if err != nil {
+		h.error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dtos.BaseResponse{
+		Code:    0,
+		Message: "Account deletion cancelled",
+	})
+}
+
+func (h *accountHandler) error(c *gin.Context, err error) {
+	for code, knownErr := range []error{
+		usecases.AccountUserNotFound,
+		usecases.RequestDeletionAlreadyScheduled,
+		usecases.ConfirmDeletionTokenInvalid,
+		usecases.CancelDeletionNotScheduled,
+	} {
+		if errors.Is(err, knownErr) {
+			c.JSON(http.StatusOK, dtos.BaseResponse{
+				Code:    code + 1,
+				Message: knownErr.Error(),
+				Error: &dtos.ErrorResponse{
+					ErrorDetails: err.Error(),
+				},
+			})
+			return
+		}
+	}
+
+	c.JSON(http.StatusInternalServerError, dtos.BaseResponse{
+		Message: InternalServerError,
+		Error: &dtos.ErrorResponse{
+			ErrorDetails: err.Error(),
+		},
+	})
+}
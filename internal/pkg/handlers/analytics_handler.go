@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"errors"
+	"go-server/internal/pkg/domains/interfaces"
+	"go-server/internal/pkg/domains/models/dtos"
+	"go-server/internal/pkg/domains/models/entities"
+	"go-server/internal/pkg/repositories"
+	"go-server/internal/pkg/usecases"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// defaultReportDays is the range of a report when no from date is given
+const defaultReportDays = 30
+
+type analyticsHandler struct {
+	analyticsUsecase interfaces.AnalyticsUsecase
+	db               *gorm.DB
+	logger           *logrus.Logger
+}
+
+func NewAnalyticsHandler(
+	logger *logrus.Logger,
+	db *gorm.DB,
+) *analyticsHandler {
+	analyticsRepo := repositories.NewAnalyticsRepository(db, logger)
+	analyticsUsecase := usecases.NewAnalyticsUsecase(analyticsRepo, logger)
+
+	return &analyticsHandler{
+		analyticsUsecase,
+		db,
+		logger,
+	}
+}
+
+func (h *analyticsHandler) Track(c *gin.Context) {
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, dtos.BaseResponse{
+			Code:    1,
+			Message: "Unauthorized",
+		})
+		return
+	}
+
+	req := dtos.TrackEventsRequestDto{}
+	err := c.ShouldBindJSON(&req)
+	if err != nil {
+		c.JSON(http.StatusOK, dtos.BaseResponse{
+			Code:    400,
+			Message: BadRequest,
+			Error: &dtos.ErrorResponse{
+				ErrorDetails: err.Error(),
+			},
+		})
+		return
+	}
+
+	err = h.analyticsUsecase.Track(c, h.db, userID, req)
+	if err != nil {
+		if errors.Is(err, usecases.TrackContentNotFound) {
+			c.JSON(http.StatusOK, dtos.BaseResponse{
+				Code:    2,
+				Message: err.Error(),
+				Error: &dtos.ErrorResponse{
+					ErrorDetails: err,
+				},
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dtos.BaseResponse{
+			Message: InternalServerError,
+			Error: &dtos.ErrorResponse{
+				ErrorDetails: err,
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dtos.BaseResponse{
+		Code:    0,
+		Message: "OK",
+	})
+}
+
+// BannerReport returns the impressions, clicks and CTR of a banner per day,
+// between the from and to query parameters (unix time, default the last 30 days)
+func (h *analyticsHandler) BannerReport(c *gin.Context) {
+	bannerID, err := strconv.Atoi(c.Param("banner_id"))
+	if err != nil {
+		c.JSON(http.StatusOK, dtos.BaseResponse{
+			Code:    400,
+			Message: BadRequest,
+			Error: &dtos.ErrorResponse{
+				ErrorDetails: err.Error(),
+			},
+		})
+		return
+	}
+
+	from, to, err := reportRangeFromQuery(c)
+	if err != nil {
+		c.JSON(http.StatusOK, dtos.BaseResponse{
+			Code:    400,
+			Message: BadRequest,
+			Error: &dtos.ErrorResponse{
+				ErrorDetails: err.Error(),
+			},
+		})
+		return
+	}
+
+	report, err := h.analyticsUsecase.Report(c, entities.ContentTypeBanner, bannerID, from, to)
+	if err != nil {
+		if errors.Is(err, usecases.ReportContentNotFound) {
+			c.JSON(http.StatusOK, dtos.BaseResponse{
+				Code:    1,
+				Message: "Banner not found",
+				Error: &dtos.ErrorResponse{
+					ErrorDetails: err,
+				},
+			})
+			return
+		}
+		if errors.Is(err, usecases.ReportRangeInvalid) {
+			c.JSON(http.StatusOK, dtos.BaseResponse{
+				Code:    2,
+				Message: err.Error(),
+				Error: &dtos.ErrorResponse{
+					ErrorDetails: err,
+				},
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dtos.BaseResponse{
+			Message: InternalServerError,
+			Error: &dtos.ErrorResponse{
+				ErrorDetails: err,
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dtos.BaseResponse{
+		Code:    0,
+		Message: "OK",
+		Data: gin.H{
+			"report": report,
+		},
+	})
+}
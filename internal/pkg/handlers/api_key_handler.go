@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"errors"
+	"go-server/internal/pkg/domains/interfaces"
+	"go-server/internal/pkg/domains/models/dtos"
+	"go-server/internal/pkg/repositories"
+	"go-server/internal/pkg/usecases"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type apiKeyHandler struct {
+	apiKeyUsecase interfaces.APIKeyUsecase
+	logger        *logrus.Logger
+	db            *gorm.DB
+}
+
+func NewAPIKeyHandler(logger *logrus.Logger, db *gorm.DB) *apiKeyHandler {
+	apiKeyRepo := repositories.NewAPIKeyRepository(db, logger)
+	apiKeyUsecase := usecases.NewAPIKeyUsecase(apiKeyRepo, logger)
+
+	return &apiKeyHandler{
+		apiKeyUsecase,
+		logger,
+		db,
+	}
+}
+
+// CreateAPIKey creates a key for a partner, the response is the only time the key is shown
+func (h *apiKeyHandler) CreateAPIKey(c *gin.Context) {
+	req := dtos.CreateAPIKeyRequestDto{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusOK, dtos.BaseResponse{
+			Code:    400,
+			Message: BadRequest,
+			Error: &dtos.ErrorResponse{
+				ErrorDetails: err.Error(),
+			},
+		})
+		return
+	}
+
+	userID, _ := userIDFromContext(c)
+	apiKey, err := h.apiKeyUsecase.Create(c, userID, req)
+	if err != nil {
+		h.error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dtos.BaseResponse{
+		Code:    0,
+		Message: "Created success",
+		Data:    apiKey,
+	})
+}
+
+func (h *apiKeyHandler) ListAPIKeys(c *gin.Context) {
+	apiKeys, err := h.apiKeyUsecase.FindAll(c)
+	if err != nil {
+		h.error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dtos.BaseResponse{
+		Code:    0,
+		Message: "OK",
+		Data: gin.H{
+			"api_keys": apiKeys,
+		},
+	})
+}
+
+func (h *apiKeyHandler) RevokeAPIKey(c *gin.Context) {
+	apiKeyID, ok := h.apiKeyIDParam(c)
+	if !ok {
+		return
+	}
+
+	err := h.apiKeyUsecase.Revoke(c, apiKeyID)
+	if err != nil {
+		h.error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dtos.BaseResponse{
+		Code:    0,
+		Message: "Revoked success",
+	})
+}
+
+// APIKeyUsage reports the daily requests of a key between the from and to unix timestamps
+func (h *apiKeyHandler) APIKeyUsage(c *gin.Context) {
+	apiKeyID, ok := h.apiKeyIDParam(c)
+	if !ok {
+		return
+	}
+
+	from, to, err := reportRangeFromQuery(c)
+	if err != nil {
+		c.JSON(http.StatusOK, dtos.BaseResponse{
+			Code:    400,
+			Message: BadRequest,
+			Error: &dtos.ErrorResponse{
+				ErrorDetails: err.Error(),
+			},
+		})
+		return
+	}
+
+	report, err := h.apiKeyUsecase.Usage(c, apiKeyID, from, to)
+	if err != nil {
+		h.error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dtos.BaseResponse{
+		Code:    0,
+		Message: "OK",
+		Data: gin.H{
+			"report": report,
+		},
+	})
+}
+
+func (h *apiKeyHandler) apiKeyIDParam(c *gin.Context) (int, bool) {
+	apiKeyID, err := strconv.Atoi(c.Param("api_key_id"))
+	if err != nil {
+		c.JSON(http.StatusOK, dtos.BaseResponse{
+			Code:    400,
+			Message: BadRequest,
+			Error: &dtos.ErrorResponse{
+				ErrorDetails: err.Error(),
+			},
+		})
+		return 0, false
+	}
+
+	return apiKeyID, true
+}
+
+func (h *apiKeyHandler) error(c *gin.Context, err error) {
+	for code, knownErr := range []error{
+		usecases.APIKeyNotFound,
+		usecases.APIKeyAlreadyRevoked,
+		usecases.CreateAPIKeyScopeInvalid,
+		usecases.CreateAPIKeyExpiryInvalid,
+		usecases.ReportRangeInvalid,
+	} {
+		if errors.Is(err, knownErr) {
+			c.JSON(http.StatusOK, dtos.BaseResponse{
+				Code:    code + 1,
+				Message: knownErr.Error(),
+				Error: &dtos.ErrorResponse{
+					ErrorDetails: err.Error(),
+				},
+			})
+			return
+		}
+	}
+
+	c.JSON(http.StatusInternalServerError, dtos.BaseResponse{
+		Message: InternalServerError,
+		Error: &dtos.ErrorResponse{
+			ErrorDetails: err.Error(),
+		},
+	})
+}
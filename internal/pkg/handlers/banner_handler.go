@@ -16,6 +16,7 @@ import (
 
 type bannerHandler struct {
 	bannerUsecase interfaces.BannerUsecase
+	db            *gorm.DB
 	logger        *logrus.Logger
 }
 
@@ -24,10 +25,12 @@ func NewBannerHandler(
 	db *gorm.DB,
 ) *bannerHandler {
 	bannerRepo := repositories.NewBannerRepository(db, logger)
-	bannerUsecase := usecases.NewBannerUsecase(bannerRepo, logger)
+	translationRepo := repositories.NewTranslationRepository(db, logger)
+	bannerUsecase := usecases.NewBannerUsecase(bannerRepo, translationRepo, logger)
 
 	return &bannerHandler{
 		bannerUsecase,
+		db,
 		logger,
 	}
 }
@@ -46,8 +49,48 @@ func (h *bannerHandler) CreateBanner(c *gin.Context) {
 		return
 	}
 
-	banner, err := h.bannerUsecase.Create(c, req)
+	banner, err := h.bannerUsecase.Create(c, h.db, req)
 	if err != nil {
+		if errors.Is(err, usecases.TranslationLocaleNotSupported) {
+			c.JSON(http.StatusOK, dtos.BaseResponse{
+				Code:    1,
+				Message: err.Error(),
+				Error: &dtos.ErrorResponse{
+					ErrorDetails: err,
+				},
+			})
+			return
+		}
+		if errors.Is(err, usecases.BannerScheduleInvalid) {
+			c.JSON(http.StatusOK, dtos.BaseResponse{
+				Code:    2,
+				Message: err.Error(),
+				Error: &dtos.ErrorResponse{
+					ErrorDetails: err,
+				},
+			})
+			return
+		}
+		if errors.Is(err, usecases.BannerTargetInvalid) {
+			c.JSON(http.StatusOK, dtos.BaseResponse{
+				Code:    3,
+				Message: err.Error(),
+				Error: &dtos.ErrorResponse{
+					ErrorDetails: err,
+				},
+			})
+			return
+		}
+		if errors.Is(err, usecases.BannerTargetNotFound) {
+			c.JSON(http.StatusOK, dtos.BaseResponse{
+				Code:    4,
+				Message: err.Error(),
+				Error: &dtos.ErrorResponse{
+					ErrorDetails: err,
+				},
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, dtos.BaseResponse{
 			Message: InternalServerError,
 			Error: &dtos.ErrorResponse{
@@ -78,6 +121,36 @@ func (h *bannerHandler) ListBanner(c *gin.Context) {
 		return
 	}
 
+	for i := range banners {
+		banners[i].Translate(c.GetString("locale"))
+	}
+
+	c.JSON(http.StatusOK, dtos.BaseResponse{
+		Code:    0,
+		Message: "OK",
+		Data: gin.H{
+			"banners": banners,
+		},
+	})
+}
+
+// ListAppBanner lists the banners currently displayed in the app, by position
+func (h *bannerHandler) ListAppBanner(c *gin.Context) {
+	banners, err := h.bannerUsecase.FindLive(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dtos.BaseResponse{
+			Message: InternalServerError,
+			Error: &dtos.ErrorResponse{
+				ErrorDetails: err,
+			},
+		})
+		return
+	}
+
+	for i := range banners {
+		banners[i].Translate(c.GetString("locale"))
+	}
+
 	c.JSON(http.StatusOK, dtos.BaseResponse{
 		Code:    0,
 		Message: "OK",
@@ -115,7 +188,7 @@ func (h *bannerHandler) Update(c *gin.Context) {
 		return
 	}
 
-	banner, err := h.bannerUsecase.Update(c, req, map[string]interface{}{
+	banner, err := h.bannerUsecase.Update(c, h.db, req, map[string]interface{}{
 		"id": bannerID,
 	})
 
@@ -130,6 +203,46 @@ func (h *bannerHandler) Update(c *gin.Context) {
 			})
 			return
 		}
+		if errors.Is(err, usecases.TranslationLocaleNotSupported) {
+			c.JSON(http.StatusOK, dtos.BaseResponse{
+				Code:    2,
+				Message: err.Error(),
+				Error: &dtos.ErrorResponse{
+					ErrorDetails: err,
+				},
+			})
+			return
+		}
+		if errors.Is(err, usecases.BannerScheduleInvalid) {
+			c.JSON(http.StatusOK, dtos.BaseResponse{
+				Code:    3,
+				Message: err.Error(),
+				Error: &dtos.ErrorResponse{
+					ErrorDetails: err,
+				},
+			})
+			return
+		}
+		if errors.Is(err, usecases.BannerTargetInvalid) {
+			c.JSON(http.StatusOK, dtos.BaseResponse{
+				Code:    4,
+				Message: err.Error(),
+				Error: &dtos.ErrorResponse{
+					ErrorDetails: err,
+				},
+			})
+			return
+		}
+		if errors.Is(err, usecases.BannerTargetNotFound) {
+			c.JSON(http.StatusOK, dtos.BaseResponse{
+				Code:    5,
+				Message: err.Error(),
+				Error: &dtos.ErrorResponse{
+					ErrorDetails: err,
+				},
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, dtos.BaseResponse{
 			Message: InternalServerError,
 			Error: &dtos.ErrorResponse{
@@ -186,6 +299,57 @@ func (h *bannerHandler) DetailBanner(c *gin.Context) {
 		return
 	}
 
+	banner.Translate(c.GetString("locale"))
+
+	c.JSON(http.StatusOK, dtos.BaseResponse{
+		Code:    0,
+		Message: "OK",
+		Data: dtos.UpdateBannerResponseDto{
+			Banner: banner,
+		},
+	})
+}
+
+// DetailAppBanner is a banner currently displayed in the app, scheduled, expired and
+// inactive banners are not found
+func (h *bannerHandler) DetailAppBanner(c *gin.Context) {
+	bannerIDParam := c.Param("banner_id")
+
+	bannerID, err := strconv.Atoi(bannerIDParam)
+	if err != nil {
+		c.JSON(http.StatusOK, dtos.BaseResponse{
+			Code:    400,
+			Message: BadRequest,
+			Error: &dtos.ErrorResponse{
+				ErrorDetails: err.Error(),
+			},
+		})
+		return
+	}
+
+	banner, err := h.bannerUsecase.TakeLive(c, bannerID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusOK, dtos.BaseResponse{
+				Code:    1,
+				Message: "Banner not found",
+				Error: &dtos.ErrorResponse{
+					ErrorDetails: err,
+				},
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dtos.BaseResponse{
+			Message: InternalServerError,
+			Error: &dtos.ErrorResponse{
+				ErrorDetails: err,
+			},
+		})
+		return
+	}
+
+	banner.Translate(c.GetString("locale"))
+
 	c.JSON(http.StatusOK, dtos.BaseResponse{
 		Code:    0,
 		Message: "OK",
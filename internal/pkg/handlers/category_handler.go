@@ -16,6 +16,7 @@ import (
 
 type categoryHandler struct {
 	categoryUsecase interfaces.CategoryUsecase
+	db              *gorm.DB
 	logger          *logrus.Logger
 }
 
@@ -24,10 +25,12 @@ func NewCategoryHandler(
 	db *gorm.DB,
 ) *categoryHandler {
 	categoryRepo := repositories.NewCategoryRepository(db, logger)
-	categoryUsecase := usecases.NewCategoryRepository(categoryRepo, logger)
+	translationRepo := repositories.NewTranslationRepository(db, logger)
+	categoryUsecase := usecases.NewCategoryRepository(categoryRepo, translationRepo, logger)
 
 	return &categoryHandler{
 		categoryUsecase,
+		db,
 		logger,
 	}
 }
@@ -46,8 +49,28 @@ func (h *categoryHandler) CreateCategory(c *gin.Context) {
 		return
 	}
 
-	category, err := h.categoryUsecase.Create(c, req)
+	category, err := h.categoryUsecase.Create(c, h.db, req)
 	if err != nil {
+		if errors.Is(err, usecases.TranslationLocaleNotSupported) {
+			c.JSON(http.StatusOK, dtos.BaseResponse{
+				Code:    1,
+				Message: err.Error(),
+				Error: &dtos.ErrorResponse{
+					ErrorDetails: err,
+				},
+			})
+			return
+		}
+		if errors.Is(err, usecases.CreateCategoryParentNotFound) {
+			c.JSON(http.StatusOK, dtos.BaseResponse{
+				Code:    2,
+				Message: err.Error(),
+				Error: &dtos.ErrorResponse{
+					ErrorDetails: err,
+				},
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, dtos.BaseResponse{
 			Message: InternalServerError,
 			Error: &dtos.ErrorResponse{
@@ -78,6 +101,10 @@ func (h *categoryHandler) ListCategory(c *gin.Context) {
 		return
 	}
 
+	for i := range categories {
+		categories[i].Translate(c.GetString("locale"))
+	}
+
 	c.JSON(http.StatusOK, dtos.BaseResponse{
 		Code:    0,
 		Message: "OK",
@@ -115,7 +142,7 @@ func (h *categoryHandler) Update(c *gin.Context) {
 		return
 	}
 
-	category, err := h.categoryUsecase.Update(c, req, map[string]interface{}{
+	category, err := h.categoryUsecase.Update(c, h.db, req, map[string]interface{}{
 		"id": categoryID,
 	})
 
@@ -130,6 +157,36 @@ func (h *categoryHandler) Update(c *gin.Context) {
 			})
 			return
 		}
+		if errors.Is(err, usecases.TranslationLocaleNotSupported) {
+			c.JSON(http.StatusOK, dtos.BaseResponse{
+				Code:    2,
+				Message: err.Error(),
+				Error: &dtos.ErrorResponse{
+					ErrorDetails: err,
+				},
+			})
+			return
+		}
+		if errors.Is(err, usecases.UpdateCategoryParentNotFound) {
+			c.JSON(http.StatusOK, dtos.BaseResponse{
+				Code:    3,
+				Message: err.Error(),
+				Error: &dtos.ErrorResponse{
+					ErrorDetails: err,
+				},
+			})
+			return
+		}
+		if errors.Is(err, usecases.UpdateCategoryParentCycle) {
+			c.JSON(http.StatusOK, dtos.BaseResponse{
+				Code:    4,
+				Message: err.Error(),
+				Error: &dtos.ErrorResponse{
+					ErrorDetails: err,
+				},
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, dtos.BaseResponse{
 			Message: InternalServerError,
 			Error: &dtos.ErrorResponse{
@@ -186,6 +243,8 @@ func (h *categoryHandler) DetailCategory(c *gin.Context) {
 		return
 	}
 
+	category.Translate(c.GetString("locale"))
+
 	c.JSON(http.StatusOK, dtos.BaseResponse{
 		Code:    0,
 		Message: "OK",
@@ -234,6 +293,16 @@ func (h *categoryHandler) DeleteCategory(c *gin.Context) {
 			})
 			return
 		}
+		if errors.Is(err, usecases.DeleteCategoryHasChildren) {
+			c.JSON(http.StatusOK, dtos.BaseResponse{
+				Code:    3,
+				Message: err.Error(),
+				Error: &dtos.ErrorResponse{
+					ErrorDetails: err,
+				},
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, dtos.BaseResponse{
 			Message: InternalServerError,
 			Error: &dtos.ErrorResponse{
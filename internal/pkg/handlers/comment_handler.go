@@ -2,25 +2,40 @@ package handlers
 
 import (
 	"errors"
+	"go-server/internal/pkg/domains/interfaces"
 	"go-server/internal/pkg/domains/models/dtos"
 	"go-server/internal/pkg/domains/models/entities"
+	"go-server/internal/pkg/repositories"
+	"go-server/internal/pkg/usecases"
+	"go-server/pkg/shared/middleware"
+	"go-server/pkg/shared/moderation"
+	"go-server/pkg/shared/realtime"
+	"go-server/pkg/shared/utils"
 	"net/http"
 	"strconv"
 
+	"github.com/cloudinary/cloudinary-go/v2"
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
 )
 
 type commentHandler struct {
-	db     *gorm.DB
-	logger *logrus.Logger
+	commentUsecase interfaces.CommentUsecase
+	db             *gorm.DB
+	logger         *logrus.Logger
 }
 
-func NewCommentHandler(db *gorm.DB, logger *logrus.Logger) *commentHandler {
+func NewCommentHandler(db *gorm.DB, logger *logrus.Logger, cld *cloudinary.Cloudinary, hub realtime.Publisher) *commentHandler {
+	commentRepo := repositories.NewCommentRepository(db, logger)
+	moderationRepo := repositories.NewCommentModerationRepository(db, logger)
+	uploadUsecase := usecases.NewUploadUsecase(cld, logger)
+	commentUsecase := usecases.NewCommentUsecase(commentRepo, moderationRepo, uploadUsecase, moderation.NewFilterFromEnv(), newNotificationUsecase(db, hub, logger), logger)
+
 	return &commentHandler{
-		db:     db,
-		logger: logger,
+		commentUsecase: commentUsecase,
+		db:             db,
+		logger:         logger,
 	}
 }
 
@@ -38,15 +53,49 @@ func (h *commentHandler) Create(c *gin.Context) {
 		return
 	}
 
-	newComment := entities.Comment{
-		PlaceID: req.PlaceID,
-		UserID:  req.UserID,
-		Comment: req.Comment,
-		Rate:    req.Rate,
-	}
-
-	err = h.db.Create(&newComment).Error
+	userID, _ := userIDFromContext(c)
+	newComment, created, err := h.commentUsecase.Create(c, h.db, userID, req)
 	if err != nil {
+		if errors.Is(err, usecases.CreateCommentParentNotFound) {
+			c.JSON(http.StatusOK, dtos.BaseResponse{
+				Code:    1,
+				Message: err.Error(),
+				Error: &dtos.ErrorResponse{
+					ErrorDetails: err,
+				},
+			})
+			return
+		}
+		if errors.Is(err, usecases.CreateCommentParentIsReply) {
+			c.JSON(http.StatusOK, dtos.BaseResponse{
+				Code:    2,
+				Message: err.Error(),
+				Error: &dtos.ErrorResponse{
+					ErrorDetails: err,
+				},
+			})
+			return
+		}
+		if errors.Is(err, usecases.CommentImagesTooMany) {
+			c.JSON(http.StatusOK, dtos.BaseResponse{
+				Code:    3,
+				Message: err.Error(),
+				Error: &dtos.ErrorResponse{
+					ErrorDetails: gin.H{"max_images": usecases.CommentMaxImages()},
+				},
+			})
+			return
+		}
+		if errors.Is(err, usecases.CommentImageInvalid) {
+			c.JSON(http.StatusOK, dtos.BaseResponse{
+				Code:    4,
+				Message: err.Error(),
+				Error: &dtos.ErrorResponse{
+					ErrorDetails: err,
+				},
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, dtos.BaseResponse{
 			Message: InternalServerError,
 			Error: &dtos.ErrorResponse{
@@ -56,12 +105,20 @@ func (h *commentHandler) Create(c *gin.Context) {
 		return
 	}
 
+	message := "Created success"
+	if !created {
+		// the user had already reviewed the place
+		message = "Updated success"
+	}
+
 	c.JSON(http.StatusOK, dtos.BaseResponse{
 		Code:    0,
-		Message: "Created success",
+		Message: message,
 		Data: gin.H{
 			"comment": gin.H{
-				"id": newComment.ID,
+				"id":                newComment.ID,
+				"parent_comment_id": newComment.ParentCommentID,
+				"status":            newComment.Status,
 			},
 		},
 	})
@@ -94,10 +151,21 @@ func (h *commentHandler) Update(c *gin.Context) {
 		return
 	}
 
-	var comment entities.Comment
-	err = h.db.Where("id = ?", commentID).Find(&comment).Error
+	userID, _ := userIDFromContext(c)
+	moderator, err := h.moderator(c, userID)
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusInternalServerError, dtos.BaseResponse{
+			Code:    0,
+			Message: InternalServerError,
+			Error: &dtos.ErrorResponse{
+				ErrorDetails: err,
+			},
+		})
+		return
+	}
+	_, err = h.commentUsecase.Update(c, h.db, userID, moderator, commentID, req)
+	if err != nil {
+		if errors.Is(err, usecases.UpdateCommentNotFound) {
 			c.JSON(http.StatusOK, dtos.BaseResponse{
 				Code:    1,
 				Message: "Comment not found",
@@ -107,6 +175,56 @@ func (h *commentHandler) Update(c *gin.Context) {
 			})
 			return
 		}
+		if errors.Is(err, usecases.UpdateCommentForbidden) {
+			c.JSON(http.StatusForbidden, dtos.BaseResponse{
+				Code:    2,
+				Message: "Forbidden",
+				Error: &dtos.ErrorResponse{
+					ErrorDetails: err.Error(),
+				},
+			})
+			return
+		}
+		if errors.Is(err, usecases.CommentImagesTooMany) {
+			c.JSON(http.StatusOK, dtos.BaseResponse{
+				Code:    3,
+				Message: err.Error(),
+				Error: &dtos.ErrorResponse{
+					ErrorDetails: gin.H{"max_images": usecases.CommentMaxImages()},
+				},
+			})
+			return
+		}
+		if errors.Is(err, usecases.CommentImageInvalid) {
+			c.JSON(http.StatusOK, dtos.BaseResponse{
+				Code:    4,
+				Message: err.Error(),
+				Error: &dtos.ErrorResponse{
+					ErrorDetails: err,
+				},
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dtos.BaseResponse{
+			Code:    0,
+			Message: InternalServerError,
+			Error: &dtos.ErrorResponse{
+				ErrorDetails: err,
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dtos.BaseResponse{
+		Code:    0,
+		Message: "Updated success",
+	})
+}
+
+func (h *commentHandler) Delete(c *gin.Context) {
+	commentIDParam := c.Param("comment_id")
+	commentID, err := strconv.Atoi(commentIDParam)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, dtos.BaseResponse{
 			Code:    0,
 			Message: InternalServerError,
@@ -117,11 +235,40 @@ func (h *commentHandler) Update(c *gin.Context) {
 		return
 	}
 
-	err = h.db.Model(&comment).Where("id = ?", commentID).Updates(map[string]interface{}{
-		"comment": req.Comment,
-		"rate":    req.Rate,
-	}).Error
+	userID, _ := userIDFromContext(c)
+	moderator, err := h.moderator(c, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dtos.BaseResponse{
+			Code:    0,
+			Message: InternalServerError,
+			Error: &dtos.ErrorResponse{
+				ErrorDetails: err,
+			},
+		})
+		return
+	}
+	err = h.commentUsecase.Delete(c, h.db, userID, moderator, commentID)
 	if err != nil {
+		if errors.Is(err, usecases.DeleteCommentNotFound) {
+			c.JSON(http.StatusOK, dtos.BaseResponse{
+				Code:    1,
+				Message: "Comment not found",
+				Error: &dtos.ErrorResponse{
+					ErrorDetails: err,
+				},
+			})
+			return
+		}
+		if errors.Is(err, usecases.DeleteCommentForbidden) {
+			c.JSON(http.StatusForbidden, dtos.BaseResponse{
+				Code:    2,
+				Message: "Forbidden",
+				Error: &dtos.ErrorResponse{
+					ErrorDetails: err.Error(),
+				},
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, dtos.BaseResponse{
 			Code:    0,
 			Message: InternalServerError,
@@ -134,14 +281,49 @@ func (h *commentHandler) Update(c *gin.Context) {
 
 	c.JSON(http.StatusOK, dtos.BaseResponse{
 		Code:    0,
-		Message: "Updated success",
+		Message: "Deleted success",
 	})
 }
 
-func (h *commentHandler) Delete(c *gin.Context) {
+func (h *commentHandler) ListReplies(c *gin.Context) {
 	commentIDParam := c.Param("comment_id")
 	commentID, err := strconv.Atoi(commentIDParam)
 	if err != nil {
+		c.JSON(http.StatusOK, dtos.BaseResponse{
+			Code:    400,
+			Message: BadRequest,
+			Error: &dtos.ErrorResponse{
+				ErrorDetails: err,
+			},
+		})
+		return
+	}
+
+	pageData, err := pageDataFromQuery(c)
+	if err != nil {
+		c.JSON(http.StatusOK, dtos.BaseResponse{
+			Code:    400,
+			Message: BadRequest,
+			Error: &dtos.ErrorResponse{
+				ErrorDetails: err,
+			},
+		})
+		return
+	}
+
+	userID, _ := userIDFromContext(c)
+	replies, count, err := h.commentUsecase.FindRepliesPaginate(c, userID, commentID, pageData)
+	if err != nil {
+		if errors.Is(err, usecases.ListRepliesCommentNotFound) {
+			c.JSON(http.StatusOK, dtos.BaseResponse{
+				Code:    1,
+				Message: err.Error(),
+				Error: &dtos.ErrorResponse{
+					ErrorDetails: err,
+				},
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, dtos.BaseResponse{
 			Code:    0,
 			Message: InternalServerError,
@@ -152,8 +334,69 @@ func (h *commentHandler) Delete(c *gin.Context) {
 		return
 	}
 
-	err = h.db.Where("id = ?", commentID).Delete(&entities.Comment{}).Error
+	c.JSON(http.StatusOK, dtos.BaseResponse{
+		Code:    0,
+		Message: "OK",
+		Data: gin.H{
+			"replies":      replies,
+			"page":         pageData["page"],
+			"per_page":     pageData["per_page"],
+			"total_record": count,
+			"total_page":   utils.CalcTotalPage(count, pageData["per_page"]),
+		},
+	})
+}
+
+func (h *commentHandler) CreateOfficialResponse(c *gin.Context) {
+	commentIDParam := c.Param("comment_id")
+	commentID, err := strconv.Atoi(commentIDParam)
+	if err != nil {
+		c.JSON(http.StatusOK, dtos.BaseResponse{
+			Code:    400,
+			Message: BadRequest,
+			Error: &dtos.ErrorResponse{
+				ErrorDetails: err,
+			},
+		})
+		return
+	}
+
+	req := dtos.OfficialResponseRequestDto{}
+	err = c.ShouldBindJSON(&req)
+	if err != nil {
+		c.JSON(http.StatusOK, dtos.BaseResponse{
+			Code:    400,
+			Message: BadRequest,
+			Error: &dtos.ErrorResponse{
+				ErrorDetails: err,
+			},
+		})
+		return
+	}
+
+	userID, _ := userIDFromContext(c)
+	response, err := h.commentUsecase.RespondOfficially(c, userID, commentID, req)
 	if err != nil {
+		if errors.Is(err, usecases.OfficialResponseCommentNotFound) {
+			c.JSON(http.StatusOK, dtos.BaseResponse{
+				Code:    1,
+				Message: err.Error(),
+				Error: &dtos.ErrorResponse{
+					ErrorDetails: err,
+				},
+			})
+			return
+		}
+		if errors.Is(err, usecases.OfficialResponseCommentIsReply) {
+			c.JSON(http.StatusOK, dtos.BaseResponse{
+				Code:    2,
+				Message: err.Error(),
+				Error: &dtos.ErrorResponse{
+					ErrorDetails: err,
+				},
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, dtos.BaseResponse{
 			Code:    0,
 			Message: InternalServerError,
@@ -166,8 +409,141 @@ func (h *commentHandler) Delete(c *gin.Context) {
 
 	c.JSON(http.StatusOK, dtos.BaseResponse{
 		Code:    0,
-		Message: "Deleted success",
+		Message: "OK",
+		Data: gin.H{
+			"comment": gin.H{
+				"id": response.ID,
+			},
+		},
 	})
 }
 
+func (h *commentHandler) Vote(c *gin.Context) {
+	commentIDParam := c.Param("comment_id")
+	commentID, err := strconv.Atoi(commentIDParam)
+	if err != nil {
+		c.JSON(http.StatusOK, dtos.BaseResponse{
+			Code:    400,
+			Message: BadRequest,
+			Error: &dtos.ErrorResponse{
+				ErrorDetails: err,
+			},
+		})
+		return
+	}
 
+	req := dtos.VoteCommentRequestDto{}
+	err = c.ShouldBindJSON(&req)
+	if err != nil {
+		c.JSON(http.StatusOK, dtos.BaseResponse{
+			Code:    400,
+			Message: BadRequest,
+			Error: &dtos.ErrorResponse{
+				ErrorDetails: err,
+			},
+		})
+		return
+	}
+
+	userID, _ := userIDFromContext(c)
+	comment, err := h.commentUsecase.Vote(c, h.db, userID, commentID, req)
+	if err != nil {
+		if errors.Is(err, usecases.VoteCommentNotFound) {
+			c.JSON(http.StatusOK, dtos.BaseResponse{
+				Code:    1,
+				Message: err.Error(),
+				Error: &dtos.ErrorResponse{
+					ErrorDetails: err,
+				},
+			})
+			return
+		}
+		if errors.Is(err, usecases.VoteCommentIsOwn) {
+			c.JSON(http.StatusOK, dtos.BaseResponse{
+				Code:    2,
+				Message: err.Error(),
+				Error: &dtos.ErrorResponse{
+					ErrorDetails: err,
+				},
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dtos.BaseResponse{
+			Code:    0,
+			Message: InternalServerError,
+			Error: &dtos.ErrorResponse{
+				ErrorDetails: err,
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dtos.BaseResponse{
+		Code:    0,
+		Message: "OK",
+		Data: gin.H{
+			"comment": voteResponse(comment),
+		},
+	})
+}
+
+func (h *commentHandler) DeleteVote(c *gin.Context) {
+	commentIDParam := c.Param("comment_id")
+	commentID, err := strconv.Atoi(commentIDParam)
+	if err != nil {
+		c.JSON(http.StatusOK, dtos.BaseResponse{
+			Code:    400,
+			Message: BadRequest,
+			Error: &dtos.ErrorResponse{
+				ErrorDetails: err,
+			},
+		})
+		return
+	}
+
+	userID, _ := userIDFromContext(c)
+	comment, err := h.commentUsecase.DeleteVote(c, h.db, userID, commentID)
+	if err != nil {
+		if errors.Is(err, usecases.VoteCommentNotFound) {
+			c.JSON(http.StatusOK, dtos.BaseResponse{
+				Code:    1,
+				Message: err.Error(),
+				Error: &dtos.ErrorResponse{
+					ErrorDetails: err,
+				},
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dtos.BaseResponse{
+			Code:    0,
+			Message: InternalServerError,
+			Error: &dtos.ErrorResponse{
+				ErrorDetails: err,
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dtos.BaseResponse{
+		Code:    0,
+		Message: "OK",
+		Data: gin.H{
+			"comment": voteResponse(comment),
+		},
+	})
+}
+
+func voteResponse(comment entities.Comment) gin.H {
+	return gin.H{
+		"id":                comment.ID,
+		"helpful_count":     comment.HelpfulCount,
+		"not_helpful_count": comment.NotHelpfulCount,
+		"my_vote":           comment.MyVote,
+	}
+}
+
+// moderator tells whether the user may edit and delete the comments of others, any role
+// of the CMS sets is_admin so the permission is checked instead
+func (h *commentHandler) moderator(c *gin.Context, userID int) (bool, error) {
+	return middleware.HasPermission(h.db.WithContext(c), userID, entities.PermissionCommentsModerate)
+}
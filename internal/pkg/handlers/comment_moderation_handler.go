@@ -0,0 +1,277 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"go-server/internal/pkg/domains/interfaces"
+	"go-server/internal/pkg/domains/models/dtos"
+	"go-server/internal/pkg/repositories"
+	"go-server/internal/pkg/usecases"
+	"go-server/pkg/shared/realtime"
+	"go-server/pkg/shared/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type commentModerationHandler struct {
+	moderationUsecase interfaces.CommentModerationUsecase
+	db                *gorm.DB
+	logger            *logrus.Logger
+}
+
+func NewCommentModerationHandler(
+	logger *logrus.Logger,
+	db *gorm.DB,
+	hub realtime.Publisher,
+) *commentModerationHandler {
+	commentRepo := repositories.NewCommentRepository(db, logger)
+	moderationRepo := repositories.NewCommentModerationRepository(db, logger)
+
+	moderationUsecase := usecases.NewCommentModerationUsecase(
+		commentRepo,
+		moderationRepo,
+		newNotificationUsecase(db, hub, logger),
+		logger,
+	)
+
+	return &commentModerationHandler{
+		moderationUsecase,
+		db,
+		logger,
+	}
+}
+
+func (h *commentModerationHandler) Report(c *gin.Context) {
+	commentID, err := strconv.Atoi(c.Param("comment_id"))
+	if err != nil {
+		c.JSON(http.StatusOK, dtos.BaseResponse{
+			Code:    400,
+			Message: BadRequest,
+			Error: &dtos.ErrorResponse{
+				ErrorDetails: err,
+			},
+		})
+		return
+	}
+
+	req := dtos.ReportCommentRequestDto{}
+	err = c.ShouldBindJSON(&req)
+	if err != nil {
+		c.JSON(http.StatusOK, dtos.BaseResponse{
+			Code:    400,
+			Message: BadRequest,
+			Error: &dtos.ErrorResponse{
+				ErrorDetails: err,
+			},
+		})
+		return
+	}
+
+	userID, _ := userIDFromContext(c)
+	err = h.moderationUsecase.Report(c, userID, commentID, req)
+	if err != nil {
+		if errors.Is(err, usecases.ReportCommentNotFound) {
+			c.JSON(http.StatusOK, dtos.BaseResponse{
+				Code:    1,
+				Message: err.Error(),
+				Error: &dtos.ErrorResponse{
+					ErrorDetails: err,
+				},
+			})
+			return
+		}
+		if errors.Is(err, usecases.ReportCommentIsOwn) {
+			c.JSON(http.StatusOK, dtos.BaseResponse{
+				Code:    2,
+				Message: err.Error(),
+				Error: &dtos.ErrorResponse{
+					ErrorDetails: err,
+				},
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dtos.BaseResponse{
+			Code:    0,
+			Message: InternalServerError,
+			Error: &dtos.ErrorResponse{
+				ErrorDetails: err,
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dtos.BaseResponse{
+		Code:    0,
+		Message: "Reported success",
+	})
+}
+
+func (h *commentModerationHandler) ListQueue(c *gin.Context) {
+	pageData, err := pageDataFromQuery(c)
+	if err != nil {
+		c.JSON(http.StatusOK, dtos.BaseResponse{
+			Code:    400,
+			Message: BadRequest,
+			Error: &dtos.ErrorResponse{
+				ErrorDetails: err,
+			},
+		})
+		return
+	}
+
+	comments, count, err := h.moderationUsecase.FindQueuePaginate(c, pageData, c.Query("queue"))
+	if err != nil {
+		if errors.Is(err, usecases.ModerationQueueInvalid) {
+			c.JSON(http.StatusOK, dtos.BaseResponse{
+				Code:    1,
+				Message: err.Error(),
+				Error: &dtos.ErrorResponse{
+					ErrorDetails: err,
+				},
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dtos.BaseResponse{
+			Code:    0,
+			Message: InternalServerError,
+			Error: &dtos.ErrorResponse{
+				ErrorDetails: err,
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dtos.BaseResponse{
+		Code:    0,
+		Message: "OK",
+		Data: gin.H{
+			"comments":     comments,
+			"page":         pageData["page"],
+			"per_page":     pageData["per_page"],
+			"total_record": count,
+			"total_page":   utils.CalcTotalPage(count, pageData["per_page"]),
+		},
+	})
+}
+
+func (h *commentModerationHandler) Hide(c *gin.Context) {
+	h.moderate(c, h.moderationUsecase.Hide, "Hidden success")
+}
+
+func (h *commentModerationHandler) Restore(c *gin.Context) {
+	h.moderate(c, h.moderationUsecase.Restore, "Restored success")
+}
+
+func (h *commentModerationHandler) Delete(c *gin.Context) {
+	h.moderate(c, h.moderationUsecase.Delete, "Deleted success")
+}
+
+func (h *commentModerationHandler) ListLogs(c *gin.Context) {
+	commentID, err := strconv.Atoi(c.Param("comment_id"))
+	if err != nil {
+		c.JSON(http.StatusOK, dtos.BaseResponse{
+			Code:    400,
+			Message: BadRequest,
+			Error: &dtos.ErrorResponse{
+				ErrorDetails: err,
+			},
+		})
+		return
+	}
+
+	logs, err := h.moderationUsecase.FindLogs(c, commentID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dtos.BaseResponse{
+			Code:    0,
+			Message: InternalServerError,
+			Error: &dtos.ErrorResponse{
+				ErrorDetails: err,
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dtos.BaseResponse{
+		Code:    0,
+		Message: "OK",
+		Data: gin.H{
+			"logs": logs,
+		},
+	})
+}
+
+// moderate runs a moderation action of the authenticated admin, the note is optional
+func (h *commentModerationHandler) moderate(
+	c *gin.Context,
+	action func(ctx context.Context, db *gorm.DB, adminID int, commentID int, req dtos.ModerateCommentRequestDto) error,
+	message string,
+) {
+	commentID, err := strconv.Atoi(c.Param("comment_id"))
+	if err != nil {
+		c.JSON(http.StatusOK, dtos.BaseResponse{
+			Code:    400,
+			Message: BadRequest,
+			Error: &dtos.ErrorResponse{
+				ErrorDetails: err,
+			},
+		})
+		return
+	}
+
+	req := dtos.ModerateCommentRequestDto{}
+	if c.Request.ContentLength > 0 {
+		err = c.ShouldBindJSON(&req)
+		if err != nil {
+			c.JSON(http.StatusOK, dtos.BaseResponse{
+				Code:    400,
+				Message: BadRequest,
+				Error: &dtos.ErrorResponse{
+					ErrorDetails: err,
+				},
+			})
+			return
+		}
+	}
+
+	adminID, _ := userIDFromContext(c)
+	err = action(c, h.db, adminID, commentID, req)
+	if err != nil {
+		if errors.Is(err, usecases.ModerateCommentNotFound) {
+			c.JSON(http.StatusOK, dtos.BaseResponse{
+				Code:    1,
+				Message: err.Error(),
+				Error: &dtos.ErrorResponse{
+					ErrorDetails: err,
+				},
+			})
+			return
+		}
+		if errors.Is(err, usecases.ModerateCommentStatusUnchanged) {
+			c.JSON(http.StatusOK, dtos.BaseResponse{
+				Code:    2,
+				Message: err.Error(),
+				Error: &dtos.ErrorResponse{
+					ErrorDetails: err,
+				},
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dtos.BaseResponse{
+			Code:    0,
+			Message: InternalServerError,
+			Error: &dtos.ErrorResponse{
+				ErrorDetails: err,
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dtos.BaseResponse{
+		Code:    0,
+		Message: message,
+	})
+}
@@ -1,6 +1,100 @@
 package handlers
 
+import (
+	"go-server/internal/pkg/domains/models/dtos"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
 const (
 	BadRequest          string = "Bad Request"
 	InternalServerError string = "Internal Server Error"
 )
+
+const (
+	defaultPerPage = 20
+	maxPerPage     = 100
+)
+
+// userIDFromContext returns the user_id claim set by middleware.CheckAuthentication
+func userIDFromContext(c *gin.Context) (int, bool) {
+	userID, ok := c.Get("user_id")
+	if !ok {
+		return 0, false
+	}
+
+	// numbers of the JWT claims are decoded as float64
+	switch value := userID.(type) {
+	case float64:
+		return int(value), true
+	case int:
+		return value, true
+	}
+
+	return 0, false
+}
+
+// pageDataFromQuery reads the page and per_page queries, defaulting to the first page of
+// defaultPerPage, per_page is capped at maxPerPage
+func pageDataFromQuery(c *gin.Context) (map[string]int, error) {
+	pageData := map[string]int{
+		"page":     1,
+		"per_page": defaultPerPage,
+	}
+	for _, key := range []string{"page", "per_page"} {
+		query, ok := c.GetQuery(key)
+		if !ok {
+			continue
+		}
+		value, err := strconv.Atoi(query)
+		if err != nil {
+			return nil, err
+		}
+		pageData[key] = value
+	}
+	if pageData["page"] < 1 {
+		pageData["page"] = 1
+	}
+	if pageData["per_page"] < 1 {
+		pageData["per_page"] = defaultPerPage
+	}
+	if pageData["per_page"] > maxPerPage {
+		pageData["per_page"] = maxPerPage
+	}
+
+	return pageData, nil
+}
+
+// reportRangeFromQuery reads the from and to unix timestamps of a report, defaulting to
+// the last defaultReportDays days
+func reportRangeFromQuery(c *gin.Context) (time.Time, time.Time, error) {
+	to := time.Now()
+	if toQuery, ok := c.GetQuery("to"); ok {
+		toUnix, err := strconv.ParseInt(toQuery, 10, 64)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		to = time.Unix(toUnix, 0)
+	}
+
+	from := to.AddDate(0, 0, -(defaultReportDays - 1))
+	if fromQuery, ok := c.GetQuery("from"); ok {
+		fromUnix, err := strconv.ParseInt(fromQuery, 10, 64)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		from = time.Unix(fromUnix, 0)
+	}
+
+	return from, to, nil
+}
+
+// clientFromContext describes the device of the request for the session it starts
+func clientFromContext(c *gin.Context) dtos.ClientDto {
+	return dtos.ClientDto{
+		UserAgent: c.Request.UserAgent(),
+		IP:        c.ClientIP(),
+	}
+}
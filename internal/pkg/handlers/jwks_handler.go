@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"go-server/internal/pkg/domains/models/dtos"
+	"go-server/pkg/shared/auth"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// jwksMaxAge lets verifiers cache the key set, a new key must be published this long
+// before it starts signing
+const jwksMaxAge = "public, max-age=300"
+
+type jwksHandler struct {
+	logger *logrus.Logger
+}
+
+func NewJWKSHandler(logger *logrus.Logger) *jwksHandler {
+	return &jwksHandler{
+		logger,
+	}
+}
+
+// JWKS publishes the public keys of our tokens for other services, as a plain
+// JSON Web Key Set and not a BaseResponse so that JWT libraries can read it
+func (h *jwksHandler) JWKS(c *gin.Context) {
+	keys, err := auth.Keys()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dtos.BaseResponse{
+			Message: InternalServerError,
+			Error: &dtos.ErrorResponse{
+				ErrorDetails: err.Error(),
+			},
+		})
+		return
+	}
+
+	c.Header("Cache-Control", jwksMaxAge)
+	c.JSON(http.StatusOK, keys.JWKS())
+}
@@ -0,0 +1,217 @@
+package handlers
+
+import (
+	"errors"
+	"go-server/internal/pkg/domains/interfaces"
+	"go-server/internal/pkg/domains/models/dtos"
+	"go-server/internal/pkg/repositories"
+	"go-server/internal/pkg/services"
+	"go-server/internal/pkg/usecases"
+	"go-server/pkg/shared/realtime"
+	"go-server/pkg/shared/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type notificationHandler struct {
+	notificationUsecase interfaces.NotificationUsecase
+	logger              *logrus.Logger
+	db                  *gorm.DB
+}
+
+func NewNotificationHandler(logger *logrus.Logger, db *gorm.DB, hub realtime.Publisher) *notificationHandler {
+	return &notificationHandler{
+		newNotificationUsecase(db, hub, logger),
+		logger,
+		db,
+	}
+}
+
+// newNotificationUsecase is the notification service of the features notifying users
+func newNotificationUsecase(db *gorm.DB, hub realtime.Publisher, logger *logrus.Logger) interfaces.NotificationUsecase {
+	return usecases.NewNotificationUsecase(
+		repositories.NewNotificationRepository(db, logger),
+		repositories.NewUserRepository(db, logger),
+		services.NewMailService(),
+		hub,
+		logger,
+	)
+}
+
+// ListNotification lists the notifications of the caller, newest first, only the unread
+// ones with unread=true
+func (h *notificationHandler) ListNotification(c *gin.Context) {
+	pageData, err := pageDataFromQuery(c)
+	if err != nil {
+		c.JSON(http.StatusOK, dtos.BaseResponse{
+			Code:    400,
+			Message: BadRequest,
+			Error: &dtos.ErrorResponse{
+				ErrorDetails: err.Error(),
+			},
+		})
+		return
+	}
+
+	unreadOnly := false
+	if unreadQuery, ok := c.GetQuery("unread"); ok {
+		unreadOnly, err = strconv.ParseBool(unreadQuery)
+		if err != nil {
+			c.JSON(http.StatusOK, dtos.BaseResponse{
+				Code:    400,
+				Message: BadRequest,
+				Error: &dtos.ErrorResponse{
+					ErrorDetails: err.Error(),
+				},
+			})
+			return
+		}
+	}
+
+	userID, _ := userIDFromContext(c)
+	notifications, count, err := h.notificationUsecase.FindPaginate(c, userID, pageData, unreadOnly)
+	if err != nil {
+		h.error(c, err)
+		return
+	}
+
+	unreadCount, err := h.notificationUsecase.CountUnread(c, userID)
+	if err != nil {
+		h.error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dtos.BaseResponse{
+		Code:    0,
+		Message: "OK",
+		Data: gin.H{
+			"notifications": notifications,
+			"unread_count":  unreadCount,
+			"page":          pageData["page"],
+			"per_page":      pageData["per_page"],
+			"total_record":  count,
+			"total_page":    utils.CalcTotalPage(count, pageData["per_page"]),
+		},
+	})
+}
+
+func (h *notificationHandler) MarkRead(c *gin.Context) {
+	notificationID, err := strconv.Atoi(c.Param("notification_id"))
+	if err != nil {
+		c.JSON(http.StatusOK, dtos.BaseResponse{
+			Code:    400,
+			Message: BadRequest,
+			Error: &dtos.ErrorResponse{
+				ErrorDetails: err.Error(),
+			},
+		})
+		return
+	}
+
+	userID, _ := userIDFromContext(c)
+	notification, err := h.notificationUsecase.MarkRead(c, userID, notificationID)
+	if err != nil {
+		h.error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dtos.BaseResponse{
+		Code:    0,
+		Message: "OK",
+		Data: gin.H{
+			"notification": notification,
+		},
+	})
+}
+
+func (h *notificationHandler) MarkAllRead(c *gin.Context) {
+	userID, _ := userIDFromContext(c)
+	marked, err := h.notificationUsecase.MarkAllRead(c, userID)
+	if err != nil {
+		h.error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dtos.BaseResponse{
+		Code:    0,
+		Message: "OK",
+		Data: gin.H{
+			"marked": marked,
+		},
+	})
+}
+
+func (h *notificationHandler) ListPreferences(c *gin.Context) {
+	userID, _ := userIDFromContext(c)
+	preferences, err := h.notificationUsecase.FindPreferences(c, userID)
+	if err != nil {
+		h.error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dtos.BaseResponse{
+		Code:    0,
+		Message: "OK",
+		Data: gin.H{
+			"preferences": preferences,
+		},
+	})
+}
+
+func (h *notificationHandler) UpdatePreferences(c *gin.Context) {
+	req := dtos.UpdateNotificationPreferencesRequestDto{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusOK, dtos.BaseResponse{
+			Code:    400,
+			Message: BadRequest,
+			Error: &dtos.ErrorResponse{
+				ErrorDetails: err.Error(),
+			},
+		})
+		return
+	}
+
+	userID, _ := userIDFromContext(c)
+	preferences, err := h.notificationUsecase.UpdatePreferences(c, userID, req)
+	if err != nil {
+		h.error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dtos.BaseResponse{
+		Code:    0,
+		Message: "Updated success",
+		Data: gin.H{
+			"preferences": preferences,
+		},
+	})
+}
+
+func (h *notificationHandler) error(c *gin.Context, err error) {
+	for code, knownErr := range []error{
+		usecases.NotificationNotFound,
+		usecases.NotificationPreferenceTypeInvalid,
+	} {
+		if errors.Is(err, knownErr) {
+			c.JSON(http.StatusOK, dtos.BaseResponse{
+				Code:    code + 1,
+				Message: knownErr.Error(),
+				Error: &dtos.ErrorResponse{
+					ErrorDetails: err.Error(),
+				},
+			})
+			return
+		}
+	}
+
+	c.JSON(http.StatusInternalServerError, dtos.BaseResponse{
+		Message: InternalServerError,
+		Error: &dtos.ErrorResponse{
+			ErrorDetails: err.Error(),
+		},
+	})
+}
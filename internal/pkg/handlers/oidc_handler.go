@@ -0,0 +1,246 @@
+package handlers
+
+import (
+	"errors"
+	"go-server/internal/pkg/domains/interfaces"
+	"go-server/internal/pkg/domains/models/dtos"
+	"go-server/internal/pkg/domains/models/entities"
+	"go-server/internal/pkg/repositories"
+	"go-server/internal/pkg/services"
+	"go-server/internal/pkg/usecases"
+	"go-server/pkg/shared/auth"
+	"go-server/pkg/shared/realtime"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/oauth2"
+	"gorm.io/gorm"
+)
+
+const (
+	oidcLoginCookie  = "oidc_login"
+	oidcLoginPurpose = "oidc_login"
+	oidcLoginTTL     = 10 * time.Minute
+)
+
+type oidcHandler struct {
+	oauthUsecase     interfaces.OAuthUsecase
+	tokenUsecase     interfaces.TokenUsecase
+	twoFactorUsecase interfaces.TwoFactorUsecase
+	providers        map[string]*services.OIDCProvider
+	logger           *logrus.Logger
+	db               *gorm.DB
+}
+
+func NewOIDCHandler(logger *logrus.Logger, db *gorm.DB, sessions realtime.SessionCloser) *oidcHandler {
+	userRepo := repositories.NewUserRepository(db, logger)
+	userTokenRepo := repositories.NewUserTokenRepository(db, logger)
+	userIdentityRepo := repositories.NewUserIdentityRepository(db, logger)
+	mailService := services.NewMailService()
+	oauthUsecase := usecases.NewOAuthUsecase(userRepo, userIdentityRepo, userTokenRepo, mailService, sessions, logger)
+	tokenUsecase := usecases.NewTokenUsecase(userRepo, userTokenRepo, sessions, logger)
+	recoveryCodeRepo := repositories.NewUserRecoveryCodeRepository(db, logger)
+	loginAttemptUsecase := usecases.NewLoginAttemptUsecase(loginAttemptStore(db, logger), userRepo, mailService, logger)
+	twoFactorUsecase := usecases.NewTwoFactorUsecase(userRepo, recoveryCodeRepo, loginAttemptUsecase, logger)
+
+	return &oidcHandler{
+		oauthUsecase,
+		tokenUsecase,
+		twoFactorUsecase,
+		services.NewOIDCProvidersFromEnv(),
+		logger,
+		db,
+	}
+}
+
+// Login redirects to the provider. The state, nonce and PKCE verifier of the attempt are kept
+// in a signed cookie until the callback
+func (h *oidcHandler) Login(c *gin.Context) {
+	name := c.Param("provider")
+	provider, ok := h.providers[name]
+	if !ok {
+		c.JSON(http.StatusNotFound, dtos.BaseResponse{
+			Code:    1,
+			Message: "Provider not found",
+		})
+		return
+	}
+
+	state, _, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		h.internalError(c, err)
+		return
+	}
+	nonce, _, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		h.internalError(c, err)
+		return
+	}
+	verifier := oauth2.GenerateVerifier()
+
+	authURL, err := provider.AuthCodeURL(c, state, nonce, verifier)
+	if err != nil {
+		h.logger.Errorf("oidc provider %s unavailable: %v", name, err)
+		c.JSON(http.StatusBadGateway, dtos.BaseResponse{
+			Code:    2,
+			Message: "Provider unavailable",
+			Error: &dtos.ErrorResponse{
+				ErrorDetails: err.Error(),
+			},
+		})
+		return
+	}
+
+	cookie, _, err := auth.GenerateSignedToken(oidcLoginPurpose, map[string]interface{}{
+		"provider": name,
+		"state":    state,
+		"nonce":    nonce,
+		"verifier": verifier,
+	}, oidcLoginTTL)
+	if err != nil {
+		h.internalError(c, err)
+		return
+	}
+
+	c.SetCookie(oidcLoginCookie, cookie, int(oidcLoginTTL.Seconds()), "/api/auth/oidc", "", false, true)
+	c.Redirect(http.StatusTemporaryRedirect, authURL)
+}
+
+func (h *oidcHandler) Callback(c *gin.Context) {
+	name := c.Param("provider")
+	provider, ok := h.providers[name]
+	if !ok {
+		c.JSON(http.StatusNotFound, dtos.BaseResponse{
+			Code:    1,
+			Message: "Provider not found",
+		})
+		return
+	}
+
+	cookie, err := c.Cookie(oidcLoginCookie)
+	if err != nil {
+		h.invalidState(c, err.Error())
+		return
+	}
+	// the attempt is single use
+	c.SetCookie(oidcLoginCookie, "", -1, "/api/auth/oidc", "", false, true)
+
+	attempt, err := auth.ParseSignedToken(oidcLoginPurpose, cookie)
+	if err != nil {
+		h.invalidState(c, err.Error())
+		return
+	}
+	state := c.Query("state")
+	if attempt["provider"] != name || state == "" || attempt["state"] != state {
+		h.invalidState(c, "Invalid state")
+		return
+	}
+
+	if providerErr := c.Query("error"); providerErr != "" {
+		c.JSON(http.StatusOK, dtos.BaseResponse{
+			Code:    3,
+			Message: "Login failed",
+			Error: &dtos.ErrorResponse{
+				ErrorDetails: gin.H{
+					"error":             providerErr,
+					"error_description": c.Query("error_description"),
+				},
+			},
+		})
+		return
+	}
+
+	verifier, _ := attempt["verifier"].(string)
+	nonce, _ := attempt["nonce"].(string)
+	identity, err := provider.FetchIdentity(c, c.Query("code"), verifier, nonce)
+	if err != nil {
+		c.JSON(http.StatusOK, dtos.BaseResponse{
+			Code:    3,
+			Message: "Login failed",
+			Error: &dtos.ErrorResponse{
+				ErrorDetails: err.Error(),
+			},
+		})
+		return
+	}
+
+	user, err := h.oauthUsecase.Login(c, h.db, identity)
+	if err != nil {
+		if errors.Is(err, usecases.OAuthEmailNotVerified) || errors.Is(err, usecases.OAuthEmailVerificationPending) {
+			c.JSON(http.StatusOK, dtos.BaseResponse{
+				Code:    4,
+				Message: "Email not verified",
+				Error: &dtos.ErrorResponse{
+					ErrorDetails: err.Error(),
+				},
+			})
+			return
+		}
+		if errors.Is(err, usecases.OAuthUserNotActive) {
+			c.JSON(http.StatusOK, dtos.BaseResponse{
+				Code:    5,
+				Message: "User not active",
+				Error: &dtos.ErrorResponse{
+					ErrorDetails: err.Error(),
+				},
+			})
+			return
+		}
+		if errors.Is(err, usecases.OAuthEmailInUse) {
+			c.JSON(http.StatusOK, dtos.BaseResponse{
+				Code:    6,
+				Message: "Email already in use",
+				Error: &dtos.ErrorResponse{
+					ErrorDetails: err.Error(),
+				},
+			})
+			return
+		}
+		h.internalError(c, err)
+		return
+	}
+
+	if challengeTwoFactor(c, h.twoFactorUsecase, user, false) {
+		return
+	}
+
+	tokens, err := h.tokenUsecase.Issue(c, user, clientFromContext(c))
+	if err != nil {
+		h.internalError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dtos.BaseResponse{
+		Code: 0,
+		Data: dtos.LoginResponseDto{
+			User: entities.User{
+				ID:       user.ID,
+				Username: user.Username,
+				Email:    user.Email,
+			},
+			TokenPairDto: tokens,
+		},
+		Message: "Login success",
+	})
+}
+
+func (h *oidcHandler) invalidState(c *gin.Context, details string) {
+	c.JSON(http.StatusOK, dtos.BaseResponse{
+		Code:    2,
+		Message: BadRequest,
+		Error: &dtos.ErrorResponse{
+			ErrorDetails: details,
+		},
+	})
+}
+
+func (h *oidcHandler) internalError(c *gin.Context, err error) {
+	c.JSON(http.StatusInternalServerError, dtos.BaseResponse{
+		Message: InternalServerError,
+		Error: &dtos.ErrorResponse{
+			ErrorDetails: err.Error(),
+		},
+	})
+}
@@ -7,40 +7,54 @@ import (
 	"go-server/internal/pkg/domains/models/entities"
 	"go-server/internal/pkg/repositories"
 	"go-server/internal/pkg/usecases"
-	"go-server/pkg/shared/database"
+	"go-server/pkg/shared/moderation"
+	"go-server/pkg/shared/realtime"
 	"go-server/pkg/shared/utils"
 	"net/http"
 	"strconv"
 	"strings"
 
+	"github.com/cloudinary/cloudinary-go/v2"
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
 )
 
 type placeHandler struct {
-	placeUsecase interfaces.PlaceUsecase
-	db           *gorm.DB
-	logger       *logrus.Logger
+	placeUsecase   interfaces.PlaceUsecase
+	commentUsecase interfaces.CommentUsecase
+	db             *gorm.DB
+	logger         *logrus.Logger
 }
 
 func NewPlaceHandler(
 	logger *logrus.Logger,
 	db *gorm.DB,
+	cld *cloudinary.Cloudinary,
+	hub realtime.Publisher,
 ) *placeHandler {
 	placeRepo := repositories.NewPlaceRepository(db, logger)
 	categoryRepo := repositories.NewCategoryRepository(db, logger)
 	placeCategoryRepo := repositories.NewPlaceCategoryRepository(db, logger)
+	translationRepo := repositories.NewTranslationRepository(db, logger)
+	bannerRepo := repositories.NewBannerRepository(db, logger)
+	commentRepo := repositories.NewCommentRepository(db, logger)
+	commentModerationRepo := repositories.NewCommentModerationRepository(db, logger)
 
 	placeUsecase := usecases.NewPlaceUsecase(
 		placeRepo,
 		placeCategoryRepo,
 		categoryRepo,
+		translationRepo,
+		bannerRepo,
 		logger,
 	)
+	uploadUsecase := usecases.NewUploadUsecase(cld, logger)
+	commentUsecase := usecases.NewCommentUsecase(commentRepo, commentModerationRepo, uploadUsecase, moderation.NewFilterFromEnv(), newNotificationUsecase(db, hub, logger), logger)
 
 	return &placeHandler{
 		placeUsecase,
+		commentUsecase,
 		db,
 		logger,
 	}
@@ -95,6 +109,17 @@ func (h *placeHandler) CreatePlace(c *gin.Context) {
 			return
 		}
 
+		if errors.Is(err, usecases.TranslationLocaleNotSupported) {
+			c.JSON(http.StatusOK, dtos.BaseResponse{
+				Code:    4,
+				Message: err,
+				Error: &dtos.ErrorResponse{
+					ErrorDetails: errDetail,
+				},
+			})
+			return
+		}
+
 		c.JSON(http.StatusInternalServerError, dtos.BaseResponse{
 			Message: InternalServerError,
 			Error: &dtos.ErrorResponse{
@@ -104,11 +129,17 @@ func (h *placeHandler) CreatePlace(c *gin.Context) {
 		return
 	}
 
+	duplicates, err := h.placeUsecase.FindDuplicates(c, place)
+	if err != nil {
+		h.logger.WithContext(c).Warnf("Failed to find duplicates of place %d: %v", place.ID, err)
+	}
+
 	c.JSON(http.StatusOK, dtos.BaseResponse{
 		Code:    0,
 		Message: "Created success",
 		Data: gin.H{
-			"place": place,
+			"place":      place,
+			"duplicates": duplicates,
 		},
 	})
 }
@@ -184,6 +215,10 @@ func (h *placeHandler) ListPlacePaginate(c *gin.Context) {
 		return
 	}
 
+	for i := range places {
+		places[i].Translate(c.GetString("locale"))
+	}
+
 	c.JSON(http.StatusOK, dtos.BaseResponse{
 		Code:    0,
 		Message: "OK",
@@ -259,6 +294,17 @@ func (h *placeHandler) UpdatePlace(c *gin.Context) {
 			return
 		}
 
+		if errors.Is(err, usecases.TranslationLocaleNotSupported) {
+			c.JSON(http.StatusOK, dtos.BaseResponse{
+				Code:    4,
+				Message: err,
+				Error: &dtos.ErrorResponse{
+					ErrorDetails: errDetail,
+				},
+			})
+			return
+		}
+
 		c.JSON(http.StatusInternalServerError, dtos.BaseResponse{
 			Message: InternalServerError,
 			Error: &dtos.ErrorResponse{
@@ -268,11 +314,17 @@ func (h *placeHandler) UpdatePlace(c *gin.Context) {
 		return
 	}
 
+	duplicates, err := h.placeUsecase.FindDuplicates(c, place)
+	if err != nil {
+		h.logger.WithContext(c).Warnf("Failed to find duplicates of place %d: %v", place.ID, err)
+	}
+
 	c.JSON(http.StatusOK, dtos.BaseResponse{
 		Code:    0,
 		Message: "Updated success",
 		Data: gin.H{
-			"place": place,
+			"place":      place,
+			"duplicates": duplicates,
 		},
 	})
 }
@@ -315,6 +367,8 @@ func (h *placeHandler) DetailPlace(c *gin.Context) {
 		return
 	}
 
+	place.Translate(c.GetString("locale"))
+
 	c.JSON(http.StatusOK, dtos.BaseResponse{
 		Code:    0,
 		Message: "OK",
@@ -403,6 +457,10 @@ func (h *placeHandler) ListAllPlace(c *gin.Context) {
 		return
 	}
 
+	for i := range places {
+		places[i].Translate(c.GetString("locale"))
+	}
+
 	c.JSON(http.StatusOK, dtos.BaseResponse{
 		Code:    0,
 		Message: "OK",
@@ -412,6 +470,14 @@ func (h *placeHandler) ListAllPlace(c *gin.Context) {
 	})
 }
 
+// commentSortOrders maps the sort query of ListComment to its order clause
+var commentSortOrders = map[string]string{
+	"newest":       "created_at DESC, id DESC",
+	"most_helpful": "helpful_count DESC, created_at DESC",
+	"highest_rate": "rate DESC, created_at DESC",
+	"lowest_rate":  "rate ASC, created_at DESC",
+}
+
 func (h *placeHandler) ListComment(c *gin.Context) {
 	placeIDParam := c.Param("place_id")
 	placeID, err := strconv.Atoi(placeIDParam)
@@ -487,9 +553,40 @@ func (h *placeHandler) ListComment(c *gin.Context) {
 		}
 	}
 
-	var comments []entities.Comment
-	var count int64
-	err = h.db.Model(&entities.Comment{}).Where(conditions).Count(&count).Error
+	// sort takes precedence over the legacy order parameter
+	if sort, ok := c.GetQuery("sort"); ok {
+		sortCondition, ok := commentSortOrders[sort]
+		if !ok {
+			c.JSON(http.StatusOK, dtos.BaseResponse{
+				Code:    400,
+				Message: BadRequest,
+				Error: &dtos.ErrorResponse{
+					ErrorDetails: "sort must be one of newest, most_helpful, highest_rate, lowest_rate",
+				},
+			})
+			return
+		}
+		orderCondition = sortCondition
+	}
+
+	// the first replies of each review are embedded, the rest is paged from the comment
+	repliesPerPage := 3
+	if repliesPerPageQuery, ok := c.GetQuery("replies_per_page"); ok {
+		repliesPerPage, err = strconv.Atoi(repliesPerPageQuery)
+		if err != nil {
+			c.JSON(http.StatusOK, dtos.BaseResponse{
+				Code:    400,
+				Message: BadRequest,
+				Error: &dtos.ErrorResponse{
+					ErrorDetails: err,
+				},
+			})
+			return
+		}
+	}
+
+	userID, _ := userIDFromContext(c)
+	comments, count, err := h.commentUsecase.FindReviewsPaginate(c, userID, pageData, conditions, orderCondition, repliesPerPage)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, dtos.BaseResponse{
 			Code:    0,
@@ -501,7 +598,46 @@ func (h *placeHandler) ListComment(c *gin.Context) {
 		return
 	}
 
-	err = h.db.Scopes(database.Pagination(pageData)).Preload("User").Where(conditions).Order(orderCondition).Find(&comments).Error
+	c.JSON(http.StatusOK, dtos.BaseResponse{
+		Code:    0,
+		Message: "OK",
+		Data: gin.H{
+			"comments":     comments,
+			"page":         pageData["page"],
+			"per_page":     pageData["per_page"],
+			"total_record": count,
+			"total_page":   utils.CalcTotalPage(count, pageData["per_page"]),
+		},
+	})
+}
+
+func (h *placeHandler) ListVisitorPhotos(c *gin.Context) {
+	placeIDParam := c.Param("place_id")
+	placeID, err := strconv.Atoi(placeIDParam)
+	if err != nil {
+		c.JSON(http.StatusOK, dtos.BaseResponse{
+			Code:    400,
+			Message: BadRequest,
+			Error: &dtos.ErrorResponse{
+				ErrorDetails: err,
+			},
+		})
+		return
+	}
+
+	pageData, err := pageDataFromQuery(c)
+	if err != nil {
+		c.JSON(http.StatusOK, dtos.BaseResponse{
+			Code:    400,
+			Message: BadRequest,
+			Error: &dtos.ErrorResponse{
+				ErrorDetails: err,
+			},
+		})
+		return
+	}
+
+	photos, count, err := h.commentUsecase.FindPlacePhotosPaginate(c, placeID, pageData)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, dtos.BaseResponse{
 			Code:    0,
@@ -517,7 +653,7 @@ func (h *placeHandler) ListComment(c *gin.Context) {
 		Code:    0,
 		Message: "OK",
 		Data: gin.H{
-			"comments":     comments,
+			"photos":       photos,
 			"page":         pageData["page"],
 			"per_page":     pageData["per_page"],
 			"total_record": count,
@@ -526,6 +662,84 @@ func (h *placeHandler) ListComment(c *gin.Context) {
 	})
 }
 
+func (h *placeHandler) MergePlace(c *gin.Context) {
+	placeIDParam := c.Param("place_id")
+	placeID, err := strconv.Atoi(placeIDParam)
+	if err != nil {
+		c.JSON(http.StatusOK, dtos.BaseResponse{
+			Code:    400,
+			Message: BadRequest,
+			Error: &dtos.ErrorResponse{
+				ErrorDetails: err,
+			},
+		})
+		return
+	}
+
+	req := dtos.MergePlaceRequestDto{}
+	err = c.ShouldBindJSON(&req)
+	if err != nil {
+		c.JSON(http.StatusOK, dtos.BaseResponse{
+			Code:    400,
+			Message: BadRequest,
+			Error: &dtos.ErrorResponse{
+				ErrorDetails: err,
+			},
+		})
+		return
+	}
+
+	place, err := h.placeUsecase.Merge(c, h.db, placeID, req.DuplicateID)
+	if err != nil {
+		if errors.Is(err, usecases.MergePlaceIDNotFound) {
+			c.JSON(http.StatusOK, dtos.BaseResponse{
+				Code:    1,
+				Message: err.Error(),
+				Error: &dtos.ErrorResponse{
+					ErrorDetails: err,
+				},
+			})
+			return
+		}
+		if errors.Is(err, usecases.MergePlaceDuplicateIDNotFound) {
+			c.JSON(http.StatusOK, dtos.BaseResponse{
+				Code:    2,
+				Message: err.Error(),
+				Error: &dtos.ErrorResponse{
+					ErrorDetails: err,
+				},
+			})
+			return
+		}
+		if errors.Is(err, usecases.MergePlaceSameID) {
+			c.JSON(http.StatusOK, dtos.BaseResponse{
+				Code:    3,
+				Message: err.Error(),
+				Error: &dtos.ErrorResponse{
+					ErrorDetails: err,
+				},
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dtos.BaseResponse{
+			Code:    0,
+			Message: InternalServerError,
+			Error: &dtos.ErrorResponse{
+				ErrorDetails: err,
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dtos.BaseResponse{
+		Code:    0,
+		Message: "Merged success",
+		Data: gin.H{
+			"place": place,
+		},
+	})
+}
+
 type placeResponse struct {
 	entities.Place
 	Distance  float64 `json:"distance"`
@@ -592,10 +806,37 @@ func (h *placeHandler) ListSuggestPlace(c *gin.Context) {
 		}
 	}
 
+	// places are scanned from a raw query so their translations are not preloaded
+	translations := make(map[int][]entities.Translation)
+	if locale := c.GetString("locale"); locale != "" && len(places) > 0 {
+		var placeIDs []int
+		for _, place := range places {
+			placeIDs = append(placeIDs, place.ID)
+		}
+
+		var placeTranslations []entities.Translation
+		err := h.db.Where("entity_type = ? AND entity_id IN ? AND locale = ?", entities.TranslationEntityPlace, placeIDs, locale).Find(&placeTranslations).Error
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, dtos.BaseResponse{
+				Code:    0,
+				Message: InternalServerError,
+				Error: &dtos.ErrorResponse{
+					ErrorDetails: err,
+				},
+			})
+			return
+		}
+		for _, translation := range placeTranslations {
+			translations[translation.EntityID] = append(translations[translation.EntityID], translation)
+		}
+	}
+
 	var placeResponses []placeResponse
 	for _, place := range places {
 		place.ImagesResponse = strings.Split(place.Images, "|")
 		place.ImagesResponse = place.ImagesResponse[1 : len(place.ImagesResponse)-1]
+		place.Translations = translations[place.ID]
+		place.Translate(c.GetString("locale"))
 		placeResponses = append(placeResponses, placeResponse{
 			Place:     place.Place,
 			Distance:  place.Distance,
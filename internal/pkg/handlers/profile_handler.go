@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"go-server/internal/pkg/domains/interfaces"
+	"go-server/internal/pkg/domains/models/dtos"
+	"go-server/internal/pkg/repositories"
+	"go-server/internal/pkg/usecases"
+	"go-server/pkg/shared/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type profileHandler struct {
+	profileUsecase interfaces.ProfileUsecase
+	logger         *logrus.Logger
+	db             *gorm.DB
+}
+
+func NewProfileHandler(logger *logrus.Logger, db *gorm.DB) *profileHandler {
+	profileRepo := repositories.NewProfileRepository(db, logger)
+	profileUsecase := usecases.NewProfileUsecase(profileRepo, logger)
+
+	return &profileHandler{
+		profileUsecase,
+		logger,
+		db,
+	}
+}
+
+// DetailProfile is the public profile of a user
+func (h *profileHandler) DetailProfile(c *gin.Context) {
+	userID, ok := h.userIDParam(c)
+	if !ok {
+		return
+	}
+
+	viewerID, _ := userIDFromContext(c)
+	profile, err := h.profileUsecase.Detail(c, viewerID, userID)
+	if err != nil {
+		h.error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dtos.BaseResponse{
+		Code:    0,
+		Message: "OK",
+		Data: gin.H{
+			"profile": profile,
+		},
+	})
+}
+
+func (h *profileHandler) Follow(c *gin.Context) {
+	userID, ok := h.userIDParam(c)
+	if !ok {
+		return
+	}
+
+	followerID, _ := userIDFromContext(c)
+	err := h.profileUsecase.Follow(c, followerID, userID)
+	if err != nil {
+		h.error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dtos.BaseResponse{
+		Code:    0,
+		Message: "Followed success",
+	})
+}
+
+func (h *profileHandler) Unfollow(c *gin.Context) {
+	userID, ok := h.userIDParam(c)
+	if !ok {
+		return
+	}
+
+	followerID, _ := userIDFromContext(c)
+	err := h.profileUsecase.Unfollow(c, followerID, userID)
+	if err != nil {
+		h.error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dtos.BaseResponse{
+		Code:    0,
+		Message: "Unfollowed success",
+	})
+}
+
+func (h *profileHandler) ListFollowers(c *gin.Context) {
+	h.listUsers(c, h.profileUsecase.FindFollowersPaginate)
+}
+
+func (h *profileHandler) ListFollowing(c *gin.Context) {
+	h.listUsers(c, h.profileUsecase.FindFollowingPaginate)
+}
+
+func (h *profileHandler) listUsers(
+	c *gin.Context,
+	find func(context.Context, int, map[string]int) ([]dtos.PublicUserDto, int64, error),
+) {
+	userID, ok := h.userIDParam(c)
+	if !ok {
+		return
+	}
+
+	pageData, err := pageDataFromQuery(c)
+	if err != nil {
+		c.JSON(http.StatusOK, dtos.BaseResponse{
+			Code:    400,
+			Message: BadRequest,
+			Error: &dtos.ErrorResponse{
+				ErrorDetails: err.Error(),
+			},
+		})
+		return
+	}
+
+	users, count, err := find(c, userID, pageData)
+	if err != nil {
+		h.error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dtos.BaseResponse{
+		Code:    0,
+		Message: "OK",
+		Data: gin.H{
+			"users":        users,
+			"page":         pageData["page"],
+			"per_page":     pageData["per_page"],
+			"total_record": count,
+			"total_page":   utils.CalcTotalPage(count, pageData["per_page"]),
+		},
+	})
+}
+
+// Feed lists the new public trips and reviews of the users followed by the caller,
+// the next page is requested with the next_cursor of the previous one
+func (h *profileHandler) Feed(c *gin.Context) {
+	limit := 0
+	if limitQuery, ok := c.GetQuery("limit"); ok {
+		var err error
+		limit, err = strconv.Atoi(limitQuery)
+		if err != nil {
+			c.JSON(http.StatusOK, dtos.BaseResponse{
+				Code:    400,
+				Message: BadRequest,
+				Error: &dtos.ErrorResponse{
+					ErrorDetails: err.Error(),
+				},
+			})
+			return
+		}
+	}
+
+	userID, _ := userIDFromContext(c)
+	feed, err := h.profileUsecase.Feed(c, userID, c.Query("cursor"), limit)
+	if err != nil {
+		h.error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dtos.BaseResponse{
+		Code:    0,
+		Message: "OK",
+		Data:    feed,
+	})
+}
+
+func (h *profileHandler) userIDParam(c *gin.Context) (int, bool) {
+	userID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusOK, dtos.BaseResponse{
+			Code:    400,
+			Message: BadRequest,
+			Error: &dtos.ErrorResponse{
+				ErrorDetails: err.Error(),
+			},
+		})
+		return 0, false
+	}
+
+	return userID, true
+}
+
+func (h *profileHandler) error(c *gin.Context, err error) {
+	for code, knownErr := range []error{
+		usecases.ProfileUserNotFound,
+		usecases.FollowSelf,
+		usecases.FeedCursorInvalid,
+	} {
+		if errors.Is(err, knownErr) {
+			c.JSON(http.StatusOK, dtos.BaseResponse{
+				Code:    code + 1,
+				Message: knownErr.Error(),
+				Error: &dtos.ErrorResponse{
+					ErrorDetails: err.Error(),
+				},
+			})
+			return
+		}
+	}
+
+	c.JSON(http.StatusInternalServerError, dtos.BaseResponse{
+		Message: InternalServerError,
+		Error: &dtos.ErrorResponse{
+			ErrorDetails: err.Error(),
+		},
+	})
+}
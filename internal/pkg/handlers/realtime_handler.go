@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"go-server/internal/pkg/domains/models/entities"
+	"go-server/pkg/shared/realtime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type realtimeHandler struct {
+	hub      *realtime.Hub
+	upgrader websocket.Upgrader
+	logger   *logrus.Logger
+	db       *gorm.DB
+}
+
+func NewRealtimeHandler(logger *logrus.Logger, db *gorm.DB, hub *realtime.Hub) *realtimeHandler {
+	return &realtimeHandler{
+		hub: hub,
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  1024,
+			WriteBufferSize: 1024,
+			// the connection is authenticated by the access token, not by cookies, so any
+			// origin allowed by the CORS config may connect
+			CheckOrigin: func(r *http.Request) bool { return true },
+		},
+		logger: logger,
+		db:     db,
+	}
+}
+
+// Connect upgrades the request to the websocket of the caller, see realtime.Hub for the
+// messages exchanged. The connection lasts until the access token expires or its session ends
+func (h *realtimeHandler) Connect(c *gin.Context) {
+	userID, _ := userIDFromContext(c)
+
+	// the upgrader writes the error response itself
+	conn, err := h.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		h.logger.Debugf("websocket upgrade of user %d failed: %v", userID, err)
+		return
+	}
+
+	session := realtime.Session{
+		UserID:    userID,
+		TokenID:   c.GetString("token_id"),
+		ExpiresAt: c.GetTime("token_expires_at"),
+	}
+	h.hub.Serve(conn, session, func(topic string) bool {
+		return h.canSubscribe(c, userID, topic)
+	})
+}
+
+// canSubscribe allows the topic of the user and the trips the user owns, joined
+// or can see on a public profile
+func (h *realtimeHandler) canSubscribe(c *gin.Context, userID int, topic string) bool {
+	if topic == realtime.UserTopic(userID) {
+		return true
+	}
+
+	tripIDParam, ok := strings.CutPrefix(topic, "trip:")
+	if !ok {
+		return false
+	}
+	tripID, err := strconv.Atoi(tripIDParam)
+	if err != nil || realtime.TripTopic(tripID) != topic {
+		return false
+	}
+
+	var count int64
+	err = h.db.WithContext(c).Model(&entities.Trip{}).
+		Where("id = ?", tripID).
+		Where(h.db.
+			Where("owner = ? OR is_public = ?", userID, true).
+			Or("id IN (?)", h.db.Model(&entities.UserTrip{}).Select("trip_id").Where("user_id = ?", userID))).
+		Count(&count).Error
+	if err != nil {
+		h.logger.Errorf("authorize websocket topic %s of user %d failed: %v", topic, userID, err)
+		return false
+	}
+
+	return count > 0
+}
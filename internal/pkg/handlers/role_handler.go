@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"errors"
+	"go-server/internal/pkg/domains/interfaces"
+	"go-server/internal/pkg/domains/models/dtos"
+	"go-server/internal/pkg/repositories"
+	"go-server/internal/pkg/usecases"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type roleHandler struct {
+	roleUsecase interfaces.RoleUsecase
+	logger      *logrus.Logger
+	db          *gorm.DB
+}
+
+func NewRoleHandler(logger *logrus.Logger, db *gorm.DB) *roleHandler {
+	roleRepo := repositories.NewRoleRepository(db, logger)
+	userRepo := repositories.NewUserRepository(db, logger)
+	roleUsecase := usecases.NewRoleUsecase(roleRepo, userRepo, logger)
+
+	return &roleHandler{
+		roleUsecase,
+		logger,
+		db,
+	}
+}
+
+// ListRoles lists the roles with their permissions
+func (h *roleHandler) ListRoles(c *gin.Context) {
+	roles, err := h.roleUsecase.FindAll(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dtos.BaseResponse{
+			Message: InternalServerError,
+			Error: &dtos.ErrorResponse{
+				ErrorDetails: err.Error(),
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dtos.BaseResponse{
+		Code:    0,
+		Message: "OK",
+		Data: gin.H{
+			"roles": roles,
+		},
+	})
+}
+
+func (h *roleHandler) ListUserRoles(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusOK, dtos.BaseResponse{
+			Code:    400,
+			Message: BadRequest,
+			Error: &dtos.ErrorResponse{
+				ErrorDetails: err.Error(),
+			},
+		})
+		return
+	}
+
+	roles, err := h.roleUsecase.FindUserRoles(c, userID)
+	if err != nil {
+		h.error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dtos.BaseResponse{
+		Code:    0,
+		Message: "OK",
+		Data: gin.H{
+			"roles": roles,
+		},
+	})
+}
+
+// AssignUserRoles replaces the roles of a user
+func (h *roleHandler) AssignUserRoles(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusOK, dtos.BaseResponse{
+			Code:    400,
+			Message: BadRequest,
+			Error: &dtos.ErrorResponse{
+				ErrorDetails: err.Error(),
+			},
+		})
+		return
+	}
+
+	req := dtos.AssignRolesRequestDto{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusOK, dtos.BaseResponse{
+			Code:    400,
+			Message: BadRequest,
+			Error: &dtos.ErrorResponse{
+				ErrorDetails: err.Error(),
+			},
+		})
+		return
+	}
+
+	roles, err := h.roleUsecase.AssignUserRoles(c, h.db, userID, req)
+	if err != nil {
+		h.error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dtos.BaseResponse{
+		Code:    0,
+		Message: "Updated success",
+		Data: gin.H{
+			"roles": roles,
+		},
+	})
+}
+
+func (h *roleHandler) error(c *gin.Context, err error) {
+	for code, knownErr := range []error{
+		usecases.AssignRolesUserNotFound,
+		usecases.AssignRolesRoleNotFound,
+		usecases.AssignRolesLastSuperAdmin,
+	} {
+		if errors.Is(err, knownErr) {
+			c.JSON(http.StatusOK, dtos.BaseResponse{
+				Code:    code + 1,
+				Message: knownErr.Error(),
+				Error: &dtos.ErrorResponse{
+					ErrorDetails: err.Error(),
+				},
+			})
+			return
+		}
+	}
+
+	c.JSON(http.StatusInternalServerError, dtos.BaseResponse{
+		Message: InternalServerError,
+		Error: &dtos.ErrorResponse{
+			ErrorDetails: err.Error(),
+		},
+	})
+}
@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"errors"
+	"go-server/internal/pkg/domains/interfaces"
+	"go-server/internal/pkg/domains/models/dtos"
+	"go-server/internal/pkg/repositories"
+	"go-server/internal/pkg/usecases"
+	"go-server/pkg/shared/realtime"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type sessionHandler struct {
+	tokenUsecase interfaces.TokenUsecase
+	logger       *logrus.Logger
+}
+
+func NewSessionHandler(logger *logrus.Logger, db *gorm.DB, sessions realtime.SessionCloser) *sessionHandler {
+	userRepo := repositories.NewUserRepository(db, logger)
+	userTokenRepo := repositories.NewUserTokenRepository(db, logger)
+	tokenUsecase := usecases.NewTokenUsecase(userRepo, userTokenRepo, sessions, logger)
+
+	return &sessionHandler{
+		tokenUsecase,
+		logger,
+	}
+}
+
+// ListMySessions lists the sessions of the authenticated user
+func (h *sessionHandler) ListMySessions(c *gin.Context) {
+	userID, _ := userIDFromContext(c)
+	h.listSessions(c, userID, c.GetString("token_id"))
+}
+
+func (h *sessionHandler) RevokeMySession(c *gin.Context) {
+	userID, _ := userIDFromContext(c)
+	h.revokeSession(c, userID)
+}
+
+func (h *sessionHandler) RevokeMyOtherSessions(c *gin.Context) {
+	userID, _ := userIDFromContext(c)
+	h.revokeOtherSessions(c, userID, c.GetString("token_id"))
+}
+
+// ListUserSessions lists the sessions of a user for the CMS
+func (h *sessionHandler) ListUserSessions(c *gin.Context) {
+	userID, ok := h.userIDParam(c)
+	if !ok {
+		return
+	}
+	h.listSessions(c, userID, "")
+}
+
+func (h *sessionHandler) RevokeUserSession(c *gin.Context) {
+	userID, ok := h.userIDParam(c)
+	if !ok {
+		return
+	}
+	h.revokeSession(c, userID)
+}
+
+// RevokeUserSessions signs a user out of every device
+func (h *sessionHandler) RevokeUserSessions(c *gin.Context) {
+	userID, ok := h.userIDParam(c)
+	if !ok {
+		return
+	}
+	h.revokeOtherSessions(c, userID, "")
+}
+
+func (h *sessionHandler) listSessions(c *gin.Context, userID int, currentTokenID string) {
+	sessions, err := h.tokenUsecase.FindSessions(c, userID, currentTokenID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dtos.BaseResponse{
+			Message: InternalServerError,
+			Error: &dtos.ErrorResponse{
+				ErrorDetails: err.Error(),
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dtos.BaseResponse{
+		Code:    0,
+		Message: "OK",
+		Data: gin.H{
+			"sessions": sessions,
+		},
+	})
+}
+
+func (h *sessionHandler) revokeSession(c *gin.Context, userID int) {
+	sessionID, err := strconv.Atoi(c.Param("session_id"))
+	if err != nil {
+		c.JSON(http.StatusOK, dtos.BaseResponse{
+			Code:    400,
+			Message: BadRequest,
+			Error: &dtos.ErrorResponse{
+				ErrorDetails: err.Error(),
+			},
+		})
+		return
+	}
+
+	err = h.tokenUsecase.RevokeSession(c, userID, sessionID)
+	if err != nil {
+		if errors.Is(err, usecases.RevokeSessionNotFound) {
+			c.JSON(http.StatusOK, dtos.BaseResponse{
+				Code:    1,
+				Message: err.Error(),
+				Error: &dtos.ErrorResponse{
+					ErrorDetails: err.Error(),
+				},
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dtos.BaseResponse{
+			Message: InternalServerError,
+			Error: &dtos.ErrorResponse{
+				ErrorDetails: err.Error(),
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dtos.BaseResponse{
+		Code:    0,
+		Message: "Revoked success",
+	})
+}
+
+func (h *sessionHandler) revokeOtherSessions(c *gin.Context, userID int, currentTokenID string) {
+	err := h.tokenUsecase.RevokeOtherSessions(c, userID, currentTokenID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dtos.BaseResponse{
+			Message: InternalServerError,
+			Error: &dtos.ErrorResponse{
+				ErrorDetails: err.Error(),
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dtos.BaseResponse{
+		Code:    0,
+		Message: "Revoked success",
+	})
+}
+
+func (h *sessionHandler) userIDParam(c *gin.Context) (int, bool) {
+	userID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusOK, dtos.BaseResponse{
+			Code:    400,
+			Message: BadRequest,
+			Error: &dtos.ErrorResponse{
+				ErrorDetails: err.Error(),
+			},
+		})
+		return 0, false
+	}
+
+	return userID, true
+}
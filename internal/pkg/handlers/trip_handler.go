@@ -6,6 +6,7 @@ import (
 	"go-server/internal/pkg/domains/models/dtos"
 	"go-server/internal/pkg/domains/models/entities"
 	"go-server/pkg/shared/database"
+	"go-server/pkg/shared/realtime"
 	"go-server/pkg/shared/utils"
 	"net/http"
 	"strconv"
@@ -19,15 +20,18 @@ import (
 type tripHandler struct {
 	db     *gorm.DB
 	logger *logrus.Logger
+	hub    realtime.Broker
 }
 
 func NewTripHandler(
 	logger *logrus.Logger,
 	db *gorm.DB,
+	hub realtime.Broker,
 ) *tripHandler {
 	return &tripHandler{
 		db,
 		logger,
+		hub,
 	}
 }
 
@@ -119,6 +123,7 @@ func (h *tripHandler) CreateTrip(c *gin.Context) {
 			Owner:    req.Owner,
 			Name:     req.Name,
 			Users:    req.Users,
+			IsPublic: req.IsPublic,
 			FromDate: time.Unix(int64(req.FromDate), 0),
 			ToDate:   time.Unix(int64(req.ToDate), 0),
 		}
@@ -465,6 +470,9 @@ func (h *tripHandler) UpdateTrip(c *gin.Context) {
 	err = database.Transaction(c, h.db, func(tx *gorm.DB) error {
 		trip.Name = req.Name
 		trip.Users = req.Users
+		if req.IsPublic != nil {
+			trip.IsPublic = *req.IsPublic
+		}
 		trip.FromDate = time.Unix(int64(req.FromDate), 0)
 		trip.ToDate = time.Unix(int64(req.ToDate), 0)
 
@@ -500,6 +508,8 @@ func (h *tripHandler) UpdateTrip(c *gin.Context) {
 		return
 	}
 
+	h.publishTrip(c, trip.ID)
+
 	c.JSON(http.StatusOK, dtos.BaseResponse{
 		Code:    0,
 		Message: "Updated success",
@@ -576,8 +586,27 @@ func (h *tripHandler) DeleteTrip(c *gin.Context) {
 		return
 	}
 
+	h.hub.Publish(realtime.TripTopic(tripID), realtime.EventTripDeleted, gin.H{
+		"trip_id": tripID,
+	})
+	h.hub.DropTopic(realtime.TripTopic(tripID))
+
 	c.JSON(http.StatusOK, dtos.BaseResponse{
 		Code:    0,
 		Message: "Deleted success",
 	})
 }
+
+// publishTrip pushes the saved trip to the clients viewing it. The update may have made the
+// trip private or removed members, the clients no longer allowed to view it are dropped first
+func (h *tripHandler) publishTrip(c *gin.Context, tripID int) {
+	var trip entities.Trip
+	err := h.db.WithContext(c).Preload("Days").Where("id = ?", tripID).Take(&trip).Error
+	if err != nil {
+		h.logger.Errorf("load trip %d to publish failed: %v", tripID, err)
+		return
+	}
+
+	h.hub.Revalidate(realtime.TripTopic(tripID))
+	h.hub.Publish(realtime.TripTopic(tripID), realtime.EventTripUpdated, trip)
+}
@@ -0,0 +1,265 @@
+package handlers
+
+import (
+	"errors"
+	"go-server/internal/pkg/domains/interfaces"
+	"go-server/internal/pkg/domains/models/dtos"
+	"go-server/internal/pkg/domains/models/entities"
+	"go-server/internal/pkg/repositories"
+	"go-server/internal/pkg/services"
+	"go-server/internal/pkg/usecases"
+	"go-server/pkg/shared/realtime"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// twoFactorErrors are answered with their index + 1 as code
+var twoFactorErrors = []error{
+	usecases.TwoFactorUserNotFound,
+	usecases.TwoFactorAlreadyEnabled,
+	usecases.TwoFactorNotEnrolled,
+	usecases.TwoFactorNotEnabled,
+	usecases.TwoFactorCodeInvalid,
+	usecases.TwoFactorRequiredForAdmin,
+	usecases.TwoFactorChallengeInvalid,
+	usecases.TwoFactorTooManyAttempts,
+}
+
+type twoFactorHandler struct {
+	twoFactorUsecase interfaces.TwoFactorUsecase
+	tokenUsecase     interfaces.TokenUsecase
+	logger           *logrus.Logger
+	db               *gorm.DB
+}
+
+func NewTwoFactorHandler(logger *logrus.Logger, db *gorm.DB, sessions realtime.SessionCloser) *twoFactorHandler {
+	userRepo := repositories.NewUserRepository(db, logger)
+	userTokenRepo := repositories.NewUserTokenRepository(db, logger)
+	recoveryCodeRepo := repositories.NewUserRecoveryCodeRepository(db, logger)
+	loginAttemptUsecase := usecases.NewLoginAttemptUsecase(loginAttemptStore(db, logger), userRepo, services.NewMailService(), logger)
+	twoFactorUsecase := usecases.NewTwoFactorUsecase(userRepo, recoveryCodeRepo, loginAttemptUsecase, logger)
+	tokenUsecase := usecases.NewTokenUsecase(userRepo, userTokenRepo, sessions, logger)
+
+	return &twoFactorHandler{
+		twoFactorUsecase,
+		tokenUsecase,
+		logger,
+		db,
+	}
+}
+
+func (h *twoFactorHandler) Status(c *gin.Context) {
+	userID, _ := userIDFromContext(c)
+
+	status, err := h.twoFactorUsecase.Status(c, userID)
+	if err != nil {
+		h.error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dtos.BaseResponse{
+		Code:    0,
+		Message: "OK",
+		Data:    status,
+	})
+}
+
+func (h *twoFactorHandler) Enroll(c *gin.Context) {
+	userID, _ := userIDFromContext(c)
+
+	enrollment, err := h.twoFactorUsecase.Enroll(c, userID)
+	if err != nil {
+		h.error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dtos.BaseResponse{
+		Code:    0,
+		Message: "OK",
+		Data:    enrollment,
+	})
+}
+
+func (h *twoFactorHandler) Enable(c *gin.Context) {
+	userID, _ := userIDFromContext(c)
+
+	req := dtos.TwoFactorCodeRequestDto{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusOK, dtos.BaseResponse{
+			Code:    400,
+			Message: BadRequest,
+			Error: &dtos.ErrorResponse{
+				ErrorDetails: err.Error(),
+			},
+		})
+		return
+	}
+
+	codes, err := h.twoFactorUsecase.Enable(c, h.db, userID, req)
+	if err != nil {
+		h.error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dtos.BaseResponse{
+		Code:    0,
+		Message: "Two-factor authentication enabled",
+		Data: dtos.RecoveryCodesDto{
+			RecoveryCodes: codes,
+		},
+	})
+}
+
+func (h *twoFactorHandler) Disable(c *gin.Context) {
+	userID, _ := userIDFromContext(c)
+
+	req := dtos.TwoFactorProofDto{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusOK, dtos.BaseResponse{
+			Code:    400,
+			Message: BadRequest,
+			Error: &dtos.ErrorResponse{
+				ErrorDetails: err.Error(),
+			},
+		})
+		return
+	}
+
+	err := h.twoFactorUsecase.Disable(c, h.db, userID, req)
+	if err != nil {
+		h.error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dtos.BaseResponse{
+		Code:    0,
+		Message: "Two-factor authentication disabled",
+	})
+}
+
+func (h *twoFactorHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	userID, _ := userIDFromContext(c)
+
+	req := dtos.TwoFactorCodeRequestDto{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusOK, dtos.BaseResponse{
+			Code:    400,
+			Message: BadRequest,
+			Error: &dtos.ErrorResponse{
+				ErrorDetails: err.Error(),
+			},
+		})
+		return
+	}
+
+	codes, err := h.twoFactorUsecase.RegenerateRecoveryCodes(c, h.db, userID, req)
+	if err != nil {
+		h.error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dtos.BaseResponse{
+		Code:    0,
+		Message: "OK",
+		Data: dtos.RecoveryCodesDto{
+			RecoveryCodes: codes,
+		},
+	})
+}
+
+// Login is the second login step, it exchanges the challenge token of Login or AdminLogin
+// and a code for a session
+func (h *twoFactorHandler) Login(c *gin.Context) {
+	req := dtos.TwoFactorLoginRequestDto{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusOK, dtos.BaseResponse{
+			Code:    400,
+			Message: BadRequest,
+			Error: &dtos.ErrorResponse{
+				ErrorDetails: err.Error(),
+			},
+		})
+		return
+	}
+
+	user, err := h.twoFactorUsecase.VerifyChallenge(c, req)
+	if err != nil {
+		h.error(c, err)
+		return
+	}
+
+	tokens, err := h.tokenUsecase.IssueTwoFactor(c, user, clientFromContext(c))
+	if err != nil {
+		h.error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dtos.BaseResponse{
+		Code: 0,
+		Data: dtos.LoginResponseDto{
+			User: entities.User{
+				ID:       user.ID,
+				Username: user.Username,
+				Email:    user.Email,
+			},
+			TokenPairDto: tokens,
+		},
+		Message: "Login success",
+	})
+}
+
+// challengeTwoFactor answers the first login step with a challenge when the user has
+// two-factor authentication, false when the session can be issued right away
+func challengeTwoFactor(
+	c *gin.Context,
+	twoFactorUsecase interfaces.TwoFactorUsecase,
+	user entities.User,
+	admin bool,
+) bool {
+	if !user.TwoFactorEnabled {
+		return false
+	}
+
+	challenge, err := twoFactorUsecase.Challenge(c, user, admin)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dtos.BaseResponse{
+			Message: InternalServerError,
+			Error: &dtos.ErrorResponse{
+				ErrorDetails: err.Error(),
+			},
+		})
+		return true
+	}
+
+	c.JSON(http.StatusOK, dtos.BaseResponse{
+		Code:    0,
+		Message: "Two-factor authentication required",
+		Data:    challenge,
+	})
+	return true
+}
+
+func (h *twoFactorHandler) error(c *gin.Context, err error) {
+	for code, knownErr := range twoFactorErrors {
+		if errors.Is(err, knownErr) {
+			c.JSON(http.StatusOK, dtos.BaseResponse{
+				Code:    code + 1,
+				Message: knownErr.Error(),
+				Error: &dtos.ErrorResponse{
+					ErrorDetails: err.Error(),
+				},
+			})
+			return
+		}
+	}
+
+	c.JSON(http.StatusInternalServerError, dtos.BaseResponse{
+		Message: InternalServerError,
+		Error: &dtos.ErrorResponse{
+			ErrorDetails: err.Error(),
+		},
+	})
+}
@@ -8,45 +8,71 @@ import (
 	"go-server/internal/pkg/repositories"
 	"go-server/internal/pkg/services"
 	"go-server/internal/pkg/usecases"
-	"go-server/pkg/shared/auth"
 	"go-server/pkg/shared/database"
+	"go-server/pkg/shared/realtime"
 	"go-server/pkg/shared/utils"
-	"io"
-	"log"
 	"net/http"
+	"os"
 	"strconv"
-	"time"
+	"sync"
 
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
-	"golang.org/x/oauth2"
 	"gorm.io/gorm"
 )
 
 type userHandler struct {
-	userUsecase  interfaces.UserUsecase
-	logger       *logrus.Logger
-	googleConfig *oauth2.Config
-	mailService  services.MailServiceInterface
-	db           *gorm.DB
+	userUsecase         interfaces.UserUsecase
+	tokenUsecase        interfaces.TokenUsecase
+	oauthUsecase        interfaces.OAuthUsecase
+	twoFactorUsecase    interfaces.TwoFactorUsecase
+	loginAttemptUsecase interfaces.LoginAttemptUsecase
+	logger              *logrus.Logger
+	googleProvider      services.OAuthProviderInterface
+	db                  *gorm.DB
 }
 
-func NewUserHandler(logger *logrus.Logger, db *gorm.DB) *userHandler {
+func NewUserHandler(logger *logrus.Logger, db *gorm.DB, sessions realtime.SessionCloser) *userHandler {
 	userRepo := repositories.NewUserRepository(db, logger)
-	userUsecase := usecases.NewUserUsecase(userRepo, logger)
+	userTokenRepo := repositories.NewUserTokenRepository(db, logger)
+	passwordResetRepo := repositories.NewPasswordResetTokenRepository(db, logger)
+	roleRepo := repositories.NewRoleRepository(db, logger)
 	mailService := services.NewMailService()
-
-	googleConfig := utils.SetupConfig()
+	userUsecase := usecases.NewUserUsecase(userRepo, userTokenRepo, passwordResetRepo, roleRepo, mailService, sessions, logger)
+	tokenUsecase := usecases.NewTokenUsecase(userRepo, userTokenRepo, sessions, logger)
+	userIdentityRepo := repositories.NewUserIdentityRepository(db, logger)
+	oauthUsecase := usecases.NewOAuthUsecase(userRepo, userIdentityRepo, userTokenRepo, mailService, sessions, logger)
+	recoveryCodeRepo := repositories.NewUserRecoveryCodeRepository(db, logger)
+	loginAttemptUsecase := usecases.NewLoginAttemptUsecase(loginAttemptStore(db, logger), userRepo, mailService, logger)
+	twoFactorUsecase := usecases.NewTwoFactorUsecase(userRepo, recoveryCodeRepo, loginAttemptUsecase, logger)
+
+	googleProvider := services.NewGoogleOAuthService(utils.SetupConfig(), utils.GoogleUserInfoURL())
 	return &userHandler{
 		userUsecase,
+		tokenUsecase,
+		oauthUsecase,
+		twoFactorUsecase,
+		loginAttemptUsecase,
 		logger,
-		googleConfig,
-		mailService,
+		googleProvider,
 		db,
 	}
 }
 
+// memoryLoginAttempts is shared by the handlers, the second login step locks the accounts
+// the first one checks
+var memoryLoginAttempts = sync.OnceValue(repositories.NewMemoryLoginAttemptStore)
+
+// loginAttemptStore shares the failed login counters through the database unless
+// LOGIN_ATTEMPT_STORE=memory, which only suits a single instance
+func loginAttemptStore(db *gorm.DB, logger *logrus.Logger) interfaces.LoginAttemptStore {
+	if os.Getenv("LOGIN_ATTEMPT_STORE") == "memory" {
+		return memoryLoginAttempts()
+	}
+
+	return repositories.NewLoginAttemptRepository(db, logger)
+}
+
 func (h *userHandler) Register(c *gin.Context) {
 	req := dtos.RegisterRequestDto{}
 	err := c.ShouldBindJSON(&req)
@@ -100,18 +126,97 @@ func (h *userHandler) Register(c *gin.Context) {
 		return
 	}
 
-	tokenID := uuid.New().String()
-	accessToken, err := auth.GenerateHS256JWT(map[string]interface{}{
-		"user_id":  user.ID,
-		"sub":      user.Username,
-		"email":    user.Email,
-		"is_admin": user.IsAdmin,
-		"token_id": tokenID,
+	// the account is created anyway, the user can ask for a new link
+	err = h.userUsecase.SendVerificationEmail(c, user)
+	if err != nil {
+		h.logger.Errorf("send verification email to user %d failed: %v", user.ID, err)
+	}
+
+	res := dtos.RegisterResponseDto{
+		User: entities.User{
+			ID:       user.ID,
+			Username: user.Username,
+			Email:    user.Email,
+		},
+		EmailVerificationRequired: usecases.EmailVerificationRequired(),
+	}
+	if !res.EmailVerificationRequired {
+		tokens, err := h.tokenUsecase.Issue(c, user, clientFromContext(c))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, dtos.BaseResponse{
+				Code:    0,
+				Message: "Internal Server Error",
+				Error: &dtos.ErrorResponse{
+					ErrorDetails: err.Error(),
+				},
+			})
+			return
+		}
+		res.TokenPairDto = &tokens
+	}
+
+	c.JSON(http.StatusOK, dtos.BaseResponse{
+		Code:    0,
+		Message: "OK",
+		Data:    res,
 	})
+}
+
+func (h *userHandler) VerifyEmail(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		c.JSON(http.StatusOK, dtos.BaseResponse{
+			Code:    400,
+			Message: BadRequest,
+			Error: &dtos.ErrorResponse{
+				ErrorDetails: "token is required",
+			},
+		})
+		return
+	}
+
+	user, err := h.userUsecase.VerifyEmail(c, token)
+	if err != nil {
+		if errors.Is(err, usecases.VerifyEmailTokenInvalid) {
+			c.JSON(http.StatusOK, dtos.BaseResponse{
+				Code:    1,
+				Message: "Verification link invalid or expired",
+				Error: &dtos.ErrorResponse{
+					ErrorDetails: err.Error(),
+				},
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dtos.BaseResponse{
+			Message: InternalServerError,
+			Error: &dtos.ErrorResponse{
+				ErrorDetails: err.Error(),
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dtos.BaseResponse{
+		Code:    0,
+		Message: "Email verified",
+		Data: gin.H{
+			"user": entities.User{
+				ID:            user.ID,
+				Username:      user.Username,
+				Email:         user.Email,
+				EmailVerified: user.EmailVerified,
+			},
+		},
+	})
+}
+
+func (h *userHandler) ResendVerification(c *gin.Context) {
+	req := dtos.ResendVerificationRequestDto{}
+	err := c.ShouldBindJSON(&req)
 	if err != nil {
 		c.JSON(http.StatusOK, dtos.BaseResponse{
-			Code:    0,
-			Message: "Internal Server Error",
+			Code:    400,
+			Message: BadRequest,
 			Error: &dtos.ErrorResponse{
 				ErrorDetails: err.Error(),
 			},
@@ -119,14 +224,33 @@ func (h *userHandler) Register(c *gin.Context) {
 		return
 	}
 
-	err = h.db.Create(&entities.UserToken{
-		UserID:  user.ID,
-		TokenID: tokenID,
-	}).Error
+	retryAfter, err := h.userUsecase.ResendVerificationEmail(c, req)
 	if err != nil {
+		if errors.Is(err, usecases.ResendVerificationAlreadyVerified) {
+			c.JSON(http.StatusOK, dtos.BaseResponse{
+				Code:    1,
+				Message: "Email already verified",
+				Error: &dtos.ErrorResponse{
+					ErrorDetails: err.Error(),
+				},
+			})
+			return
+		}
+		if errors.Is(err, usecases.ResendVerificationTooSoon) {
+			c.JSON(http.StatusOK, dtos.BaseResponse{
+				Code:    2,
+				Message: "Too many requests",
+				Error: &dtos.ErrorResponse{
+					ErrorDetails: gin.H{
+						"message":     err.Error(),
+						"retry_after": int64(retryAfter.Seconds()) + 1,
+					},
+				},
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, dtos.BaseResponse{
-			Code:    0,
-			Message: "Internal Server Error",
+			Message: InternalServerError,
 			Error: &dtos.ErrorResponse{
 				ErrorDetails: err.Error(),
 			},
@@ -137,14 +261,6 @@ func (h *userHandler) Register(c *gin.Context) {
 	c.JSON(http.StatusOK, dtos.BaseResponse{
 		Code:    0,
 		Message: "OK",
-		Data: dtos.RegisterResponseDto{
-			User: entities.User{
-				ID:       user.ID,
-				Username: user.Username,
-				Email:    user.Email,
-			},
-			AccessToken: accessToken,
-		},
 	})
 }
 
@@ -158,29 +274,31 @@ func (h *userHandler) GoogleLogin(c *gin.Context) {
 		on your redirect callback.
 	*/
 
-	h.googleConfig.AuthCodeURL(oauthState)
-	c.Redirect(http.StatusTemporaryRedirect, h.googleConfig.AuthCodeURL(oauthState))
+	c.Redirect(http.StatusTemporaryRedirect, h.googleProvider.AuthCodeURL(oauthState))
 }
 
 func (h *userHandler) GoogleCallback(c *gin.Context) {
 	oauthstate, err := c.Cookie("oauthstate")
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, dtos.BaseResponse{
-			Code: 1,
+		c.JSON(http.StatusOK, dtos.BaseResponse{
+			Code:    1,
+			Message: BadRequest,
 			Error: &dtos.ErrorResponse{
 				ErrorDetails: err.Error(),
 			},
 		})
 		return
 	}
+	// the state is single use
+	c.SetCookie("oauthstate", "", -1, "", "", false, true)
 
 	state := c.Request.FormValue("state")
 	code := c.Request.FormValue("code")
 
-	c.Request.Header.Add("content-type", "application/json")
-	if state != oauthstate {
-		c.JSON(http.StatusTemporaryRedirect, dtos.BaseResponse{
-			Code: 2,
+	if state == "" || state != oauthstate {
+		c.JSON(http.StatusOK, dtos.BaseResponse{
+			Code:    2,
+			Message: BadRequest,
 			Error: &dtos.ErrorResponse{
 				ErrorDetails: "Invalid state",
 			},
@@ -188,10 +306,11 @@ func (h *userHandler) GoogleCallback(c *gin.Context) {
 		return
 	}
 
-	token, err := h.googleConfig.Exchange(c, code)
+	identity, err := h.googleProvider.FetchIdentity(c, code)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, dtos.BaseResponse{
-			Code: 3,
+		c.JSON(http.StatusOK, dtos.BaseResponse{
+			Code:    3,
+			Message: "Google login failed",
 			Error: &dtos.ErrorResponse{
 				ErrorDetails: err.Error(),
 			},
@@ -199,10 +318,40 @@ func (h *userHandler) GoogleCallback(c *gin.Context) {
 		return
 	}
 
-	response, err := http.Get(utils.OauthGoogleUrlAPI + token.AccessToken)
+	user, err := h.oauthUsecase.Login(c, h.db, identity)
 	if err != nil {
+		if errors.Is(err, usecases.OAuthEmailNotVerified) || errors.Is(err, usecases.OAuthEmailVerificationPending) {
+			c.JSON(http.StatusOK, dtos.BaseResponse{
+				Code:    4,
+				Message: "Email not verified",
+				Error: &dtos.ErrorResponse{
+					ErrorDetails: err.Error(),
+				},
+			})
+			return
+		}
+		if errors.Is(err, usecases.OAuthUserNotActive) {
+			c.JSON(http.StatusOK, dtos.BaseResponse{
+				Code:    5,
+				Message: "User not active",
+				Error: &dtos.ErrorResponse{
+					ErrorDetails: err.Error(),
+				},
+			})
+			return
+		}
+		if errors.Is(err, usecases.OAuthEmailInUse) {
+			c.JSON(http.StatusOK, dtos.BaseResponse{
+				Code:    6,
+				Message: "Email already in use",
+				Error: &dtos.ErrorResponse{
+					ErrorDetails: err.Error(),
+				},
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, dtos.BaseResponse{
-			Code: 4,
+			Message: InternalServerError,
 			Error: &dtos.ErrorResponse{
 				ErrorDetails: err.Error(),
 			},
@@ -210,11 +359,14 @@ func (h *userHandler) GoogleCallback(c *gin.Context) {
 		return
 	}
 
-	defer response.Body.Close()
-	contents, err := io.ReadAll(response.Body)
+	if challengeTwoFactor(c, h.twoFactorUsecase, user, false) {
+		return
+	}
+
+	tokens, err := h.tokenUsecase.Issue(c, user, clientFromContext(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, dtos.BaseResponse{
-			Code: 5,
+			Message: InternalServerError,
 			Error: &dtos.ErrorResponse{
 				ErrorDetails: err.Error(),
 			},
@@ -222,8 +374,18 @@ func (h *userHandler) GoogleCallback(c *gin.Context) {
 		return
 	}
 
-	// send back response to browser
-	log.Println(string(contents))
+	c.JSON(http.StatusOK, dtos.BaseResponse{
+		Code: 0,
+		Data: dtos.LoginResponseDto{
+			User: entities.User{
+				ID:       user.ID,
+				Username: user.Username,
+				Email:    user.Email,
+			},
+			TokenPairDto: tokens,
+		},
+		Message: "Login success",
+	})
 }
 
 func (h *userHandler) Login(c *gin.Context) {
@@ -239,8 +401,11 @@ func (h *userHandler) Login(c *gin.Context) {
 		return
 	}
 
-	user, accessToken, tokenID, err := h.userUsecase.Login(c, req)
+	user, err := h.login(c, req)
 	if err != nil {
+		if loginBlocked(c, 5, err) {
+			return
+		}
 		if errors.Is(err, usecases.EmailNotFound) {
 			c.JSON(http.StatusOK, dtos.BaseResponse{
 				Code:    1,
@@ -261,6 +426,16 @@ func (h *userHandler) Login(c *gin.Context) {
 			})
 			return
 		}
+		if errors.Is(err, usecases.LoginEmailNotVerified) {
+			c.JSON(http.StatusOK, dtos.BaseResponse{
+				Code:    4,
+				Message: "Email not verified",
+				Error: &dtos.ErrorResponse{
+					ErrorDetails: err.Error(),
+				},
+			})
+			return
+		}
 		if !user.Active {
 			c.JSON(http.StatusOK, dtos.BaseResponse{
 				Code:    3,
@@ -280,11 +455,11 @@ func (h *userHandler) Login(c *gin.Context) {
 		return
 	}
 
-	userToken := entities.UserToken{
-		UserID:  user.ID,
-		TokenID: tokenID,
+	if challengeTwoFactor(c, h.twoFactorUsecase, user, false) {
+		return
 	}
-	err = h.db.Create(&userToken).Error
+
+	tokens, err := h.tokenUsecase.Issue(c, user, clientFromContext(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, dtos.BaseResponse{
 			Code:    0,
@@ -304,12 +479,60 @@ func (h *userHandler) Login(c *gin.Context) {
 				Username: user.Username,
 				Email:    user.Email,
 			},
-			AccessToken: accessToken,
+			TokenPairDto: tokens,
 		},
 		Message: "Login success",
 	})
 }
 
+// login checks the credentials behind the brute-force protection, wrong emails and
+// passwords count as failures of the account and of the client address
+func (h *userHandler) login(c *gin.Context, req dtos.LoginRequestDto) (entities.User, error) {
+	err := h.loginAttemptUsecase.Check(c, req.Email, c.ClientIP())
+	if err != nil {
+		return entities.User{}, err
+	}
+
+	user, err := h.userUsecase.Login(c, req)
+	if errors.Is(err, usecases.EmailNotFound) || errors.Is(err, usecases.WrongPassword) {
+		if failureErr := h.loginAttemptUsecase.RegisterFailure(c, req.Email, c.ClientIP()); failureErr != nil {
+			h.logger.Errorf("register login failure failed: %v", failureErr)
+		}
+		return user, err
+	}
+	if err != nil {
+		return user, err
+	}
+
+	if successErr := h.loginAttemptUsecase.RegisterSuccess(c, req.Email); successErr != nil {
+		h.logger.Errorf("register login success failed: %v", successErr)
+	}
+
+	return user, nil
+}
+
+// loginBlocked answers with code when err rejected the attempt before the credentials were checked
+func loginBlocked(c *gin.Context, code int, err error) bool {
+	var blocked *usecases.LoginBlockedError
+	if !errors.As(err, &blocked) {
+		return false
+	}
+
+	retryAfter := int64(blocked.RetryAfter.Seconds()) + 1
+	c.Header("Retry-After", strconv.FormatInt(retryAfter, 10))
+	c.JSON(http.StatusOK, dtos.BaseResponse{
+		Code:    code,
+		Message: "Too many requests",
+		Error: &dtos.ErrorResponse{
+			ErrorDetails: gin.H{
+				"message":     err.Error(),
+				"retry_after": retryAfter,
+			},
+		},
+	})
+	return true
+}
+
 func (h *userHandler) AdminLogin(c *gin.Context) {
 	req := dtos.LoginRequestDto{}
 	err := c.ShouldBindJSON(&req)
@@ -323,8 +546,11 @@ func (h *userHandler) AdminLogin(c *gin.Context) {
 		return
 	}
 
-	user, accessToken, tokenID, err := h.userUsecase.Login(c, req)
+	user, err := h.login(c, req)
 	if err != nil {
+		if loginBlocked(c, 4, err) {
+			return
+		}
 		if errors.Is(err, usecases.EmailNotFound) {
 			c.JSON(http.StatusOK, dtos.BaseResponse{
 				Code:    1,
@@ -364,10 +590,11 @@ func (h *userHandler) AdminLogin(c *gin.Context) {
 		return
 	}
 
-	err = h.db.Create(&entities.UserToken{
-		UserID:  user.ID,
-		TokenID: tokenID,
-	}).Error
+	if challengeTwoFactor(c, h.twoFactorUsecase, user, true) {
+		return
+	}
+
+	tokens, err := h.tokenUsecase.Issue(c, user, clientFromContext(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, dtos.BaseResponse{
 			Code:    0,
@@ -387,7 +614,7 @@ func (h *userHandler) AdminLogin(c *gin.Context) {
 				Username: user.Username,
 				Email:    user.Email,
 			},
-			AccessToken: accessToken,
+			TokenPairDto: tokens,
 		},
 		Message: "Login success",
 	})
@@ -509,18 +736,12 @@ func (h *userHandler) ForgotPassword(c *gin.Context) {
 		return
 	}
 
-	user, err := h.userUsecase.TakeByConditions(c, map[string]interface{}{
-		"email": req.Email,
-	})
+	err = h.loginAttemptUsecase.RegisterPasswordReset(c, req.Email, c.ClientIP())
+	if err == nil {
+		err = h.userUsecase.ForgotPassword(c, h.db, req)
+	}
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			c.JSON(http.StatusOK, dtos.BaseResponse{
-				Code:    1,
-				Message: "Email not found",
-				Error: &dtos.ErrorResponse{
-					ErrorDetails: err.Error(),
-				},
-			})
+		if loginBlocked(c, 1, err) {
 			return
 		}
 
@@ -533,32 +754,20 @@ func (h *userHandler) ForgotPassword(c *gin.Context) {
 		return
 	}
 
-	newPassword, err := utils.GeneratePassword(10)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, dtos.BaseResponse{
-			Message: InternalServerError,
-			Error: &dtos.ErrorResponse{
-				ErrorDetails: err.Error(),
-			},
-		})
-		return
-	}
+	c.JSON(http.StatusOK, dtos.BaseResponse{
+		Code:    0,
+		Message: "OK",
+	})
+}
 
-	hashPassword, err := utils.HashPassword(newPassword)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, dtos.BaseResponse{
-			Message: InternalServerError,
-			Error: &dtos.ErrorResponse{
-				ErrorDetails: err.Error(),
-			},
-		})
-		return
-	}
+func (h *userHandler) ResetPassword(c *gin.Context) {
+	req := dtos.ResetPasswordRequestDto{}
 
-	err = h.db.Model(&user).Where("id = ?", user.ID).UpdateColumn("password", hashPassword).Error
+	err := c.ShouldBindJSON(&req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, dtos.BaseResponse{
-			Message: InternalServerError,
+		c.JSON(http.StatusOK, dtos.BaseResponse{
+			Code:    400,
+			Message: BadRequest,
 			Error: &dtos.ErrorResponse{
 				ErrorDetails: err.Error(),
 			},
@@ -566,15 +775,24 @@ func (h *userHandler) ForgotPassword(c *gin.Context) {
 		return
 	}
 
-	data := map[string]interface{}{
-		"to":       req.ReceiveEmail,
-		"username": user.Username,
-		"password": newPassword,
-		"year":     time.Now().Year(),
-	}
-
-	err = h.mailService.SendOneTimePasswordMail("new_password_template.html", data)
+	err = h.userUsecase.ResetPassword(c, h.db, req)
 	if err != nil {
+		for code, knownErr := range []error{
+			usecases.ResetPasswordTokenInvalid,
+			usecases.ResetPasswordTokenExpired,
+			usecases.ResetPasswordNotMatch,
+		} {
+			if errors.Is(err, knownErr) {
+				c.JSON(http.StatusOK, dtos.BaseResponse{
+					Code:    code + 1,
+					Message: knownErr.Error(),
+					Error: &dtos.ErrorResponse{
+						ErrorDetails: err.Error(),
+					},
+				})
+				return
+			}
+		}
 		c.JSON(http.StatusInternalServerError, dtos.BaseResponse{
 			Message: InternalServerError,
 			Error: &dtos.ErrorResponse{
@@ -584,16 +802,9 @@ func (h *userHandler) ForgotPassword(c *gin.Context) {
 		return
 	}
 
-	go func(userID int) {
-		err = h.db.Where("user_id = ?", userID).Delete(&entities.UserToken{}).Error
-		if err != nil {
-			return
-		}
-	}(user.ID)
-
 	c.JSON(http.StatusOK, dtos.BaseResponse{
 		Code:    0,
-		Message: "OK",
+		Message: "Reset password success",
 	})
 }
 
@@ -686,12 +897,11 @@ func (h *userHandler) ChangePassword(c *gin.Context) {
 		return
 	}
 
-	go func(tokenID string) {
-		err = h.db.Where("token_id = ?", tokenID).Delete(&entities.UserToken{}).Error
-		if err != nil {
-			return
-		}
-	}(tokenID)
+	// the password changed, the session ends with its websockets
+	err = h.tokenUsecase.Revoke(c, tokenID)
+	if err != nil {
+		h.logger.Errorf("revoke session of user %d after password change failed: %v", user.ID, err)
+	}
 
 	c.JSON(http.StatusOK, dtos.BaseResponse{
 		Code:    0,
@@ -700,8 +910,8 @@ func (h *userHandler) ChangePassword(c *gin.Context) {
 }
 
 func (h *userHandler) Logout(c *gin.Context) {
-	userID := c.MustGet("token_id")
-	if userID == nil {
+	tokenID := c.GetString("token_id")
+	if tokenID == "" {
 		c.JSON(http.StatusUnauthorized, dtos.BaseResponse{
 			Code:    1,
 			Message: "Unauthorized",
@@ -709,12 +919,16 @@ func (h *userHandler) Logout(c *gin.Context) {
 		return
 	}
 
-	go func() {
-		err := h.db.Where("token_id = ?", userID).Delete(&entities.UserToken{}).Error
-		if err != nil {
-			return
-		}
-	}()
+	err := h.tokenUsecase.Revoke(c, tokenID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dtos.BaseResponse{
+			Message: InternalServerError,
+			Error: &dtos.ErrorResponse{
+				ErrorDetails: err.Error(),
+			},
+		})
+		return
+	}
 
 	c.JSON(http.StatusOK, dtos.BaseResponse{
 		Code:    0,
@@ -722,6 +936,55 @@ func (h *userHandler) Logout(c *gin.Context) {
 	})
 }
 
+func (h *userHandler) RefreshToken(c *gin.Context) {
+	req := dtos.RefreshTokenRequestDto{}
+	err := c.ShouldBindJSON(&req)
+	if err != nil {
+		c.JSON(http.StatusOK, dtos.BaseResponse{
+			Code:    400,
+			Message: BadRequest,
+			Error: &dtos.ErrorResponse{
+				ErrorDetails: err.Error(),
+			},
+		})
+		return
+	}
+
+	tokens, err := h.tokenUsecase.Refresh(c, h.db, req, clientFromContext(c))
+	if err != nil {
+		for code, knownErr := range []error{
+			usecases.RefreshTokenInvalid,
+			usecases.RefreshTokenExpired,
+			usecases.RefreshTokenReused,
+			usecases.RefreshUserNotActive,
+		} {
+			if errors.Is(err, knownErr) {
+				c.JSON(http.StatusUnauthorized, dtos.BaseResponse{
+					Code:    code + 1,
+					Message: "Unauthorized",
+					Error: &dtos.ErrorResponse{
+						ErrorDetails: err.Error(),
+					},
+				})
+				return
+			}
+		}
+		c.JSON(http.StatusInternalServerError, dtos.BaseResponse{
+			Message: InternalServerError,
+			Error: &dtos.ErrorResponse{
+				ErrorDetails: err.Error(),
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dtos.BaseResponse{
+		Code:    0,
+		Message: "OK",
+		Data:    tokens,
+	})
+}
+
 func (h *userHandler) UpdateStatus(c *gin.Context) {
 	req := dtos.UpdateStatusRequestDto{}
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -763,8 +1026,18 @@ func (h *userHandler) UpdateStatus(c *gin.Context) {
 		isActive = false
 	}
 
-	err = h.db.Model(&user).Where("id = ?", user.ID).UpdateColumn("active", isActive).Error
+	err = h.userUsecase.UpdateStatus(c, h.db, user, isActive)
 	if err != nil {
+		if errors.Is(err, usecases.UpdateStatusLastSuperAdmin) {
+			c.JSON(http.StatusOK, dtos.BaseResponse{
+				Code:    2,
+				Message: "Last super admin",
+				Error: &dtos.ErrorResponse{
+					ErrorDetails: err.Error(),
+				},
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, dtos.BaseResponse{
 			Message: InternalServerError,
 			Error: &dtos.ErrorResponse{
@@ -778,12 +1051,16 @@ func (h *userHandler) UpdateStatus(c *gin.Context) {
 	if req.Status == 1 {
 		message = "Activate account success"
 	} else {
-		go func(userID int) {
-			err = h.db.Where("id = ?", userID).Delete(&entities.UserToken{}).Error
-			if err != nil {
-				return
-			}
-		}(user.ID)
+		err = h.tokenUsecase.RevokeOtherSessions(c, user.ID, "")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, dtos.BaseResponse{
+				Message: InternalServerError,
+				Error: &dtos.ErrorResponse{
+					ErrorDetails: err.Error(),
+				},
+			})
+			return
+		}
 		message = "Deactivate account success"
 	}
 	c.JSON(http.StatusOK, dtos.BaseResponse{
@@ -792,6 +1069,47 @@ func (h *userHandler) UpdateStatus(c *gin.Context) {
 	})
 }
 
+// UnlockUser lifts the login lockout of the user before it expires
+func (h *userHandler) UnlockUser(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusOK, dtos.BaseResponse{
+			Code:    400,
+			Message: BadRequest,
+			Error: &dtos.ErrorResponse{
+				ErrorDetails: err.Error(),
+			},
+		})
+		return
+	}
+
+	err = h.loginAttemptUsecase.Unlock(c, userID)
+	if err != nil {
+		if errors.Is(err, usecases.UnlockUserNotFound) {
+			c.JSON(http.StatusOK, dtos.BaseResponse{
+				Code:    1,
+				Message: "User not found",
+				Error: &dtos.ErrorResponse{
+					ErrorDetails: err.Error(),
+				},
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dtos.BaseResponse{
+			Message: InternalServerError,
+			Error: &dtos.ErrorResponse{
+				ErrorDetails: err.Error(),
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dtos.BaseResponse{
+		Code:    0,
+		Message: "Unlock account success",
+	})
+}
+
 func (h *userHandler) ListUserPaginate(c *gin.Context) {
 	pageData := make(map[string]int)
 	conditions := make(map[string]interface{})
@@ -0,0 +1,60 @@
+package migrations
+
+import (
+	"go-server/internal/pkg/domains/models/entities"
+
+	"gorm.io/gorm"
+)
+
+// dedupeReviews prepares the unique review index of a user and place: before it exists, the
+// most recently updated review of every pair is kept and the others become plain replies
+// to it, handing their threads over
+func dedupeReviews(db *gorm.DB) error {
+	migrator := db.Migrator()
+	if !migrator.HasTable(&entities.Comment{}) || migrator.HasIndex(&entities.Comment{}, "idx_comments_place_review") {
+		return nil
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		var reviews []entities.Comment
+		err := tx.
+			Where("parent_comment_id IS NULL AND user_id IS NOT NULL").
+			Order("updated_at DESC, id DESC").
+			Find(&reviews).Error
+		if err != nil {
+			return err
+		}
+
+		kept := make(map[[2]int]int)
+		for _, review := range reviews {
+			key := [2]int{review.PlaceID, review.UserID}
+			survivorID, ok := kept[key]
+			if !ok {
+				kept[key] = review.ID
+				continue
+			}
+
+			err = tx.Unscoped().Model(&entities.Comment{}).
+				Where("parent_comment_id = ?", review.ID).
+				UpdateColumns(map[string]interface{}{
+					"parent_comment_id": survivorID,
+					"is_official":       false,
+				}).Error
+			if err != nil {
+				return err
+			}
+
+			err = tx.Model(&entities.Comment{}).
+				Where("id = ?", review.ID).
+				UpdateColumns(map[string]interface{}{
+					"parent_comment_id": survivorID,
+					"rate":              0,
+				}).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
@@ -12,6 +12,13 @@ func Migrate(db *gorm.DB) error {
 		return err
 	}
 
+	err = dedupeReviews(db)
+	if err != nil {
+		return err
+	}
+
+	backfillVerified := needsVerifiedBackfill(db)
+
 	err = db.AutoMigrate(
 		entities.Banner{},
 		entities.Category{},
@@ -21,7 +28,35 @@ func Migrate(db *gorm.DB) error {
 		entities.Day{},
 		entities.Comment{},
 		entities.UserToken{},
+		entities.Translation{},
+		entities.ContentEvent{},
+		entities.ContentDailyStat{},
+		entities.CommentVote{},
+		entities.CommentReport{},
+		entities.CommentModerationLog{},
+		entities.CommentImage{},
+		entities.PasswordResetToken{},
+		entities.UserIdentity{},
+		entities.UserRecoveryCode{},
+		entities.Permission{},
+		entities.Role{},
+		entities.LoginAttempt{},
+		entities.APIKey{},
+		entities.APIKeyDailyUsage{},
+		entities.Follow{},
+		entities.Notification{},
+		entities.NotificationPreference{},
 	)
+	if err != nil {
+		return err
+	}
+
+	if backfillVerified {
+		err = backfillVerifiedEmails(db)
+		if err != nil {
+			return err
+		}
+	}
 
-	return err
+	return seedRoles(db)
 }
@@ -0,0 +1,68 @@
+package migrations
+
+import (
+	"go-server/internal/pkg/domains/models/entities"
+
+	"gorm.io/gorm"
+)
+
+// seedRoles creates the built-in roles and keeps their permissions in sync with
+// entities.DefaultRolePermissions
+func seedRoles(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		permissions := make(map[string]entities.Permission)
+		for _, names := range entities.DefaultRolePermissions {
+			for _, name := range names {
+				if _, ok := permissions[name]; ok {
+					continue
+				}
+				permission := entities.Permission{}
+				err := tx.Where(entities.Permission{Name: name}).FirstOrCreate(&permission).Error
+				if err != nil {
+					return err
+				}
+				permissions[name] = permission
+			}
+		}
+
+		for roleName, names := range entities.DefaultRolePermissions {
+			role := entities.Role{}
+			err := tx.Where(entities.Role{Name: roleName}).FirstOrCreate(&role).Error
+			if err != nil {
+				return err
+			}
+
+			rolePermissions := make([]entities.Permission, len(names))
+			for i, name := range names {
+				rolePermissions[i] = permissions[name]
+			}
+			err = tx.Model(&role).Association("Permissions").Replace(rolePermissions)
+			if err != nil {
+				return err
+			}
+		}
+
+		return backfillSuperAdmins(tx)
+	})
+}
+
+// backfillSuperAdmins keeps the access of the admins created before roles existed: when no
+// role was ever assigned, every is_admin user becomes super_admin
+func backfillSuperAdmins(tx *gorm.DB) error {
+	var assigned int64
+	err := tx.Table("user_roles").Count(&assigned).Error
+	if err != nil || assigned > 0 {
+		return err
+	}
+
+	var superAdmin entities.Role
+	err = tx.Where("name = ?", entities.RoleSuperAdmin).Take(&superAdmin).Error
+	if err != nil {
+		return err
+	}
+
+	return tx.Exec(
+		"INSERT INTO user_roles (user_id, role_id) SELECT id, ? FROM users WHERE is_admin = ? AND deleted_at IS NULL",
+		superAdmin.ID, true,
+	).Error
+}
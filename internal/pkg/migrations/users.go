@@ -0,0 +1,24 @@
+package migrations
+
+import (
+	"time"
+
+	"go-server/internal/pkg/domains/models/entities"
+
+	"gorm.io/gorm"
+)
+
+// needsVerifiedBackfill tells, before AutoMigrate, whether the users table predates email
+// verification
+func needsVerifiedBackfill(db *gorm.DB) bool {
+	migrator := db.Migrator()
+	return migrator.HasTable(&entities.User{}) && !migrator.HasColumn(&entities.User{}, "EmailVerifiedAt")
+}
+
+// backfillVerifiedEmails marks the accounts created before email verification existed as
+// verified, so requiring verification does not lock them out
+func backfillVerifiedEmails(db *gorm.DB) error {
+	return db.Unscoped().Model(&entities.User{}).
+		Where("email_verified_at IS NULL").
+		UpdateColumn("email_verified_at", time.Now()).Error
+}
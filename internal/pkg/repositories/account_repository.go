@@ -0,0 +1,183 @@
+package repositories
+
+import (
+	"context"
+	"go-server/internal/pkg/domains/interfaces"
+	"go-server/internal/pkg/domains/models/entities"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type accountRepository struct {
+	db     *gorm.DB
+	logger *logrus.Logger
+}
+
+func NewAccountRepository(
+	db *gorm.DB,
+	logger *logrus.Logger,
+) interfaces.AccountRepository {
+	return &accountRepository{
+		db,
+		logger,
+	}
+}
+
+func (r *accountRepository) FindTrips(
+	ctx context.Context,
+	userID int,
+) ([]entities.Trip, error) {
+	cdb := r.db.WithContext(ctx)
+
+	var trips []entities.Trip
+	err := cdb.Preload("Days").Where("owner = ?", userID).Order("id ASC").Find(&trips).Error
+	return trips, err
+}
+
+func (r *accountRepository) FindComments(
+	ctx context.Context,
+	userID int,
+) ([]entities.Comment, error) {
+	cdb := r.db.WithContext(ctx)
+
+	var comments []entities.Comment
+	err := cdb.Preload("Images").Where("user_id = ?", userID).Order("id ASC").Find(&comments).Error
+	return comments, err
+}
+
+func (r *accountRepository) FindCommentVotes(
+	ctx context.Context,
+	userID int,
+) ([]entities.CommentVote, error) {
+	cdb := r.db.WithContext(ctx)
+
+	var votes []entities.CommentVote
+	err := cdb.Where("user_id = ?", userID).Order("id ASC").Find(&votes).Error
+	return votes, err
+}
+
+func (r *accountRepository) FindCommentReports(
+	ctx context.Context,
+	userID int,
+) ([]entities.CommentReport, error) {
+	cdb := r.db.WithContext(ctx)
+
+	var reports []entities.CommentReport
+	err := cdb.Where("user_id = ?", userID).Order("id ASC").Find(&reports).Error
+	return reports, err
+}
+
+func (r *accountRepository) FindIdentities(
+	ctx context.Context,
+	userID int,
+) ([]entities.UserIdentity, error) {
+	cdb := r.db.WithContext(ctx)
+
+	var identities []entities.UserIdentity
+	err := cdb.Where("user_id = ?", userID).Order("id ASC").Find(&identities).Error
+	return identities, err
+}
+
+func (r *accountRepository) FindFollows(
+	ctx context.Context,
+	userID int,
+) ([]entities.Follow, error) {
+	cdb := r.db.WithContext(ctx)
+
+	var follows []entities.Follow
+	err := cdb.Where("follower_id = ?", userID).Order("id ASC").Find(&follows).Error
+	return follows, err
+}
+
+func (r *accountRepository) FindNotifications(
+	ctx context.Context,
+	userID int,
+) ([]entities.Notification, error) {
+	cdb := r.db.WithContext(ctx)
+
+	var notifications []entities.Notification
+	err := cdb.Where("user_id = ?", userID).Order("id ASC").Find(&notifications).Error
+	return notifications, err
+}
+
+func (r *accountRepository) FindDueForDeletion(
+	ctx context.Context,
+	before time.Time,
+	limit int,
+) ([]entities.User, error) {
+	cdb := r.db.WithContext(ctx)
+
+	var users []entities.User
+	err := cdb.Unscoped().
+		Where("deletion_scheduled_at IS NOT NULL AND deletion_scheduled_at <= ?", before).
+		Order("deletion_scheduled_at ASC").
+		Limit(limit).
+		Find(&users).Error
+	return users, err
+}
+
+func (r *accountRepository) EraseWithTx(
+	tx *gorm.DB,
+	user entities.User,
+) error {
+	// reviews and replies stay for the other users, without their author
+	err := tx.Unscoped().Model(&entities.Comment{}).Where("user_id = ?", user.ID).UpdateColumn("user_id", nil).Error
+	if err != nil {
+		return err
+	}
+	err = tx.Unscoped().Model(&entities.ContentEvent{}).Where("user_id = ?", user.ID).UpdateColumn("user_id", nil).Error
+	if err != nil {
+		return err
+	}
+
+	var tripIDs []int
+	err = tx.Unscoped().Model(&entities.Trip{}).Where("owner = ?", user.ID).Pluck("id", &tripIDs).Error
+	if err != nil {
+		return err
+	}
+	if len(tripIDs) > 0 {
+		err = tx.Unscoped().Where("trip_id IN ?", tripIDs).Delete(&entities.Day{}).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Where("trip_id IN ?", tripIDs).Delete(&entities.UserTrip{}).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Unscoped().Where("id IN ?", tripIDs).Delete(&entities.Trip{}).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, model := range []interface{}{
+		&entities.CommentVote{},
+		&entities.CommentReport{},
+		&entities.UserToken{},
+		&entities.PasswordResetToken{},
+		&entities.UserIdentity{},
+		&entities.UserRecoveryCode{},
+		&entities.UserTrip{},
+		&entities.Notification{},
+		&entities.NotificationPreference{},
+	} {
+		err = tx.Unscoped().Where("user_id = ?", user.ID).Delete(model).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	err = tx.Unscoped().Where("follower_id = ? OR followee_id = ?", user.ID, user.ID).Delete(&entities.Follow{}).Error
+	if err != nil {
+		return err
+	}
+
+	err = tx.Model(&user).Association("Roles").Clear()
+	if err != nil {
+		return err
+	}
+
+	return tx.Unscoped().Delete(&entities.User{}, user.ID).Error
+}
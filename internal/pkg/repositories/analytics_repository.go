@@ -0,0 +1,90 @@
+package repositories
+
+import (
+	"context"
+	"go-server/internal/pkg/domains/interfaces"
+	"go-server/internal/pkg/domains/models/entities"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type analyticsRepository struct {
+	db     *gorm.DB
+	logger *logrus.Logger
+}
+
+func NewAnalyticsRepository(
+	db *gorm.DB,
+	logger *logrus.Logger,
+) interfaces.AnalyticsRepository {
+	return &analyticsRepository{
+		db,
+		logger,
+	}
+}
+
+func (r *analyticsRepository) CreateEventWithTx(
+	tx *gorm.DB,
+	event entities.ContentEvent,
+) (entities.ContentEvent, error) {
+	err := tx.Create(&event).Error
+	return event, err
+}
+
+// IncrementDailyStatWithTx creates the counters of the day or adds the stat counters to them
+func (r *analyticsRepository) IncrementDailyStatWithTx(
+	tx *gorm.DB,
+	stat entities.ContentDailyStat,
+) error {
+	return tx.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "content_type"}, {Name: "content_id"}, {Name: "date"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"impressions": gorm.Expr("impressions + ?", stat.Impressions),
+			"clicks":      gorm.Expr("clicks + ?", stat.Clicks),
+			"updated_at":  time.Now(),
+		}),
+	}).Create(&stat).Error
+}
+
+func (r *analyticsRepository) FindDailyStats(
+	ctx context.Context,
+	contentType string,
+	contentID int,
+	from time.Time,
+	to time.Time,
+) ([]entities.ContentDailyStat, error) {
+	cdb := r.db.WithContext(ctx)
+
+	var stats []entities.ContentDailyStat
+	err := cdb.
+		Where("content_type = ? AND content_id = ?", contentType, contentID).
+		Where("date BETWEEN ? AND ?", from, to).
+		Order("date ASC").
+		Find(&stats).Error
+
+	return stats, err
+}
+
+func (r *analyticsRepository) ContentExists(
+	ctx context.Context,
+	contentType string,
+	contentID int,
+) (bool, error) {
+	cdb := r.db.WithContext(ctx)
+
+	var model interface{}
+	switch contentType {
+	case entities.ContentTypeBanner:
+		model = &entities.Banner{}
+	default:
+		return false, nil
+	}
+
+	var count int64
+	err := cdb.Model(model).Where("id = ?", contentID).Count(&count).Error
+
+	return count > 0, err
+}
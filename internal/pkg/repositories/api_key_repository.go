@@ -0,0 +1,85 @@
+package repositories
+
+import (
+	"context"
+	"go-server/internal/pkg/domains/interfaces"
+	"go-server/internal/pkg/domains/models/entities"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type apiKeyRepository struct {
+	db     *gorm.DB
+	logger *logrus.Logger
+}
+
+func NewAPIKeyRepository(
+	db *gorm.DB,
+	logger *logrus.Logger,
+) interfaces.APIKeyRepository {
+	return &apiKeyRepository{
+		db,
+		logger,
+	}
+}
+
+func (r *apiKeyRepository) Create(
+	ctx context.Context,
+	apiKey entities.APIKey,
+) (entities.APIKey, error) {
+	cdb := r.db.WithContext(ctx)
+
+	err := cdb.Create(&apiKey).Error
+	return apiKey, err
+}
+
+func (r *apiKeyRepository) FindAll(
+	ctx context.Context,
+) ([]entities.APIKey, error) {
+	cdb := r.db.WithContext(ctx)
+
+	var apiKeys []entities.APIKey
+	err := cdb.Order("created_at DESC").Find(&apiKeys).Error
+	return apiKeys, err
+}
+
+func (r *apiKeyRepository) TakeByConditions(
+	ctx context.Context,
+	conditions map[string]interface{},
+) (entities.APIKey, error) {
+	cdb := r.db.WithContext(ctx)
+
+	var apiKey entities.APIKey
+	err := cdb.Where(conditions).Take(&apiKey).Error
+	return apiKey, err
+}
+
+func (r *apiKeyRepository) UpdateColumns(
+	ctx context.Context,
+	apiKey entities.APIKey,
+	columns map[string]interface{},
+) error {
+	cdb := r.db.WithContext(ctx)
+
+	return cdb.Model(&entities.APIKey{}).Where("id = ?", apiKey.ID).UpdateColumns(columns).Error
+}
+
+func (r *apiKeyRepository) FindDailyUsages(
+	ctx context.Context,
+	apiKeyID int,
+	from time.Time,
+	to time.Time,
+) ([]entities.APIKeyDailyUsage, error) {
+	cdb := r.db.WithContext(ctx)
+
+	var usages []entities.APIKeyDailyUsage
+	err := cdb.
+		Where("api_key_id = ?", apiKeyID).
+		Where("date BETWEEN ? AND ?", from, to).
+		Order("date ASC").
+		Find(&usages).Error
+
+	return usages, err
+}
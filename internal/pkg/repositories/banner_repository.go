@@ -4,9 +4,11 @@ import (
 	"context"
 	"go-server/internal/pkg/domains/interfaces"
 	"go-server/internal/pkg/domains/models/entities"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type bannerRepository struct {
@@ -24,12 +26,11 @@ func NewBannerRepository(
 	}
 }
 
-func (r *bannerRepository) Create(
-	ctx context.Context,
+func (r *bannerRepository) CreateWithTx(
+	tx *gorm.DB,
 	banner entities.Banner,
 ) (entities.Banner, error) {
-	cdb := r.db.WithContext(ctx)
-	err := cdb.Create(&banner).Error
+	err := tx.Create(&banner).Error
 	return banner, err
 }
 
@@ -40,18 +41,17 @@ func (r *bannerRepository) FindByConditions(
 	cdb := r.db.WithContext(ctx)
 
 	var banners []entities.Banner
-	err := cdb.Where(conditions).Find(&banners).Error
+	err := cdb.Preload("Translations").Where(conditions).Order("position ASC, id ASC").Find(&banners).Error
 
 	return banners, err
 }
 
-func (r *bannerRepository) Update(
-	ctx context.Context,
+func (r *bannerRepository) UpdateWithTx(
+	tx *gorm.DB,
 	banner entities.Banner,
 	newBanner entities.Banner,
 ) (entities.Banner, error) {
-	cdb := r.db.WithContext(ctx)
-	err := cdb.Model(&banner).Updates(newBanner).Error
+	err := tx.Model(&banner).Omit(clause.Associations).Updates(newBanner).Error
 	return banner, err
 }
 
@@ -62,7 +62,7 @@ func (r *bannerRepository) TakeByConditions(
 	cdb := r.db.WithContext(ctx)
 
 	var banner entities.Banner
-	err := cdb.Where(conditions).Take(&banner).Error
+	err := cdb.Preload("Translations").Where(conditions).Take(&banner).Error
 	return banner, err
 }
 
@@ -73,3 +73,90 @@ func (r *bannerRepository) DeleteByConditions(
 	cdb := r.db.WithContext(ctx)
 	return cdb.Where(conditions).Delete(&entities.Banner{}).Error
 }
+
+func (r *bannerRepository) FindLive(
+	ctx context.Context,
+	now time.Time,
+) ([]entities.Banner, error) {
+	cdb := r.db.WithContext(ctx)
+
+	var banners []entities.Banner
+	err := cdb.Preload("Translations").
+		Scopes(liveBanners(now)).
+		Order("position ASC, id ASC").
+		Find(&banners).Error
+
+	return banners, err
+}
+
+func (r *bannerRepository) TakeLive(
+	ctx context.Context,
+	bannerID int,
+	now time.Time,
+) (entities.Banner, error) {
+	cdb := r.db.WithContext(ctx)
+
+	var banner entities.Banner
+	err := cdb.Preload("Translations").
+		Scopes(liveBanners(now)).
+		Where("id = ?", bannerID).
+		Take(&banner).Error
+	return banner, err
+}
+
+// liveBanners keeps the active banners whose display window contains now
+func liveBanners(now time.Time) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.
+			Where("active = ?", true).
+			Where("start_at IS NULL OR start_at <= ?", now).
+			Where("end_at IS NULL OR end_at > ?", now)
+	}
+}
+
+func (r *bannerRepository) UpdateColumnsWithTx(
+	tx *gorm.DB,
+	banner entities.Banner,
+	columns map[string]interface{},
+) (entities.Banner, error) {
+	err := tx.Model(&banner).UpdateColumns(columns).Error
+	return banner, err
+}
+
+func (r *bannerRepository) TargetExists(
+	ctx context.Context,
+	targetType string,
+	targetID int,
+) (bool, error) {
+	cdb := r.db.WithContext(ctx)
+
+	var model interface{}
+	switch targetType {
+	case entities.BannerTargetPlace:
+		model = &entities.Place{}
+	case entities.BannerTargetCategory:
+		model = &entities.Category{}
+	case entities.BannerTargetTripTemplate:
+		model = &entities.Trip{}
+	default:
+		return false, nil
+	}
+
+	var count int64
+	err := cdb.Model(model).Where("id = ?", targetID).Count(&count).Error
+
+	return count > 0, err
+}
+
+// RetargetWithTx points the banners of a target to another one of the same type, e.g. when
+// places are merged
+func (r *bannerRepository) RetargetWithTx(
+	tx *gorm.DB,
+	targetType string,
+	fromTargetID int,
+	toTargetID int,
+) error {
+	return tx.Model(&entities.Banner{}).
+		Where("target_type = ? AND target_id = ?", targetType, fromTargetID).
+		Update("target_id", toTargetID).Error
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type categoryRepository struct {
@@ -24,12 +25,11 @@ func NewCategoryRepository(
 	}
 }
 
-func (r *categoryRepository) Create(
-	ctx context.Context,
+func (r *categoryRepository) CreateWithTx(
+	tx *gorm.DB,
 	category entities.Category,
 ) (entities.Category, error) {
-	cdb := r.db.WithContext(ctx)
-	err := cdb.Create(&category).Error
+	err := tx.Create(&category).Error
 	return category, err
 }
 
@@ -40,18 +40,17 @@ func (r *categoryRepository) FindByConditions(
 	cdb := r.db.WithContext(ctx)
 
 	var categories []entities.Category
-	err := cdb.Where(conditions).Find(&categories).Error
+	err := cdb.Preload("Translations").Where(conditions).Find(&categories).Error
 
 	return categories, err
 }
 
-func (r *categoryRepository) Update(
-	ctx context.Context,
+func (r *categoryRepository) UpdateWithTx(
+	tx *gorm.DB,
 	category entities.Category,
 	newBanner entities.Category,
 ) (entities.Category, error) {
-	cdb := r.db.WithContext(ctx)
-	err := cdb.Model(&category).Updates(newBanner).Error
+	err := tx.Model(&category).Omit(clause.Associations).Updates(newBanner).Error
 	return category, err
 }
 
@@ -62,7 +61,7 @@ func (r *categoryRepository) TakeByConditions(
 	cdb := r.db.WithContext(ctx)
 
 	var category entities.Category
-	err := cdb.Where(conditions).Take(&category).Error
+	err := cdb.Preload("Translations").Where(conditions).Take(&category).Error
 	return category, err
 }
 
@@ -81,7 +80,7 @@ func (r *categoryRepository) TakeByConditionsWithPreload(
 	cdb := r.db.WithContext(ctx)
 
 	var category entities.Category
-	err := cdb.Preload("Places").Where(conditions).Take(&category).Error
+	err := cdb.Preload("Places").Preload("Children").Where(conditions).Take(&category).Error
 	return category, err
 }
 
@@ -96,3 +95,13 @@ func (r *categoryRepository) PluckIDByConditions(
 
 	return ids, err
 }
+
+func (r *categoryRepository) UpdateParentWithTx(
+	tx *gorm.DB,
+	category entities.Category,
+	parentID *int,
+) (entities.Category, error) {
+	err := tx.Model(&category).UpdateColumn("parent_id", parentID).Error
+	category.ParentID = parentID
+	return category, err
+}
@@ -0,0 +1,118 @@
+package repositories
+
+import (
+	"context"
+	"go-server/internal/pkg/domains/interfaces"
+	"go-server/internal/pkg/domains/models/entities"
+	"go-server/pkg/shared/database"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type commentModerationRepository struct {
+	db     *gorm.DB
+	logger *logrus.Logger
+}
+
+func NewCommentModerationRepository(
+	db *gorm.DB,
+	logger *logrus.Logger,
+) interfaces.CommentModerationRepository {
+	return &commentModerationRepository{
+		db,
+		logger,
+	}
+}
+
+// UpsertReport creates the report of the user or reopens it with the new reason
+func (r *commentModerationRepository) UpsertReport(
+	ctx context.Context,
+	report entities.CommentReport,
+) error {
+	cdb := r.db.WithContext(ctx)
+
+	return cdb.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "comment_id"}, {Name: "user_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"reason":     report.Reason,
+			"text":       report.Text,
+			"status":     report.Status,
+			"updated_at": time.Now(),
+		}),
+	}).Create(&report).Error
+}
+
+func (r *commentModerationRepository) ResolveReportsWithTx(
+	tx *gorm.DB,
+	commentID int,
+) error {
+	return tx.Model(&entities.CommentReport{}).
+		Where("comment_id = ? AND status = ?", commentID, entities.CommentReportStatusOpen).
+		Update("status", entities.CommentReportStatusResolved).Error
+}
+
+func (r *commentModerationRepository) UpdateStatusWithTx(
+	tx *gorm.DB,
+	commentID int,
+	status string,
+) error {
+	return tx.Model(&entities.Comment{}).Where("id = ?", commentID).Update("status", status).Error
+}
+
+func (r *commentModerationRepository) CreateLogWithTx(
+	tx *gorm.DB,
+	log entities.CommentModerationLog,
+) error {
+	return tx.Create(&log).Error
+}
+
+func (r *commentModerationRepository) FindLogs(
+	ctx context.Context,
+	commentID int,
+) ([]entities.CommentModerationLog, error) {
+	cdb := r.db.WithContext(ctx)
+
+	var logs []entities.CommentModerationLog
+	err := cdb.Where("comment_id = ?", commentID).Order("created_at ASC, id ASC").Find(&logs).Error
+	return logs, err
+}
+
+func (r *commentModerationRepository) FindQueuePaginate(
+	ctx context.Context,
+	pageData map[string]int,
+	queue string,
+) ([]entities.Comment, int64, error) {
+	cdb := r.db.WithContext(ctx)
+
+	reported := cdb.Model(&entities.CommentReport{}).Select("comment_id").Where("status = ?", entities.CommentReportStatusOpen)
+	inQueue := func(db *gorm.DB) *gorm.DB {
+		switch queue {
+		case entities.CommentQueueHeld:
+			return db.Where("status = ?", entities.CommentStatusHeld)
+		case entities.CommentQueueHidden:
+			return db.Where("status = ?", entities.CommentStatusHidden)
+		case entities.CommentQueueReported:
+			return db.Where("id IN (?)", reported)
+		}
+		return db.Where("status = ? OR id IN (?)", entities.CommentStatusHeld, reported)
+	}
+
+	var comments []entities.Comment
+	var count int64
+	err := cdb.Model(&entities.Comment{}).Scopes(inQueue).Count(&count).Error
+	if err != nil {
+		return comments, count, err
+	}
+
+	err = cdb.Scopes(inQueue, database.Pagination(pageData)).
+		Preload("User").
+		Preload("Reports", "status = ?", entities.CommentReportStatusOpen).
+		Preload("Reports.User").
+		Order("updated_at ASC").
+		Find(&comments).Error
+
+	return comments, count, err
+}
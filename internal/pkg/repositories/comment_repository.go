@@ -0,0 +1,296 @@
+package repositories
+
+import (
+	"context"
+	"go-server/internal/pkg/domains/interfaces"
+	"go-server/internal/pkg/domains/models/entities"
+	"go-server/pkg/shared/database"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// commentImagesOrder keeps the images of a comment in the order they were attached
+func commentImagesOrder(db *gorm.DB) *gorm.DB {
+	return db.Order("position ASC")
+}
+
+// commentAuthor loads the public columns of the author of a comment, comments are shown to
+// every user
+func commentAuthor(db *gorm.DB) *gorm.DB {
+	return db.Select("id", "username", "avatar")
+}
+
+type commentRepository struct {
+	db     *gorm.DB
+	logger *logrus.Logger
+}
+
+func NewCommentRepository(
+	db *gorm.DB,
+	logger *logrus.Logger,
+) interfaces.CommentRepository {
+	return &commentRepository{
+		db,
+		logger,
+	}
+}
+
+func (r *commentRepository) Create(
+	ctx context.Context,
+	comment entities.Comment,
+) (entities.Comment, error) {
+	cdb := r.db.WithContext(ctx)
+
+	err := cdb.Create(&comment).Error
+	return comment, err
+}
+
+func (r *commentRepository) CreateWithTx(
+	tx *gorm.DB,
+	comment entities.Comment,
+) (entities.Comment, error) {
+	err := tx.Create(&comment).Error
+	return comment, err
+}
+
+func (r *commentRepository) TakeByConditions(
+	ctx context.Context,
+	conditions map[string]interface{},
+) (entities.Comment, error) {
+	cdb := r.db.WithContext(ctx)
+
+	var comment entities.Comment
+	err := cdb.Preload("User", commentAuthor).Preload("Images", commentImagesOrder).Where(conditions).Take(&comment).Error
+	return comment, err
+}
+
+func (r *commentRepository) UpdateColumnsWithTx(
+	tx *gorm.DB,
+	comment entities.Comment,
+	columns map[string]interface{},
+) (entities.Comment, error) {
+	err := tx.Model(&comment).Updates(columns).Error
+	return comment, err
+}
+
+func (r *commentRepository) UpdateColumns(
+	ctx context.Context,
+	comment entities.Comment,
+	columns map[string]interface{},
+) (entities.Comment, error) {
+	cdb := r.db.WithContext(ctx)
+
+	err := cdb.Model(&comment).Updates(columns).Error
+	return comment, err
+}
+
+// DeleteThreadWithTx deletes a comment along with its replies
+func (r *commentRepository) DeleteThreadWithTx(
+	tx *gorm.DB,
+	commentID int,
+) error {
+	return tx.Where("id = ? OR parent_comment_id = ?", commentID, commentID).Delete(&entities.Comment{}).Error
+}
+
+// ReplaceImagesWithTx sets the images of a comment, in the order of urls
+func (r *commentRepository) ReplaceImagesWithTx(
+	tx *gorm.DB,
+	commentID int,
+	urls []string,
+) error {
+	err := tx.Unscoped().Where("comment_id = ?", commentID).Delete(&entities.CommentImage{}).Error
+	if err != nil || len(urls) == 0 {
+		return err
+	}
+
+	images := make([]entities.CommentImage, 0, len(urls))
+	for i, url := range urls {
+		images = append(images, entities.CommentImage{
+			CommentID: commentID,
+			URL:       url,
+			Position:  i,
+		})
+	}
+
+	return tx.Create(&images).Error
+}
+
+// FindPlaceImagesPaginate lists the images of the published comments of a place, newest first
+func (r *commentRepository) FindPlaceImagesPaginate(
+	ctx context.Context,
+	placeID int,
+	pageData map[string]int,
+) ([]entities.CommentImage, int64, error) {
+	cdb := r.db.WithContext(ctx)
+
+	published := func(db *gorm.DB) *gorm.DB {
+		return db.Joins("JOIN comments ON comments.id = comment_images.comment_id AND comments.deleted_at IS NULL").
+			Where("comments.place_id = ? AND comments.status = ?", placeID, entities.CommentStatusPublished)
+	}
+
+	var images []entities.CommentImage
+	var count int64
+	err := cdb.Model(&entities.CommentImage{}).Scopes(published).Count(&count).Error
+	if err != nil {
+		return images, count, err
+	}
+
+	err = cdb.Scopes(published, database.Pagination(pageData)).
+		Preload("Comment.User", commentAuthor).
+		Order("comment_images.created_at DESC, comment_images.id ASC").
+		Find(&images).Error
+
+	return images, count, err
+}
+
+func (r *commentRepository) FindListPaginate(
+	ctx context.Context,
+	pageData map[string]int,
+	conditions map[string]interface{},
+	order string,
+) ([]entities.Comment, int64, error) {
+	cdb := r.db.WithContext(ctx)
+
+	var comments []entities.Comment
+	var count int64
+	err := cdb.Model(&entities.Comment{}).Where(conditions).Count(&count).Error
+	if err != nil {
+		return comments, count, err
+	}
+
+	err = cdb.Scopes(database.Pagination(pageData)).Preload("User", commentAuthor).Preload("Images", commentImagesOrder).Where(conditions).Order(order).Find(&comments).Error
+	return comments, count, err
+}
+
+func (r *commentRepository) UpsertVoteWithTx(
+	tx *gorm.DB,
+	vote entities.CommentVote,
+) error {
+	return tx.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "comment_id"}, {Name: "user_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"helpful":    vote.Helpful,
+			"updated_at": time.Now(),
+		}),
+	}).Create(&vote).Error
+}
+
+func (r *commentRepository) DeleteVoteWithTx(
+	tx *gorm.DB,
+	commentID int,
+	userID int,
+) error {
+	return tx.Unscoped().Where("comment_id = ? AND user_id = ?", commentID, userID).Delete(&entities.CommentVote{}).Error
+}
+
+// RefreshVoteCountsWithTx recounts the votes of a comment into its helpful counters
+func (r *commentRepository) RefreshVoteCountsWithTx(
+	tx *gorm.DB,
+	commentID int,
+) error {
+	helpful := tx.Model(&entities.CommentVote{}).Select("COUNT(*)").Where("comment_id = ? AND helpful = ?", commentID, true)
+	notHelpful := tx.Model(&entities.CommentVote{}).Select("COUNT(*)").Where("comment_id = ? AND helpful = ?", commentID, false)
+
+	return tx.Model(&entities.Comment{}).Where("id = ?", commentID).UpdateColumns(map[string]interface{}{
+		"helpful_count":     helpful,
+		"not_helpful_count": notHelpful,
+	}).Error
+}
+
+func (r *commentRepository) CountReplies(
+	ctx context.Context,
+	reviewIDs []int,
+) (map[int]int64, error) {
+	cdb := r.db.WithContext(ctx)
+
+	counts := make(map[int]int64, len(reviewIDs))
+	if len(reviewIDs) == 0 {
+		return counts, nil
+	}
+
+	var rows []struct {
+		ParentCommentID int
+		Count           int64
+	}
+	err := cdb.Model(&entities.Comment{}).
+		Select("parent_comment_id, COUNT(*) AS count").
+		Scopes(publishedReplies(reviewIDs)).
+		Group("parent_comment_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		counts[row.ParentCommentID] = row.Count
+	}
+	return counts, nil
+}
+
+func (r *commentRepository) FindFirstReplies(
+	ctx context.Context,
+	reviewIDs []int,
+	limit int,
+) ([]entities.Comment, error) {
+	cdb := r.db.WithContext(ctx)
+
+	var replies []entities.Comment
+	if len(reviewIDs) == 0 || limit <= 0 {
+		return replies, nil
+	}
+
+	ranked := cdb.Model(&entities.Comment{}).
+		Select("id, ROW_NUMBER() OVER (PARTITION BY parent_comment_id ORDER BY created_at ASC, id ASC) AS reply_rank").
+		Scopes(publishedReplies(reviewIDs))
+	err := cdb.Preload("User", commentAuthor).Preload("Images", commentImagesOrder).
+		Where("id IN (?)", cdb.Table("(?) AS ranked", ranked).Select("id").Where("reply_rank <= ?", limit)).
+		Order("created_at ASC, id ASC").
+		Find(&replies).Error
+	return replies, err
+}
+
+func (r *commentRepository) FindOfficialResponses(
+	ctx context.Context,
+	reviewIDs []int,
+) ([]entities.Comment, error) {
+	cdb := r.db.WithContext(ctx)
+
+	var responses []entities.Comment
+	if len(reviewIDs) == 0 {
+		return responses, nil
+	}
+
+	err := cdb.Preload("User", commentAuthor).Preload("Images", commentImagesOrder).
+		Where("parent_comment_id IN (?) AND is_official = ?", reviewIDs, true).
+		Find(&responses).Error
+	return responses, err
+}
+
+// publishedReplies keeps the published replies of the reviews, official responses excluded
+func publishedReplies(reviewIDs []int) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(
+			"parent_comment_id IN (?) AND is_official = ? AND status = ?",
+			reviewIDs, false, entities.CommentStatusPublished,
+		)
+	}
+}
+
+func (r *commentRepository) FindVotesByUser(
+	ctx context.Context,
+	userID int,
+	commentIDs []int,
+) ([]entities.CommentVote, error) {
+	cdb := r.db.WithContext(ctx)
+
+	var votes []entities.CommentVote
+	if len(commentIDs) == 0 {
+		return votes, nil
+	}
+
+	err := cdb.Where("user_id = ? AND comment_id IN (?)", userID, commentIDs).Find(&votes).Error
+	return votes, err
+}
@@ -0,0 +1,112 @@
+package repositories
+
+import (
+	"context"
+	"go-server/internal/pkg/domains/interfaces"
+	"go-server/internal/pkg/domains/models/entities"
+	"sync"
+	"time"
+)
+
+// memoryLoginAttemptStore is the LoginAttemptStore of a single instance, counters are lost on restart
+type memoryLoginAttemptStore struct {
+	mu       sync.Mutex
+	attempts map[string]entities.LoginAttempt
+	// windows keeps the failure window of each key, login failures and password reset
+	// requests are counted over different ones
+	windows map[string]time.Duration
+	sweptAt time.Time
+}
+
+func NewMemoryLoginAttemptStore() interfaces.LoginAttemptStore {
+	return &memoryLoginAttemptStore{
+		attempts: make(map[string]entities.LoginAttempt),
+		windows:  make(map[string]time.Duration),
+	}
+}
+
+func (s *memoryLoginAttemptStore) Take(
+	ctx context.Context,
+	key string,
+) (entities.LoginAttempt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	attempt, ok := s.attempts[key]
+	if !ok {
+		return entities.LoginAttempt{Key: key}, nil
+	}
+	return attempt, nil
+}
+
+func (s *memoryLoginAttemptStore) AddFailure(
+	ctx context.Context,
+	key string,
+	since time.Time,
+) (entities.LoginAttempt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.windows[key] = now.Sub(since)
+	if now.Sub(s.sweptAt) > time.Minute {
+		s.forget(now)
+		s.sweptAt = now
+	}
+
+	attempt := s.attempts[key]
+	if attempt.LastFailureAt != nil && attempt.LastFailureAt.Before(since) {
+		attempt.Failures = 0
+	}
+	attempt.Key = key
+	attempt.Failures++
+	attempt.LastFailureAt = &now
+	s.attempts[key] = attempt
+
+	return attempt, nil
+}
+
+func (s *memoryLoginAttemptStore) Lock(
+	ctx context.Context,
+	key string,
+	until time.Time,
+) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	attempt, ok := s.attempts[key]
+	if !ok {
+		return nil
+	}
+	attempt.LockedUntil = &until
+	s.attempts[key] = attempt
+
+	return nil
+}
+
+func (s *memoryLoginAttemptStore) Reset(
+	ctx context.Context,
+	key string,
+) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.attempts, key)
+	delete(s.windows, key)
+	return nil
+}
+
+// forget drops the counters without failure within their own window and no running lock,
+// it keeps the map from growing with every address ever seen
+func (s *memoryLoginAttemptStore) forget(now time.Time) {
+	for key, attempt := range s.attempts {
+		if attempt.LastFailureAt != nil && attempt.LastFailureAt.After(now.Add(-s.windows[key])) {
+			continue
+		}
+		if attempt.LockedUntil != nil && attempt.LockedUntil.After(now) {
+			continue
+		}
+		delete(s.attempts, key)
+		delete(s.windows, key)
+	}
+}
@@ -0,0 +1,41 @@
+package repositories
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreSweepsKeysByTheirOwnWindow(t *testing.T) {
+	store := NewMemoryLoginAttemptStore().(*memoryLoginAttemptStore)
+	ctx := context.Background()
+
+	_, err := store.AddFailure(ctx, "account:owner@example.com", time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = store.AddFailure(ctx, "password_reset:ip:203.0.113.1", time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale := time.Now().Add(-2 * time.Hour)
+	attempt := store.attempts["password_reset:ip:203.0.113.1"]
+	attempt.LastFailureAt = &stale
+	store.attempts["password_reset:ip:203.0.113.1"] = attempt
+
+	// a key counted over a short window sweeps the others by their own
+	time.Sleep(2 * time.Millisecond)
+	store.sweptAt = time.Time{}
+	_, err = store.AddFailure(ctx, "password_reset:account:owner@example.com", time.Now().Add(-time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	account, _ := store.Take(ctx, "account:owner@example.com")
+	if account.Failures != 1 {
+		t.Fatalf("login failures swept by the window of a password reset: %+v", account)
+	}
+	if _, ok := store.attempts["password_reset:ip:203.0.113.1"]; ok {
+		t.Fatal("counter past its window kept")
+	}
+}
@@ -0,0 +1,93 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"go-server/internal/pkg/domains/interfaces"
+	"go-server/internal/pkg/domains/models/entities"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type loginAttemptRepository struct {
+	db     *gorm.DB
+	logger *logrus.Logger
+}
+
+// NewLoginAttemptRepository is the database LoginAttemptStore
+func NewLoginAttemptRepository(
+	db *gorm.DB,
+	logger *logrus.Logger,
+) interfaces.LoginAttemptStore {
+	return &loginAttemptRepository{
+		db,
+		logger,
+	}
+}
+
+func (r *loginAttemptRepository) Take(
+	ctx context.Context,
+	key string,
+) (entities.LoginAttempt, error) {
+	cdb := r.db.WithContext(ctx)
+
+	var attempt entities.LoginAttempt
+	err := cdb.Where("`key` = ?", key).Take(&attempt).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return entities.LoginAttempt{Key: key}, nil
+	}
+	return attempt, err
+}
+
+func (r *loginAttemptRepository) AddFailure(
+	ctx context.Context,
+	key string,
+	since time.Time,
+) (entities.LoginAttempt, error) {
+	cdb := r.db.WithContext(ctx)
+
+	now := time.Now()
+	// failures is assigned first, it reads the previous last_failure_at
+	err := cdb.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "key"}},
+		DoUpdates: clause.Set{
+			{Column: clause.Column{Name: "failures"}, Value: gorm.Expr("CASE WHEN last_failure_at IS NOT NULL AND last_failure_at < ? THEN 1 ELSE failures + 1 END", since)},
+			{Column: clause.Column{Name: "last_failure_at"}, Value: now},
+			{Column: clause.Column{Name: "updated_at"}, Value: now},
+		},
+	}).Create(&entities.LoginAttempt{
+		Key:           key,
+		Failures:      1,
+		LastFailureAt: &now,
+	}).Error
+	if err != nil {
+		return entities.LoginAttempt{}, err
+	}
+
+	return r.Take(ctx, key)
+}
+
+func (r *loginAttemptRepository) Lock(
+	ctx context.Context,
+	key string,
+	until time.Time,
+) error {
+	cdb := r.db.WithContext(ctx)
+
+	return cdb.Model(&entities.LoginAttempt{}).
+		Where("`key` = ?", key).
+		Update("locked_until", until).Error
+}
+
+func (r *loginAttemptRepository) Reset(
+	ctx context.Context,
+	key string,
+) error {
+	cdb := r.db.WithContext(ctx)
+
+	// the row is dropped, it holds the email of the account
+	return cdb.Unscoped().Where("`key` = ?", key).Delete(&entities.LoginAttempt{}).Error
+}
@@ -0,0 +1,128 @@
+package repositories
+
+import (
+	"context"
+	"go-server/internal/pkg/domains/interfaces"
+	"go-server/internal/pkg/domains/models/entities"
+	"go-server/pkg/shared/database"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type notificationRepository struct {
+	db     *gorm.DB
+	logger *logrus.Logger
+}
+
+func NewNotificationRepository(
+	db *gorm.DB,
+	logger *logrus.Logger,
+) interfaces.NotificationRepository {
+	return &notificationRepository{
+		db,
+		logger,
+	}
+}
+
+func (r *notificationRepository) Create(
+	ctx context.Context,
+	notification entities.Notification,
+) (entities.Notification, error) {
+	cdb := r.db.WithContext(ctx)
+
+	err := cdb.Create(&notification).Error
+	return notification, err
+}
+
+func (r *notificationRepository) FindPaginate(
+	ctx context.Context,
+	userID int,
+	pageData map[string]int,
+	unreadOnly bool,
+) ([]entities.Notification, int64, error) {
+	cdb := r.db.WithContext(ctx)
+
+	query := cdb.Model(&entities.Notification{}).Where("user_id = ?", userID)
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+
+	var count int64
+	err := query.Count(&count).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var notifications []entities.Notification
+	err = query.
+		Scopes(database.Pagination(pageData)).
+		Order("created_at DESC, id DESC").
+		Find(&notifications).Error
+	return notifications, count, err
+}
+
+func (r *notificationRepository) CountUnread(
+	ctx context.Context,
+	userID int,
+) (int64, error) {
+	cdb := r.db.WithContext(ctx)
+
+	var count int64
+	err := cdb.Model(&entities.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *notificationRepository) TakeByConditions(
+	ctx context.Context,
+	conditions map[string]interface{},
+) (entities.Notification, error) {
+	cdb := r.db.WithContext(ctx)
+
+	var notification entities.Notification
+	err := cdb.Where(conditions).Take(&notification).Error
+	return notification, err
+}
+
+func (r *notificationRepository) MarkRead(
+	ctx context.Context,
+	userID int,
+	ids []int,
+) (int64, error) {
+	cdb := r.db.WithContext(ctx)
+
+	query := cdb.Model(&entities.Notification{}).Where("user_id = ? AND read_at IS NULL", userID)
+	if len(ids) > 0 {
+		query = query.Where("id IN ?", ids)
+	}
+
+	result := query.UpdateColumn("read_at", time.Now())
+	return result.RowsAffected, result.Error
+}
+
+func (r *notificationRepository) FindPreferences(
+	ctx context.Context,
+	userID int,
+) ([]entities.NotificationPreference, error) {
+	cdb := r.db.WithContext(ctx)
+
+	var preferences []entities.NotificationPreference
+	err := cdb.Where("user_id = ?", userID).Find(&preferences).Error
+	return preferences, err
+}
+
+func (r *notificationRepository) UpsertPreferences(
+	ctx context.Context,
+	preferences []entities.NotificationPreference,
+) error {
+	cdb := r.db.WithContext(ctx)
+
+	return cdb.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "type"}},
+		DoUpdates: clause.AssignmentColumns([]string{"in_app", "email", "updated_at"}),
+	}).Create(&preferences).Error
+}
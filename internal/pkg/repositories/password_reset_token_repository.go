@@ -0,0 +1,67 @@
+package repositories
+
+import (
+	"context"
+	"go-server/internal/pkg/domains/interfaces"
+	"go-server/internal/pkg/domains/models/entities"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type passwordResetTokenRepository struct {
+	db     *gorm.DB
+	logger *logrus.Logger
+}
+
+func NewPasswordResetTokenRepository(
+	db *gorm.DB,
+	logger *logrus.Logger,
+) interfaces.PasswordResetTokenRepository {
+	return &passwordResetTokenRepository{
+		db,
+		logger,
+	}
+}
+
+func (r *passwordResetTokenRepository) CreateWithTx(
+	tx *gorm.DB,
+	token entities.PasswordResetToken,
+) (entities.PasswordResetToken, error) {
+	err := tx.Create(&token).Error
+	return token, err
+}
+
+func (r *passwordResetTokenRepository) TakeByConditions(
+	ctx context.Context,
+	conditions map[string]interface{},
+) (entities.PasswordResetToken, error) {
+	cdb := r.db.WithContext(ctx)
+
+	var token entities.PasswordResetToken
+	err := cdb.Where(conditions).Take(&token).Error
+	return token, err
+}
+
+// MarkUsedWithTx consumes the token, false when it was already used concurrently
+func (r *passwordResetTokenRepository) MarkUsedWithTx(
+	tx *gorm.DB,
+	token entities.PasswordResetToken,
+) (bool, error) {
+	result := tx.Model(&entities.PasswordResetToken{}).
+		Where("id = ? AND used_at IS NULL", token.ID).
+		Update("used_at", time.Now())
+
+	return result.RowsAffected == 1, result.Error
+}
+
+// InvalidateByUserWithTx consumes every pending token of the user
+func (r *passwordResetTokenRepository) InvalidateByUserWithTx(
+	tx *gorm.DB,
+	userID int,
+) error {
+	return tx.Model(&entities.PasswordResetToken{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Update("used_at", time.Now()).Error
+}
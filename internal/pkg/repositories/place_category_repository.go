@@ -36,3 +36,28 @@ func (r *placeCategoryRepository) DeleteByConditionsWithTx(
 ) error {
 	return tx.Where(conditions).Delete(&entities.PlaceCategory{}).Error
 }
+
+// MovePlaceWithTx reassigns the categories of fromPlaceID to toPlaceID,
+// dropping the ones toPlaceID already has.
+func (r *placeCategoryRepository) MovePlaceWithTx(
+	tx *gorm.DB,
+	fromPlaceID int,
+	toPlaceID int,
+) error {
+	var existedCategoryIDs []int
+	err := tx.Model(&entities.PlaceCategory{}).Where("place_id = ?", toPlaceID).Pluck("category_id", &existedCategoryIDs).Error
+	if err != nil {
+		return err
+	}
+
+	moveBuilder := tx.Model(&entities.PlaceCategory{}).Where("place_id = ?", fromPlaceID)
+	if len(existedCategoryIDs) > 0 {
+		moveBuilder = moveBuilder.Where("category_id NOT IN ?", existedCategoryIDs)
+	}
+	err = moveBuilder.Update("place_id", toPlaceID).Error
+	if err != nil {
+		return err
+	}
+
+	return tx.Where("place_id = ?", fromPlaceID).Delete(&entities.PlaceCategory{}).Error
+}
@@ -2,6 +2,7 @@ package repositories
 
 import (
 	"context"
+	"fmt"
 	"go-server/internal/pkg/domains/interfaces"
 	"go-server/internal/pkg/domains/models/entities"
 	"go-server/pkg/shared/database"
@@ -54,12 +55,9 @@ func (r *placeRepository) FindListPaginate(
 
 	if categoryID, ok := conditions["category_id"]; ok {
 		delete(conditions, "category_id")
-		queryBuilder = queryBuilder.
-			Joins("JOIN place_categories ON (place_categories.place_id = places.id AND place_categories.deleted_at IS NULL)").
-			Where("category_id = ?", categoryID)
-		countBuilder = countBuilder.
-			Joins("JOIN place_categories ON (place_categories.place_id = places.id AND place_categories.deleted_at IS NULL)").
-			Where("category_id = ?", categoryID)
+		placeIDs := cdb.Model(&entities.PlaceCategory{}).Select("place_id").Where("category_id IN (?)", categoryID)
+		queryBuilder = queryBuilder.Where("places.id IN (?)", placeIDs)
+		countBuilder = countBuilder.Where("places.id IN (?)", placeIDs)
 	}
 
 	err := countBuilder.Where(conditions).Count(&count).Error
@@ -67,7 +65,7 @@ func (r *placeRepository) FindListPaginate(
 		return []entities.Place{}, 0, err
 	}
 
-	err = queryBuilder.Preload("Categories").Where(conditions).Order("updated_at DESC").Find(&places).Error
+	err = queryBuilder.Preload("Categories.Translations").Preload("Translations").Where(conditions).Order("updated_at DESC").Find(&places).Error
 	if err != nil {
 		return []entities.Place{}, 0, err
 	}
@@ -103,7 +101,7 @@ func (r *placeRepository) TakeByConditionsWithPreload(
 	cdb := r.db.WithContext(ctx)
 
 	var place entities.Place
-	err := cdb.Preload("Categories").Where(conditions).Take(&place).Error
+	err := cdb.Preload("Categories.Translations").Preload("Translations").Where(conditions).Take(&place).Error
 
 	return place, err
 }
@@ -132,15 +130,123 @@ func (r *placeRepository) FindByConditions(
 
 	if categoryID, ok := conditions["category_id"]; ok {
 		delete(conditions, "category_id")
-		queryBuilder = queryBuilder.
-			Joins("JOIN place_categories ON (place_categories.place_id = places.id AND place_categories.deleted_at IS NULL)").
-			Where("category_id = ?", categoryID)
+		placeIDs := cdb.Model(&entities.PlaceCategory{}).Select("place_id").Where("category_id IN (?)", categoryID)
+		queryBuilder = queryBuilder.Where("places.id IN (?)", placeIDs)
 	}
 
-	err := queryBuilder.Preload("Categories").Where(conditions).Order("updated_at DESC").Find(&places).Error
+	err := queryBuilder.Preload("Categories.Translations").Preload("Translations").Where(conditions).Order("updated_at DESC").Find(&places).Error
 	if err != nil {
 		return []entities.Place{}, err
 	}
 
 	return places, nil
 }
+
+func (r *placeRepository) FindInBoundingBox(
+	ctx context.Context,
+	minLatitude float64,
+	maxLatitude float64,
+	minLongitude float64,
+	maxLongitude float64,
+) ([]entities.Place, error) {
+	cdb := r.db.WithContext(ctx)
+
+	var places []entities.Place
+	err := cdb.
+		Where("latitude BETWEEN ? AND ?", minLatitude, maxLatitude).
+		Where("longitude BETWEEN ? AND ?", minLongitude, maxLongitude).
+		Find(&places).Error
+
+	return places, err
+}
+
+// MoveCommentsWithTx moves the comments of a place to another. A user who reviewed both
+// places keeps the most recently updated review, the other one becomes a plain reply to
+// it and hands its thread over.
+func (r *placeRepository) MoveCommentsWithTx(
+	tx *gorm.DB,
+	fromPlaceID int,
+	toPlaceID int,
+) error {
+	var reviews []entities.Comment
+	err := tx.
+		Where("place_id IN ? AND parent_comment_id IS NULL AND user_id IS NOT NULL", []int{fromPlaceID, toPlaceID}).
+		Order("updated_at DESC, id DESC").
+		Find(&reviews).Error
+	if err != nil {
+		return err
+	}
+
+	kept := make(map[int]entities.Comment)
+	for _, review := range reviews {
+		survivor, ok := kept[review.UserID]
+		if !ok {
+			kept[review.UserID] = review
+			continue
+		}
+
+		err = demoteReviewWithTx(tx, review.ID, survivor.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Model(&entities.Comment{}).Where("place_id = ?", fromPlaceID).Update("place_id", toPlaceID).Error
+}
+
+// demoteReviewWithTx turns a review into an unrated reply to another one. Its replies move
+// along, an official response among them stays as a plain reply.
+func demoteReviewWithTx(
+	tx *gorm.DB,
+	reviewID int,
+	toReviewID int,
+) error {
+	err := tx.Unscoped().Model(&entities.Comment{}).
+		Where("parent_comment_id = ?", reviewID).
+		UpdateColumns(map[string]interface{}{
+			"parent_comment_id": toReviewID,
+			"is_official":       false,
+		}).Error
+	if err != nil {
+		return err
+	}
+
+	return tx.Model(&entities.Comment{}).
+		Where("id = ?", reviewID).
+		UpdateColumns(map[string]interface{}{
+			"parent_comment_id": toReviewID,
+			"rate":              0,
+		}).Error
+}
+
+func (r *placeRepository) FindDaysByPlaceIDWithTx(
+	tx *gorm.DB,
+	placeID int,
+) ([]entities.Day, error) {
+	var days []entities.Day
+	err := tx.Where("places LIKE ?", fmt.Sprintf(`%%"id":%d,%%`, placeID)).Find(&days).Error
+	if err != nil {
+		return []entities.Day{}, err
+	}
+
+	// the LIKE above also matches nested ids (e.g. categories), keep only days really containing the place
+	var result []entities.Day
+	for _, day := range days {
+		for _, place := range day.PlacesJson {
+			if place.ID == placeID {
+				result = append(result, day)
+				break
+			}
+		}
+	}
+
+	return result, nil
+}
+
+func (r *placeRepository) UpdateDayPlacesWithTx(
+	tx *gorm.DB,
+	day entities.Day,
+	places string,
+) error {
+	return tx.Model(&day).UpdateColumn("places", places).Error
+}
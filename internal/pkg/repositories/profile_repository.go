@@ -0,0 +1,280 @@
+package repositories
+
+import (
+	"context"
+	"go-server/internal/pkg/domains/interfaces"
+	"go-server/internal/pkg/domains/models/dtos"
+	"go-server/internal/pkg/domains/models/entities"
+	"go-server/pkg/shared/database"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type profileRepository struct {
+	db     *gorm.DB
+	logger *logrus.Logger
+}
+
+func NewProfileRepository(
+	db *gorm.DB,
+	logger *logrus.Logger,
+) interfaces.ProfileRepository {
+	return &profileRepository{
+		db,
+		logger,
+	}
+}
+
+func (r *profileRepository) TakeActiveUser(
+	ctx context.Context,
+	userID int,
+) (entities.User, error) {
+	cdb := r.db.WithContext(ctx)
+
+	var user entities.User
+	err := cdb.Where("id = ? AND active = ?", userID, true).Take(&user).Error
+	return user, err
+}
+
+// CountReviews counts the published reviews, replies are left out
+func (r *profileRepository) CountReviews(
+	ctx context.Context,
+	userID int,
+) (int64, error) {
+	cdb := r.db.WithContext(ctx)
+
+	var count int64
+	err := cdb.Model(&entities.Comment{}).
+		Where("user_id = ? AND parent_comment_id IS NULL AND status = ?", userID, entities.CommentStatusPublished).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *profileRepository) FindPublicTrips(
+	ctx context.Context,
+	userID int,
+) ([]entities.Trip, error) {
+	cdb := r.db.WithContext(ctx)
+
+	var trips []entities.Trip
+	err := cdb.Preload("Days").
+		Where("owner = ? AND is_public = ?", userID, true).
+		Order("created_at DESC").
+		Find(&trips).Error
+	return trips, err
+}
+
+func (r *profileRepository) Follow(
+	ctx context.Context,
+	followerID int,
+	followeeID int,
+) error {
+	cdb := r.db.WithContext(ctx)
+
+	return cdb.Clauses(clause.OnConflict{DoNothing: true}).Create(&entities.Follow{
+		FollowerID: followerID,
+		FolloweeID: followeeID,
+	}).Error
+}
+
+func (r *profileRepository) Unfollow(
+	ctx context.Context,
+	followerID int,
+	followeeID int,
+) error {
+	cdb := r.db.WithContext(ctx)
+
+	return cdb.Unscoped().
+		Where("follower_id = ? AND followee_id = ?", followerID, followeeID).
+		Delete(&entities.Follow{}).Error
+}
+
+func (r *profileRepository) IsFollowing(
+	ctx context.Context,
+	followerID int,
+	followeeID int,
+) (bool, error) {
+	cdb := r.db.WithContext(ctx)
+
+	var count int64
+	err := cdb.Model(&entities.Follow{}).
+		Where("follower_id = ? AND followee_id = ?", followerID, followeeID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *profileRepository) CountFollowers(
+	ctx context.Context,
+	userID int,
+) (int64, error) {
+	cdb := r.db.WithContext(ctx)
+
+	var count int64
+	err := r.followers(cdb, userID).Count(&count).Error
+	return count, err
+}
+
+func (r *profileRepository) CountFollowing(
+	ctx context.Context,
+	userID int,
+) (int64, error) {
+	cdb := r.db.WithContext(ctx)
+
+	var count int64
+	err := r.following(cdb, userID).Count(&count).Error
+	return count, err
+}
+
+func (r *profileRepository) FindFollowersPaginate(
+	ctx context.Context,
+	userID int,
+	pageData map[string]int,
+) ([]entities.User, int64, error) {
+	cdb := r.db.WithContext(ctx)
+
+	var count int64
+	err := r.followers(cdb, userID).Count(&count).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var users []entities.User
+	err = r.followers(cdb, userID).
+		Scopes(database.Pagination(pageData)).
+		Order("follows.created_at DESC").
+		Find(&users).Error
+	return users, count, err
+}
+
+func (r *profileRepository) FindFollowingPaginate(
+	ctx context.Context,
+	userID int,
+	pageData map[string]int,
+) ([]entities.User, int64, error) {
+	cdb := r.db.WithContext(ctx)
+
+	var count int64
+	err := r.following(cdb, userID).Count(&count).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var users []entities.User
+	err = r.following(cdb, userID).
+		Scopes(database.Pagination(pageData)).
+		Order("follows.created_at DESC").
+		Find(&users).Error
+	return users, count, err
+}
+
+// followers are the active users following the user
+func (r *profileRepository) followers(cdb *gorm.DB, userID int) *gorm.DB {
+	return cdb.Model(&entities.User{}).
+		Joins("JOIN follows ON follows.follower_id = users.id AND follows.deleted_at IS NULL").
+		Where("follows.followee_id = ? AND users.active = ?", userID, true)
+}
+
+// following are the active users the user follows
+func (r *profileRepository) following(cdb *gorm.DB, userID int) *gorm.DB {
+	return cdb.Model(&entities.User{}).
+		Joins("JOIN follows ON follows.followee_id = users.id AND follows.deleted_at IS NULL").
+		Where("follows.follower_id = ? AND users.active = ?", userID, true)
+}
+
+func (r *profileRepository) FindFeedTrips(
+	ctx context.Context,
+	followerID int,
+	cursor *dtos.FeedCursor,
+	limit int,
+) ([]entities.Trip, error) {
+	cdb := r.db.WithContext(ctx)
+
+	query := cdb.Preload("Days").
+		Where("owner IN (?)", r.followees(cdb, followerID)).
+		Where("is_public = ?", true)
+	query = afterFeedCursor(query, dtos.FeedItemTrip, cursor)
+
+	var trips []entities.Trip
+	err := query.Order("created_at DESC, id DESC").Limit(limit).Find(&trips).Error
+	return trips, err
+}
+
+func (r *profileRepository) FindFeedReviews(
+	ctx context.Context,
+	followerID int,
+	cursor *dtos.FeedCursor,
+	limit int,
+) ([]entities.Comment, error) {
+	cdb := r.db.WithContext(ctx)
+
+	query := cdb.Preload("Place").Preload("Images").
+		Where("user_id IN (?)", r.followees(cdb, followerID)).
+		Where("parent_comment_id IS NULL AND status = ?", entities.CommentStatusPublished)
+	query = afterFeedCursor(query, dtos.FeedItemReview, cursor)
+
+	var comments []entities.Comment
+	err := query.Order("created_at DESC, id DESC").Limit(limit).Find(&comments).Error
+	if err != nil || len(comments) == 0 {
+		return comments, err
+	}
+
+	// Comment.AfterFind shifts created_at to the time zone of the app, the feed is merged and
+	// paginated on the stored one which the cursor compares against
+	ids := make([]int, 0, len(comments))
+	for _, comment := range comments {
+		ids = append(ids, comment.ID)
+	}
+	var stored []struct {
+		ID        int
+		CreatedAt time.Time
+	}
+	err = cdb.Model(&entities.Comment{}).Select("id, created_at").Where("id IN ?", ids).Scan(&stored).Error
+	if err != nil {
+		return nil, err
+	}
+	createdAt := make(map[int]time.Time, len(stored))
+	for _, row := range stored {
+		createdAt[row.ID] = row.CreatedAt
+	}
+	for i := range comments {
+		storedAt := createdAt[comments[i].ID]
+		comments[i].CreatedAt = &storedAt
+	}
+
+	return comments, nil
+}
+
+func (r *profileRepository) followees(cdb *gorm.DB, followerID int) *gorm.DB {
+	return cdb.Model(&entities.Follow{}).Select("followee_id").Where("follower_id = ?", followerID)
+}
+
+// afterFeedCursor keeps the items of the type which come after the cursor in the feed order,
+// created_at descending then type then id descending
+func afterFeedCursor(query *gorm.DB, itemType string, cursor *dtos.FeedCursor) *gorm.DB {
+	if cursor == nil {
+		return query
+	}
+
+	createdAt := time.Unix(cursor.CreatedAt, 0)
+	switch {
+	case itemType > cursor.Type:
+		return query.Where("created_at <= ?", createdAt)
+	case itemType == cursor.Type:
+		return query.Where("created_at < ? OR (created_at = ? AND id < ?)", createdAt, createdAt, cursor.ID)
+	}
+	return query.Where("created_at < ?", createdAt)
+}
+
+func (r *profileRepository) FindUsersByIDs(
+	ctx context.Context,
+	userIDs []int,
+) ([]entities.User, error) {
+	cdb := r.db.WithContext(ctx)
+
+	var users []entities.User
+	err := cdb.Where("id IN ?", userIDs).Find(&users).Error
+	return users, err
+}
@@ -0,0 +1,90 @@
+package repositories
+
+import (
+	"context"
+	"go-server/internal/pkg/domains/interfaces"
+	"go-server/internal/pkg/domains/models/entities"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type roleRepository struct {
+	db     *gorm.DB
+	logger *logrus.Logger
+}
+
+func NewRoleRepository(
+	db *gorm.DB,
+	logger *logrus.Logger,
+) interfaces.RoleRepository {
+	return &roleRepository{
+		db,
+		logger,
+	}
+}
+
+func (r *roleRepository) FindAll(
+	ctx context.Context,
+) ([]entities.Role, error) {
+	cdb := r.db.WithContext(ctx)
+
+	var roles []entities.Role
+	err := cdb.Preload("Permissions").Order("id ASC").Find(&roles).Error
+	return roles, err
+}
+
+func (r *roleRepository) FindByNames(
+	ctx context.Context,
+	names []string,
+) ([]entities.Role, error) {
+	cdb := r.db.WithContext(ctx)
+
+	var roles []entities.Role
+	err := cdb.Where("name IN ?", names).Find(&roles).Error
+	return roles, err
+}
+
+func (r *roleRepository) FindByUser(
+	ctx context.Context,
+	userID int,
+) ([]entities.Role, error) {
+	cdb := r.db.WithContext(ctx)
+
+	var roles []entities.Role
+	err := cdb.Preload("Permissions").
+		Joins("JOIN user_roles ON user_roles.role_id = roles.id").
+		Where("user_roles.user_id = ?", userID).
+		Order("roles.id ASC").
+		Find(&roles).Error
+	return roles, err
+}
+
+// LockUserIDsWithRoleWithTx returns the active users having the role, their rows stay locked
+// until the end of tx so that concurrent changes of these users wait for it
+func (r *roleRepository) LockUserIDsWithRoleWithTx(
+	tx *gorm.DB,
+	roleName string,
+) ([]int, error) {
+	var userIDs []int
+	err := tx.Model(&entities.User{}).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Joins("JOIN user_roles ON user_roles.user_id = users.id").
+		Joins("JOIN roles ON roles.id = user_roles.role_id").
+		Where("roles.name = ? AND users.active = ?", roleName, true).
+		Pluck("users.id", &userIDs).Error
+	return userIDs, err
+}
+
+func (r *roleRepository) ReplaceUserRolesWithTx(
+	tx *gorm.DB,
+	user entities.User,
+	roles []entities.Role,
+) error {
+	if len(roles) == 0 {
+		return tx.Model(&user).Association("Roles").Clear()
+	}
+
+	return tx.Model(&user).Association("Roles").Replace(roles)
+}
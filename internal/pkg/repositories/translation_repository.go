@@ -0,0 +1,107 @@
+package repositories
+
+import (
+	"context"
+	"go-server/internal/pkg/domains/interfaces"
+	"go-server/internal/pkg/domains/models/entities"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type translationRepository struct {
+	db     *gorm.DB
+	logger *logrus.Logger
+}
+
+func NewTranslationRepository(
+	db *gorm.DB,
+	logger *logrus.Logger,
+) interfaces.TranslationRepository {
+	return &translationRepository{
+		db,
+		logger,
+	}
+}
+
+// ReplaceWithTx hard deletes the current translations of the entity, so the unique
+// (entity, locale, field) index is free again, and stores the given ones.
+func (r *translationRepository) ReplaceWithTx(
+	tx *gorm.DB,
+	entityType string,
+	entityID int,
+	translations []entities.Translation,
+) error {
+	err := tx.Unscoped().Where("entity_type = ? AND entity_id = ?", entityType, entityID).Delete(&entities.Translation{}).Error
+	if err != nil {
+		return err
+	}
+
+	if len(translations) == 0 {
+		return nil
+	}
+
+	for i := range translations {
+		translations[i].EntityType = entityType
+		translations[i].EntityID = entityID
+	}
+
+	return tx.Create(&translations).Error
+}
+
+// MergeWithTx moves the translations of an entity to another one of the same type, the
+// values the target already has win. The translations of the source are hard deleted
+func (r *translationRepository) MergeWithTx(
+	tx *gorm.DB,
+	entityType string,
+	fromEntityID int,
+	toEntityID int,
+) error {
+	var translations []entities.Translation
+	err := tx.Where("entity_type = ? AND entity_id IN ?", entityType, []int{fromEntityID, toEntityID}).
+		Find(&translations).Error
+	if err != nil {
+		return err
+	}
+
+	merged := make(map[string]entities.Translation)
+	for _, translation := range translations {
+		if translation.Value == "" {
+			continue
+		}
+		key := translation.Locale + "|" + translation.Field
+		if kept, ok := merged[key]; ok && kept.EntityID == toEntityID {
+			continue
+		}
+		merged[key] = translation
+	}
+
+	err = tx.Unscoped().Where("entity_type = ? AND entity_id = ?", entityType, fromEntityID).Delete(&entities.Translation{}).Error
+	if err != nil {
+		return err
+	}
+
+	kept := make([]entities.Translation, 0, len(merged))
+	for _, translation := range merged {
+		kept = append(kept, entities.Translation{
+			Locale: translation.Locale,
+			Field:  translation.Field,
+			Value:  translation.Value,
+		})
+	}
+
+	return r.ReplaceWithTx(tx, entityType, toEntityID, kept)
+}
+
+func (r *translationRepository) FindByEntities(
+	ctx context.Context,
+	entityType string,
+	entityIDs []int,
+) ([]entities.Translation, error) {
+	cdb := r.db.WithContext(ctx)
+
+	var translations []entities.Translation
+	err := cdb.Where("entity_type = ? AND entity_id IN ?", entityType, entityIDs).Find(&translations).Error
+
+	return translations, err
+}
@@ -0,0 +1,44 @@
+package repositories
+
+import (
+	"context"
+	"go-server/internal/pkg/domains/interfaces"
+	"go-server/internal/pkg/domains/models/entities"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type userIdentityRepository struct {
+	db     *gorm.DB
+	logger *logrus.Logger
+}
+
+func NewUserIdentityRepository(
+	db *gorm.DB,
+	logger *logrus.Logger,
+) interfaces.UserIdentityRepository {
+	return &userIdentityRepository{
+		db,
+		logger,
+	}
+}
+
+func (r *userIdentityRepository) TakeByConditions(
+	ctx context.Context,
+	conditions map[string]interface{},
+) (entities.UserIdentity, error) {
+	cdb := r.db.WithContext(ctx)
+
+	var identity entities.UserIdentity
+	err := cdb.Where(conditions).Take(&identity).Error
+	return identity, err
+}
+
+func (r *userIdentityRepository) CreateWithTx(
+	tx *gorm.DB,
+	identity entities.UserIdentity,
+) (entities.UserIdentity, error) {
+	err := tx.Create(&identity).Error
+	return identity, err
+}
@@ -0,0 +1,84 @@
+package repositories
+
+import (
+	"context"
+	"go-server/internal/pkg/domains/interfaces"
+	"go-server/internal/pkg/domains/models/entities"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type userRecoveryCodeRepository struct {
+	db     *gorm.DB
+	logger *logrus.Logger
+}
+
+func NewUserRecoveryCodeRepository(
+	db *gorm.DB,
+	logger *logrus.Logger,
+) interfaces.UserRecoveryCodeRepository {
+	return &userRecoveryCodeRepository{
+		db,
+		logger,
+	}
+}
+
+// ReplaceWithTx drops the codes of the user and stores the new ones
+func (r *userRecoveryCodeRepository) ReplaceWithTx(
+	tx *gorm.DB,
+	userID int,
+	codeHashes []string,
+) error {
+	err := r.DeleteByUserWithTx(tx, userID)
+	if err != nil {
+		return err
+	}
+
+	codes := make([]entities.UserRecoveryCode, len(codeHashes))
+	for i, hash := range codeHashes {
+		codes[i] = entities.UserRecoveryCode{
+			UserID:   userID,
+			CodeHash: hash,
+		}
+	}
+
+	return tx.Create(&codes).Error
+}
+
+func (r *userRecoveryCodeRepository) DeleteByUserWithTx(
+	tx *gorm.DB,
+	userID int,
+) error {
+	return tx.Unscoped().Where("user_id = ?", userID).Delete(&entities.UserRecoveryCode{}).Error
+}
+
+// Use consumes the code, false when the user has no such unused code
+func (r *userRecoveryCodeRepository) Use(
+	ctx context.Context,
+	userID int,
+	codeHash string,
+) (bool, error) {
+	cdb := r.db.WithContext(ctx)
+
+	result := cdb.Model(&entities.UserRecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Limit(1).
+		Update("used_at", time.Now())
+
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *userRecoveryCodeRepository) CountUnused(
+	ctx context.Context,
+	userID int,
+) (int64, error) {
+	cdb := r.db.WithContext(ctx)
+
+	var count int64
+	err := cdb.Model(&entities.UserRecoveryCode{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Count(&count).Error
+	return count, err
+}
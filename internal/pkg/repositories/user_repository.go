@@ -34,6 +34,12 @@ func (r *userRepository) Create(ctx context.Context, user entities.User) (entiti
 	return user, err
 }
 
+func (r *userRepository) CreateWithTx(tx *gorm.DB, user entities.User) (entities.User, error) {
+	err := tx.Create(&user).Error
+
+	return user, err
+}
+
 func (r *userRepository) TakeByConditions(ctx context.Context, conditions map[string]interface{}) (entities.User, error) {
 	cdb := r.db.WithContext(ctx)
 
@@ -64,3 +70,56 @@ func (r *userRepository) Update(
 
 	return user, err
 }
+
+func (r *userRepository) UpdateColumns(
+	ctx context.Context,
+	user entities.User,
+	columns map[string]interface{},
+) (entities.User, error) {
+	cdb := r.db.WithContext(ctx)
+
+	err := cdb.Model(&user).Updates(columns).Error
+	return user, err
+}
+
+func (r *userRepository) UpdateColumnsWithTx(
+	tx *gorm.DB,
+	user entities.User,
+	columns map[string]interface{},
+) (entities.User, error) {
+	err := tx.Model(&user).Updates(columns).Error
+	return user, err
+}
+
+// AdvanceTOTPStep records the time step of an accepted code, false when the step or a later
+// one is already recorded so that concurrent requests cannot both accept the same code
+func (r *userRepository) AdvanceTOTPStep(
+	ctx context.Context,
+	userID int,
+	step int64,
+) (bool, error) {
+	cdb := r.db.WithContext(ctx)
+
+	result := cdb.Model(&entities.User{}).
+		Where("id = ? AND totp_last_step < ?", userID, step).
+		Update("totp_last_step", step)
+	return result.RowsAffected > 0, result.Error
+}
+
+// ConsumeTwoFactorChallenge clears the pending login challenge and the failed codes, false
+// when the challenge was already used or replaced
+func (r *userRepository) ConsumeTwoFactorChallenge(
+	ctx context.Context,
+	userID int,
+	challengeHash string,
+) (bool, error) {
+	cdb := r.db.WithContext(ctx)
+
+	result := cdb.Model(&entities.User{}).
+		Where("id = ? AND two_factor_challenge_hash = ?", userID, challengeHash).
+		Updates(map[string]interface{}{
+			"two_factor_challenge_hash": "",
+			"two_factor_failures":       0,
+		})
+	return result.RowsAffected > 0, result.Error
+}
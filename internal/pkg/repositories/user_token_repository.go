@@ -0,0 +1,118 @@
+package repositories
+
+import (
+	"context"
+	"go-server/internal/pkg/domains/interfaces"
+	"go-server/internal/pkg/domains/models/entities"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type userTokenRepository struct {
+	db     *gorm.DB
+	logger *logrus.Logger
+}
+
+func NewUserTokenRepository(
+	db *gorm.DB,
+	logger *logrus.Logger,
+) interfaces.UserTokenRepository {
+	return &userTokenRepository{
+		db,
+		logger,
+	}
+}
+
+func (r *userTokenRepository) Create(
+	ctx context.Context,
+	token entities.UserToken,
+) (entities.UserToken, error) {
+	cdb := r.db.WithContext(ctx)
+
+	err := cdb.Create(&token).Error
+	return token, err
+}
+
+func (r *userTokenRepository) CreateWithTx(
+	tx *gorm.DB,
+	token entities.UserToken,
+) (entities.UserToken, error) {
+	err := tx.Create(&token).Error
+	return token, err
+}
+
+func (r *userTokenRepository) TakeByConditions(
+	ctx context.Context,
+	conditions map[string]interface{},
+) (entities.UserToken, error) {
+	cdb := r.db.WithContext(ctx)
+
+	var token entities.UserToken
+	err := cdb.Where(conditions).Take(&token).Error
+	return token, err
+}
+
+// FindActiveByUser lists the live sessions of the user, most recently used first
+func (r *userTokenRepository) FindActiveByUser(
+	ctx context.Context,
+	userID int,
+) ([]entities.UserToken, error) {
+	cdb := r.db.WithContext(ctx)
+
+	var tokens []entities.UserToken
+	err := cdb.Where("user_id = ? AND rotated_at IS NULL", userID).
+		Order("COALESCE(last_used_at, created_at) DESC").
+		Find(&tokens).Error
+	return tokens, err
+}
+
+// MarkRotatedWithTx marks the token as used, false when it was already rotated concurrently
+func (r *userTokenRepository) MarkRotatedWithTx(
+	tx *gorm.DB,
+	token entities.UserToken,
+) (bool, error) {
+	result := tx.Model(&entities.UserToken{}).
+		Where("id = ? AND rotated_at IS NULL", token.ID).
+		Update("rotated_at", time.Now())
+
+	return result.RowsAffected == 1, result.Error
+}
+
+// FindTokenIDsOfFamily lists the access token ids issued to the session, rotated ones included
+func (r *userTokenRepository) FindTokenIDsOfFamily(
+	ctx context.Context,
+	familyID string,
+) ([]string, error) {
+	cdb := r.db.WithContext(ctx)
+
+	var tokenIDs []string
+	err := cdb.Model(&entities.UserToken{}).Where("family_id = ?", familyID).Pluck("token_id", &tokenIDs).Error
+	return tokenIDs, err
+}
+
+func (r *userTokenRepository) DeleteFamily(
+	ctx context.Context,
+	familyID string,
+) error {
+	cdb := r.db.WithContext(ctx)
+
+	return cdb.Where("family_id = ?", familyID).Delete(&entities.UserToken{}).Error
+}
+
+func (r *userTokenRepository) DeleteByConditions(
+	ctx context.Context,
+	conditions map[string]interface{},
+) error {
+	cdb := r.db.WithContext(ctx)
+
+	return cdb.Where(conditions).Delete(&entities.UserToken{}).Error
+}
+
+func (r *userTokenRepository) DeleteByConditionsWithTx(
+	tx *gorm.DB,
+	conditions map[string]interface{},
+) error {
+	return tx.Where(conditions).Delete(&entities.UserToken{}).Error
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go-server/internal/pkg/domains/models/dtos"
+	"net/http"
+
+	"golang.org/x/oauth2"
+)
+
+const GoogleProvider = "google"
+
+type OAuthProviderInterface interface {
+	AuthCodeURL(state string) string
+	FetchIdentity(ctx context.Context, code string) (dtos.OAuthIdentityDto, error)
+}
+
+// GoogleOAuthService exchanges the code of the google callback and reads the account of the
+// userinfo endpoint. Both the oauth2 endpoints and userInfoURL are injected so that a local
+// fake server can stand in for google
+type GoogleOAuthService struct {
+	config      *oauth2.Config
+	userInfoURL string
+}
+
+type googleUserInfo struct {
+	ID            string `json:"id"`
+	Email         string `json:"email"`
+	VerifiedEmail bool   `json:"verified_email"`
+	Name          string `json:"name"`
+	Picture       string `json:"picture"`
+}
+
+func NewGoogleOAuthService(config *oauth2.Config, userInfoURL string) *GoogleOAuthService {
+	return &GoogleOAuthService{
+		config:      config,
+		userInfoURL: userInfoURL,
+	}
+}
+
+func (s *GoogleOAuthService) AuthCodeURL(state string) string {
+	return s.config.AuthCodeURL(state)
+}
+
+func (s *GoogleOAuthService) FetchIdentity(ctx context.Context, code string) (dtos.OAuthIdentityDto, error) {
+	token, err := s.config.Exchange(ctx, code)
+	if err != nil {
+		return dtos.OAuthIdentityDto{}, err
+	}
+
+	response, err := s.config.Client(ctx, token).Get(s.userInfoURL)
+	if err != nil {
+		return dtos.OAuthIdentityDto{}, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return dtos.OAuthIdentityDto{}, fmt.Errorf("google userinfo responded %d", response.StatusCode)
+	}
+
+	var info googleUserInfo
+	err = json.NewDecoder(response.Body).Decode(&info)
+	if err != nil {
+		return dtos.OAuthIdentityDto{}, err
+	}
+	if info.ID == "" {
+		return dtos.OAuthIdentityDto{}, fmt.Errorf("google userinfo has no id")
+	}
+
+	return dtos.OAuthIdentityDto{
+		Provider:      GoogleProvider,
+		Subject:       info.ID,
+		Email:         info.Email,
+		EmailVerified: info.VerifiedEmail,
+		TrustEmail:    true, // google owns the emails it verifies, gmail and workspace ones alike
+		Name:          info.Name,
+		Avatar:        info.Picture,
+	}, nil
+}
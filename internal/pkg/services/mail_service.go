@@ -2,6 +2,7 @@ package services
 
 import (
 	"bytes"
+	"fmt"
 	"html/template"
 	"os"
 	"path/filepath"
@@ -11,7 +12,7 @@ import (
 )
 
 type MailServiceInterface interface {
-	SendOneTimePasswordMail(templateName string, data map[string]interface{}) error
+	SendMail(templateName string, subject string, data map[string]interface{}) error
 }
 
 type MailService struct {
@@ -85,18 +86,24 @@ func (m *MailService) loadDirTemplates(path string) error {
 	return nil
 }
 
-func (m *MailService) SendOneTimePasswordMail(templateName string, data map[string]interface{}) error {
+// SendMail renders the template with data and sends it to data["to"]
+func (m *MailService) SendMail(templateName string, subject string, data map[string]interface{}) error {
 	mail := gomail.NewMessage()
 
+	tmpl, ok := m.templates[templateName]
+	if !ok {
+		return fmt.Errorf("mail template %s not found", templateName)
+	}
+
 	var body bytes.Buffer
-	err := m.templates[templateName].Execute(&body, data)
+	err := tmpl.Execute(&body, data)
 	if err != nil {
 		return err
 	}
 
 	mail.SetHeader("From", os.Getenv("EMAIL_ACCOUNT"))
 	mail.SetHeader("To", data["to"].(string))
-	mail.SetHeader("Subject", "Password Change Notification")
+	mail.SetHeader("Subject", subject)
 	mail.SetBody("text/html", body.String())
 
 	d := gomail.NewDialer("smtp.gmail.com", 587, os.Getenv("EMAIL_ACCOUNT"), os.Getenv("EMAIL_PASS"))
@@ -0,0 +1,318 @@
+package services
+
+import (
+	"context"
+	"crypto"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-server/internal/pkg/domains/models/dtos"
+	"go-server/pkg/shared/auth"
+	"go-server/pkg/shared/utils"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/oauth2"
+)
+
+// jwksRefreshInterval limits the refetches of the keys of a provider on an unknown kid
+const jwksRefreshInterval = time.Minute
+
+var (
+	ErrOIDCIDTokenMissing = errors.New("token response has no id_token")
+	ErrOIDCNonceMismatch  = errors.New("id_token nonce mismatch")
+	ErrOIDCKeyNotFound    = errors.New("id_token signing key not found")
+)
+
+var idTokenSigningMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}
+
+// OIDCProviderConfig is a provider of the OIDC_PROVIDERS list (e.g. "partner1,partner2"), read
+// from the OIDC_<NAME>_ISSUER, _CLIENT_ID, _CLIENT_SECRET, _REDIRECT_URL, _SCOPES and _TRUST_EMAIL
+// variables. TrustEmail, off by default, lets the emails verified by the provider log into the
+// existing accounts of these emails: only for a provider that owns the domains of its users
+type OIDCProviderConfig struct {
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+	TrustEmail   bool
+}
+
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// OIDCProvider logs users in with an OpenID Connect provider using the authorization code flow
+// with PKCE. The endpoints are discovered from the issuer on first use and the id_tokens are
+// verified against the keys of its JWKS
+type OIDCProvider struct {
+	config     OIDCProviderConfig
+	httpClient *http.Client
+
+	mu            sync.Mutex
+	discovery     *oidcDiscovery
+	keys          map[string]crypto.PublicKey
+	keysFetchedAt time.Time
+}
+
+func NewOIDCProvider(config OIDCProviderConfig, httpClient *http.Client) *OIDCProvider {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	return &OIDCProvider{
+		config:     config,
+		httpClient: httpClient,
+	}
+}
+
+// NewOIDCProvidersFromEnv builds the providers of OIDC_PROVIDERS by name, the ones without
+// issuer or client id are skipped
+func NewOIDCProvidersFromEnv() map[string]*OIDCProvider {
+	providers := make(map[string]*OIDCProvider)
+	appURL := strings.TrimRight(os.Getenv("APP_URL"), "/")
+
+	for _, name := range strings.Split(os.Getenv("OIDC_PROVIDERS"), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		prefix := "OIDC_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+		config := OIDCProviderConfig{
+			Name:         name,
+			Issuer:       os.Getenv(prefix + "ISSUER"),
+			ClientID:     os.Getenv(prefix + "CLIENT_ID"),
+			ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+			RedirectURL:  os.Getenv(prefix + "REDIRECT_URL"),
+			Scopes:       strings.Fields(os.Getenv(prefix + "SCOPES")),
+			TrustEmail:   utils.BoolFromEnv(prefix+"TRUST_EMAIL", false),
+		}
+		if config.Issuer == "" || config.ClientID == "" {
+			continue
+		}
+		if config.RedirectURL == "" {
+			config.RedirectURL = appURL + "/api/auth/oidc/" + name + "/callback"
+		}
+		if len(config.Scopes) == 0 {
+			config.Scopes = []string{"openid", "email", "profile"}
+		}
+
+		providers[name] = NewOIDCProvider(config, nil)
+	}
+
+	return providers
+}
+
+// AuthCodeURL is the authorization url of the provider, codeVerifier is the PKCE verifier
+// that FetchIdentity will need
+func (p *OIDCProvider) AuthCodeURL(ctx context.Context, state, nonce, codeVerifier string) (string, error) {
+	config, err := p.oauth2Config(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return config.AuthCodeURL(
+		state,
+		oauth2.S256ChallengeOption(codeVerifier),
+		oauth2.SetAuthURLParam("nonce", nonce),
+	), nil
+}
+
+// FetchIdentity exchanges the code and maps the claims of the verified id_token
+func (p *OIDCProvider) FetchIdentity(ctx context.Context, code, codeVerifier, nonce string) (dtos.OAuthIdentityDto, error) {
+	config, err := p.oauth2Config(ctx)
+	if err != nil {
+		return dtos.OAuthIdentityDto{}, err
+	}
+
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, p.httpClient)
+	token, err := config.Exchange(ctx, code, oauth2.VerifierOption(codeVerifier))
+	if err != nil {
+		return dtos.OAuthIdentityDto{}, err
+	}
+
+	idToken, _ := token.Extra("id_token").(string)
+	if idToken == "" {
+		return dtos.OAuthIdentityDto{}, ErrOIDCIDTokenMissing
+	}
+
+	claims, err := p.verifyIDToken(ctx, idToken)
+	if err != nil {
+		return dtos.OAuthIdentityDto{}, err
+	}
+	if claims["nonce"] != nonce {
+		return dtos.OAuthIdentityDto{}, ErrOIDCNonceMismatch
+	}
+
+	identity := dtos.OAuthIdentityDto{
+		Provider:   "oidc:" + p.config.Name,
+		TrustEmail: p.config.TrustEmail,
+	}
+	identity.Subject, _ = claims["sub"].(string)
+	identity.Email, _ = claims["email"].(string)
+	identity.Name, _ = claims["name"].(string)
+	identity.Avatar, _ = claims["picture"].(string)
+	// some providers send the boolean as a string
+	switch verified := claims["email_verified"].(type) {
+	case bool:
+		identity.EmailVerified = verified
+	case string:
+		identity.EmailVerified = verified == "true"
+	}
+	if identity.Subject == "" {
+		return dtos.OAuthIdentityDto{}, fmt.Errorf("id_token has no sub")
+	}
+
+	return identity, nil
+}
+
+func (p *OIDCProvider) verifyIDToken(ctx context.Context, idToken string) (jwt.MapClaims, error) {
+	discovery, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := jwt.MapClaims{}
+	_, err = jwt.ParseWithClaims(idToken, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return p.publicKey(ctx, discovery.JWKSURI, kid)
+	},
+		jwt.WithValidMethods(idTokenSigningMethods),
+		jwt.WithIssuer(discovery.Issuer),
+		jwt.WithAudience(p.config.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+// publicKey returns the key of kid, the JWKS is refetched when kid is unknown to follow the key
+// rotations of the provider. A token without kid is accepted when the provider has a single key
+func (p *OIDCProvider) publicKey(ctx context.Context, jwksURI string, kid string) (crypto.PublicKey, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	key, ok := p.lookupKey(kid)
+	if ok {
+		return key, nil
+	}
+	if time.Since(p.keysFetchedAt) < jwksRefreshInterval {
+		return nil, ErrOIDCKeyNotFound
+	}
+
+	var jwks auth.JWKS
+	err := p.getJSON(ctx, jwksURI, &jwks)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]crypto.PublicKey)
+	for _, jwk := range jwks.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.PublicKey()
+		if err != nil {
+			// keys of unsupported types are ignored
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+	p.keys = keys
+	p.keysFetchedAt = time.Now()
+
+	key, ok = p.lookupKey(kid)
+	if !ok {
+		return nil, ErrOIDCKeyNotFound
+	}
+
+	return key, nil
+}
+
+func (p *OIDCProvider) lookupKey(kid string) (crypto.PublicKey, bool) {
+	if kid == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key, true
+		}
+	}
+
+	key, ok := p.keys[kid]
+	return key, ok
+}
+
+func (p *OIDCProvider) oauth2Config(ctx context.Context) (*oauth2.Config, error) {
+	discovery, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &oauth2.Config{
+		ClientID:     p.config.ClientID,
+		ClientSecret: p.config.ClientSecret,
+		RedirectURL:  p.config.RedirectURL,
+		Scopes:       p.config.Scopes,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  discovery.AuthorizationEndpoint,
+			TokenURL: discovery.TokenEndpoint,
+		},
+	}, nil
+}
+
+// discover reads the openid configuration of the issuer, kept once it succeeded
+func (p *OIDCProvider) discover(ctx context.Context) (*oidcDiscovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+
+	var discovery oidcDiscovery
+	err := p.getJSON(ctx, strings.TrimRight(p.config.Issuer, "/")+"/.well-known/openid-configuration", &discovery)
+	if err != nil {
+		return nil, err
+	}
+	if discovery.Issuer != p.config.Issuer {
+		return nil, fmt.Errorf("discovered issuer %s does not match %s", discovery.Issuer, p.config.Issuer)
+	}
+	if discovery.AuthorizationEndpoint == "" || discovery.TokenEndpoint == "" || discovery.JWKSURI == "" {
+		return nil, fmt.Errorf("incomplete openid configuration of %s", p.config.Issuer)
+	}
+
+	p.discovery = &discovery
+	return p.discovery, nil
+}
+
+func (p *OIDCProvider) getJSON(ctx context.Context, url string, target interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	response, err := p.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s responded %d", url, response.StatusCode)
+	}
+
+	return json.NewDecoder(response.Body).Decode(target)
+}
@@ -0,0 +1,328 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"go-server/internal/pkg/domains/interfaces"
+	"go-server/internal/pkg/domains/models/dtos"
+	"go-server/internal/pkg/domains/models/entities"
+	"go-server/internal/pkg/services"
+	"go-server/pkg/shared/auth"
+	"go-server/pkg/shared/database"
+	"go-server/pkg/shared/realtime"
+	"go-server/pkg/shared/utils"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+var (
+	AccountUserNotFound = errors.New("User not found")
+
+	RequestDeletionAlreadyScheduled = errors.New("Account deletion is already scheduled")
+	ConfirmDeletionTokenInvalid     = errors.New("Deletion link invalid or expired")
+	CancelDeletionNotScheduled      = errors.New("Account deletion is not scheduled")
+)
+
+const (
+	accountDeletionPurpose = "account_deletion"
+	// purgeBatchSize bounds the accounts erased by one run of the purge job
+	purgeBatchSize = 100
+)
+
+// AccountDeletionGracePeriod is the time between the confirmation of a deletion and the erasure
+// of the account, during which it can be cancelled. Configured by ACCOUNT_DELETION_GRACE_PERIOD
+func AccountDeletionGracePeriod() time.Duration {
+	return utils.DurationFromEnv("ACCOUNT_DELETION_GRACE_PERIOD", 30*24*time.Hour)
+}
+
+type accountUsecase struct {
+	userRepo          interfaces.UserRepository
+	userTokenRepo     interfaces.UserTokenRepository
+	accountRepo       interfaces.AccountRepository
+	commentRepo       interfaces.CommentRepository
+	loginAttemptStore interfaces.LoginAttemptStore
+	mailService       services.MailServiceInterface
+	sessions          realtime.SessionCloser
+	logger            *logrus.Logger
+}
+
+func NewAccountUsecase(
+	userRepo interfaces.UserRepository,
+	userTokenRepo interfaces.UserTokenRepository,
+	accountRepo interfaces.AccountRepository,
+	commentRepo interfaces.CommentRepository,
+	loginAttemptStore interfaces.LoginAttemptStore,
+	mailService services.MailServiceInterface,
+	sessions realtime.SessionCloser,
+	logger *logrus.Logger,
+) interfaces.AccountUsecase {
+	return &accountUsecase{
+		userRepo,
+		userTokenRepo,
+		accountRepo,
+		commentRepo,
+		loginAttemptStore,
+		mailService,
+		sessions,
+		logger,
+	}
+}
+
+// Export gathers the personal data of the user, secrets such as the password hash are left out
+func (u *accountUsecase) Export(
+	ctx context.Context,
+	userID int,
+) (dtos.AccountExportDto, error) {
+	user, err := u.takeUser(ctx, userID)
+	if err != nil {
+		return dtos.AccountExportDto{}, err
+	}
+	user.Password = ""
+
+	export := dtos.AccountExportDto{
+		ExportedAt: time.Now().Unix(),
+		Profile:    user,
+	}
+	export.Trips, err = u.accountRepo.FindTrips(ctx, userID)
+	if err != nil {
+		return dtos.AccountExportDto{}, err
+	}
+	export.Comments, err = u.accountRepo.FindComments(ctx, userID)
+	if err != nil {
+		return dtos.AccountExportDto{}, err
+	}
+	export.CommentVotes, err = u.accountRepo.FindCommentVotes(ctx, userID)
+	if err != nil {
+		return dtos.AccountExportDto{}, err
+	}
+	export.CommentReports, err = u.accountRepo.FindCommentReports(ctx, userID)
+	if err != nil {
+		return dtos.AccountExportDto{}, err
+	}
+	export.Identities, err = u.accountRepo.FindIdentities(ctx, userID)
+	if err != nil {
+		return dtos.AccountExportDto{}, err
+	}
+	export.Following, err = u.accountRepo.FindFollows(ctx, userID)
+	if err != nil {
+		return dtos.AccountExportDto{}, err
+	}
+	export.Notifications, err = u.accountRepo.FindNotifications(ctx, userID)
+	if err != nil {
+		return dtos.AccountExportDto{}, err
+	}
+
+	tokens, err := u.userTokenRepo.FindActiveByUser(ctx, userID)
+	if err != nil {
+		return dtos.AccountExportDto{}, err
+	}
+	export.Sessions = make([]dtos.SessionDto, 0, len(tokens))
+	for _, token := range tokens {
+		export.Sessions = append(export.Sessions, sessionDto(token, ""))
+	}
+
+	return export, nil
+}
+
+// RequestDeletion mails the user a link confirming the deletion of their account,
+// so that a stolen session alone cannot delete it. A new request voids the previous links
+func (u *accountUsecase) RequestDeletion(
+	ctx context.Context,
+	userID int,
+) error {
+	user, err := u.takeUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.DeletionScheduledAt != nil {
+		return RequestDeletionAlreadyScheduled
+	}
+
+	nonce, nonceHash, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		return err
+	}
+	user, err = u.userRepo.UpdateColumns(ctx, user, map[string]interface{}{
+		"deletion_nonce_hash": nonceHash,
+	})
+	if err != nil {
+		return err
+	}
+
+	ttl := utils.DurationFromEnv("ACCOUNT_DELETION_LINK_TTL", 24*time.Hour)
+	token, _, err := auth.GenerateSignedToken(accountDeletionPurpose, map[string]interface{}{
+		"user_id": user.ID,
+		"email":   user.Email,
+		"nonce":   nonce,
+	}, ttl)
+	if err != nil {
+		return err
+	}
+
+	return u.mailService.SendMail("account_deletion_template.html", "Confirm the deletion of your account", map[string]interface{}{
+		"to":           user.Email,
+		"username":     user.Username,
+		"link":         appURL() + "/api/auth/confirm_deletion?token=" + url.QueryEscape(token),
+		"expires_in":   ttl.String(),
+		"grace_period": int(AccountDeletionGracePeriod().Hours() / 24),
+		"year":         time.Now().Year(),
+	})
+}
+
+// ConfirmDeletion schedules the erasure of the account of the token after the grace period
+// and mails the user how to cancel it. The link works once: confirming or cancelling the
+// deletion voids it
+func (u *accountUsecase) ConfirmDeletion(
+	ctx context.Context,
+	token string,
+) (entities.User, error) {
+	claims, err := auth.ParseSignedToken(accountDeletionPurpose, token)
+	if err != nil {
+		return entities.User{}, ConfirmDeletionTokenInvalid
+	}
+
+	userID, ok := claims["user_id"].(float64)
+	if !ok {
+		return entities.User{}, ConfirmDeletionTokenInvalid
+	}
+
+	user, err := u.userRepo.TakeByConditions(ctx, map[string]interface{}{
+		"id": int(userID),
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return entities.User{}, ConfirmDeletionTokenInvalid
+		}
+		return entities.User{}, err
+	}
+
+	nonce, _ := claims["nonce"].(string)
+	if claims["email"] != user.Email || nonce == "" || user.DeletionNonceHash != auth.HashToken(nonce) {
+		return entities.User{}, ConfirmDeletionTokenInvalid
+	}
+
+	scheduledAt := time.Now().Add(AccountDeletionGracePeriod())
+	user, err = u.userRepo.UpdateColumns(ctx, user, map[string]interface{}{
+		"deletion_nonce_hash":   "",
+		"deletion_scheduled_at": &scheduledAt,
+	})
+	if err != nil {
+		return entities.User{}, err
+	}
+	user.DeletionScheduledAt = &scheduledAt
+
+	cancelURL := os.Getenv("ACCOUNT_DELETION_CANCEL_URL")
+	if cancelURL == "" {
+		cancelURL = appURL() + "/account/deletion"
+	}
+	err = u.mailService.SendMail("account_deletion_scheduled_template.html", "Your account will be deleted", map[string]interface{}{
+		"to":           user.Email,
+		"username":     user.Username,
+		"scheduled_at": scheduledAt.Format("02/01/2006"),
+		"link":         cancelURL,
+		"year":         time.Now().Year(),
+	})
+	if err != nil {
+		// the deletion stands, the account info of the user still shows it
+		u.logger.Errorf("send deletion scheduled email to user %d failed: %v", user.ID, err)
+	}
+
+	return user, nil
+}
+
+func (u *accountUsecase) CancelDeletion(
+	ctx context.Context,
+	userID int,
+) error {
+	user, err := u.takeUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.DeletionScheduledAt == nil {
+		return CancelDeletionNotScheduled
+	}
+
+	_, err = u.userRepo.UpdateColumns(ctx, user, map[string]interface{}{
+		"deletion_nonce_hash":   "",
+		"deletion_scheduled_at": nil,
+	})
+	return err
+}
+
+// PurgeDeletedAccounts erases the accounts whose grace period is over, one transaction
+// per account so that a failure does not hold back the others. It returns the number erased
+func (u *accountUsecase) PurgeDeletedAccounts(
+	ctx context.Context,
+	db *gorm.DB,
+) (int, error) {
+	users, err := u.accountRepo.FindDueForDeletion(ctx, time.Now(), purgeBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	erased := 0
+	for _, user := range users {
+		err = u.erase(ctx, db, user)
+		if err != nil {
+			u.logger.Errorf("erase account of user %d failed: %v", user.ID, err)
+			continue
+		}
+		erased++
+	}
+
+	return erased, nil
+}
+
+func (u *accountUsecase) erase(
+	ctx context.Context,
+	db *gorm.DB,
+	user entities.User,
+) error {
+	votes, err := u.accountRepo.FindCommentVotes(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	err = database.Transaction(ctx, db, func(tx *gorm.DB) error {
+		err := u.accountRepo.EraseWithTx(tx, user)
+		if err != nil {
+			return err
+		}
+
+		for _, vote := range votes {
+			err = u.commentRepo.RefreshVoteCountsWithTx(tx, vote.CommentID)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	u.sessions.CloseUser(user.ID)
+
+	return u.loginAttemptStore.Reset(ctx, accountAttemptKey(user.Email))
+}
+
+func (u *accountUsecase) takeUser(
+	ctx context.Context,
+	userID int,
+) (entities.User, error) {
+	user, err := u.userRepo.TakeByConditions(ctx, map[string]interface{}{
+		"id": userID,
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return entities.User{}, AccountUserNotFound
+		}
+		return entities.User{}, err
+	}
+
+	return user, nil
+}
@@ -0,0 +1,214 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"go-server/internal/pkg/domains/interfaces"
+	"go-server/internal/pkg/domains/models/entities"
+	"go-server/internal/pkg/repositories"
+
+	"gorm.io/gorm"
+)
+
+// accountModels are the tables holding the data of a user, see AccountRepository.EraseWithTx
+var accountModels = []interface{}{
+	&entities.User{}, &entities.Role{}, &entities.UserToken{}, &entities.PasswordResetToken{},
+	&entities.UserIdentity{}, &entities.UserRecoveryCode{}, &entities.Place{}, &entities.Comment{},
+	&entities.CommentImage{}, &entities.CommentVote{}, &entities.CommentReport{}, &entities.ContentEvent{},
+	&entities.Trip{}, &entities.Day{}, &entities.UserTrip{}, &entities.Follow{}, &entities.Notification{},
+	&entities.NotificationPreference{},
+}
+
+func newTestAccountUsecase(t *testing.T) (interfaces.AccountUsecase, *fakeMailService, *fakeSessionCloser, *gorm.DB) {
+	t.Helper()
+
+	db := newTestDB(t, accountModels...)
+	logger := newTestLogger()
+	mailService := &fakeMailService{}
+	sessions := &fakeSessionCloser{}
+	usecase := NewAccountUsecase(
+		repositories.NewUserRepository(db, logger),
+		repositories.NewUserTokenRepository(db, logger),
+		repositories.NewAccountRepository(db, logger),
+		repositories.NewCommentRepository(db, logger),
+		repositories.NewMemoryLoginAttemptStore(),
+		mailService,
+		sessions,
+		logger,
+	)
+
+	return usecase, mailService, sessions, db
+}
+
+// deletionToken requests the deletion and returns the token of the emailed link
+func deletionToken(t *testing.T, usecase interfaces.AccountUsecase, mailService *fakeMailService, userID int) string {
+	t.Helper()
+
+	err := usecase.RequestDeletion(context.Background(), userID)
+	if err != nil {
+		t.Fatalf("request deletion: %v", err)
+	}
+	mail := mailService.sent[len(mailService.sent)-1]
+	link, err := url.Parse(mail.data["link"].(string))
+	if err != nil || mail.template != "account_deletion_template.html" {
+		t.Fatalf("unexpected mail %+v", mail)
+	}
+
+	return link.Query().Get("token")
+}
+
+func TestConfirmDeletionLinkWorksOnce(t *testing.T) {
+	usecase, mailService, _, db := newTestAccountUsecase(t)
+	user := entities.User{Username: "leaving", Email: "leaving@example.com", Password: "hash", Active: true}
+	db.Create(&user)
+
+	token := deletionToken(t, usecase, mailService, user.ID)
+	confirmed, err := usecase.ConfirmDeletion(context.Background(), token)
+	if err != nil || confirmed.DeletionScheduledAt == nil {
+		t.Fatalf("confirm returned %+v, %v", confirmed, err)
+	}
+	mail := mailService.sent[len(mailService.sent)-1]
+	if mail.template != "account_deletion_scheduled_template.html" || !strings.HasSuffix(mail.data["link"].(string), "/account/deletion") {
+		t.Fatalf("scheduled notice not sent with the cancel link: %+v", mail)
+	}
+
+	err = usecase.CancelDeletion(context.Background(), user.ID)
+	if err != nil {
+		t.Fatalf("cancel deletion: %v", err)
+	}
+
+	// the used link does not schedule the deletion again
+	_, err = usecase.ConfirmDeletion(context.Background(), token)
+	if !errors.Is(err, ConfirmDeletionTokenInvalid) {
+		t.Fatalf("got %v, want ConfirmDeletionTokenInvalid", err)
+	}
+	var stored entities.User
+	db.Take(&stored, user.ID)
+	if stored.DeletionScheduledAt != nil {
+		t.Fatal("deletion rescheduled by a used link")
+	}
+}
+
+func TestConfirmDeletionVoidedByNewRequest(t *testing.T) {
+	usecase, mailService, _, db := newTestAccountUsecase(t)
+	user := entities.User{Username: "leaving", Email: "leaving@example.com", Password: "hash", Active: true}
+	db.Create(&user)
+
+	first := deletionToken(t, usecase, mailService, user.ID)
+	second := deletionToken(t, usecase, mailService, user.ID)
+
+	_, err := usecase.ConfirmDeletion(context.Background(), first)
+	if !errors.Is(err, ConfirmDeletionTokenInvalid) {
+		t.Fatalf("link of a previous request: got %v, want ConfirmDeletionTokenInvalid", err)
+	}
+
+	err = usecase.CancelDeletion(context.Background(), user.ID)
+	if !errors.Is(err, CancelDeletionNotScheduled) {
+		t.Fatalf("got %v, want CancelDeletionNotScheduled", err)
+	}
+	_, err = usecase.ConfirmDeletion(context.Background(), second)
+	if err != nil {
+		t.Fatalf("pending link refused: %v", err)
+	}
+}
+
+func TestPurgeErasesDueAccounts(t *testing.T) {
+	usecase, _, sessions, db := newTestAccountUsecase(t)
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+	leaving := entities.User{Username: "leaving", Email: "leaving@example.com", Password: "hash", Active: true, DeletionScheduledAt: &past}
+	waiting := entities.User{Username: "waiting", Email: "waiting@example.com", Password: "hash", Active: true, DeletionScheduledAt: &future}
+	staying := entities.User{Username: "staying", Email: "staying@example.com", Password: "hash", Active: true}
+	db.Create(&leaving)
+	db.Create(&waiting)
+	db.Create(&staying)
+
+	// images are stored as |url|url|
+	place := entities.Place{Name: "place", Images: "|image|"}
+	db.Create(&place)
+	review := entities.Comment{Rate: 4, UserID: leaving.ID, PlaceID: place.ID, Status: entities.CommentStatusPublished}
+	db.Create(&review)
+	otherReview := entities.Comment{Rate: 5, UserID: staying.ID, PlaceID: place.ID, Status: entities.CommentStatusPublished, HelpfulCount: 1}
+	db.Create(&otherReview)
+	db.Create(&entities.CommentVote{CommentID: otherReview.ID, UserID: leaving.ID, Helpful: true})
+	db.Create(&entities.CommentVote{CommentID: review.ID, UserID: staying.ID, Helpful: true})
+
+	trip := entities.Trip{Name: "trip", Owner: leaving.ID}
+	db.Create(&trip)
+	db.Create(&entities.Day{TripID: trip.ID})
+	db.Create(&entities.UserTrip{TripID: trip.ID, UserID: staying.ID})
+	otherTrip := entities.Trip{Name: "other", Owner: staying.ID}
+	db.Create(&otherTrip)
+	db.Create(&entities.UserTrip{TripID: otherTrip.ID, UserID: leaving.ID})
+
+	db.Create(&entities.UserToken{UserID: leaving.ID, TokenID: "leaving-session"})
+	db.Create(&entities.UserToken{UserID: staying.ID, TokenID: "staying-session"})
+	db.Create(&entities.UserIdentity{UserID: leaving.ID, Provider: "google", Subject: "1"})
+	db.Create(&entities.Follow{FollowerID: leaving.ID, FolloweeID: staying.ID})
+	db.Create(&entities.Follow{FollowerID: staying.ID, FolloweeID: leaving.ID})
+	db.Create(&entities.Notification{UserID: leaving.ID, Type: "follow", Title: "followed"})
+
+	erased, err := usecase.PurgeDeletedAccounts(context.Background(), db)
+	if err != nil || erased != 1 {
+		t.Fatalf("purge returned %d, %v", erased, err)
+	}
+
+	count := func(model interface{}, query string, args ...interface{}) int64 {
+		var n int64
+		db.Unscoped().Model(model).Where(query, args...).Count(&n)
+		return n
+	}
+	if count(&entities.User{}, "id = ?", leaving.ID) != 0 {
+		t.Fatal("user not erased")
+	}
+	if count(&entities.User{}, "id IN ?", []int{waiting.ID, staying.ID}) != 2 {
+		t.Fatal("users not due for deletion erased")
+	}
+	for _, check := range []struct {
+		model interface{}
+		query string
+	}{
+		{&entities.UserToken{}, "user_id = ?"},
+		{&entities.UserIdentity{}, "user_id = ?"},
+		{&entities.CommentVote{}, "user_id = ?"},
+		{&entities.UserTrip{}, "user_id = ?"},
+		{&entities.Notification{}, "user_id = ?"},
+		{&entities.Trip{}, "owner = ?"},
+		{&entities.Follow{}, "follower_id = ? OR followee_id = ?"},
+	} {
+		args := []interface{}{leaving.ID}
+		if strings.Count(check.query, "?") == 2 {
+			args = append(args, leaving.ID)
+		}
+		if n := count(check.model, check.query, args...); n != 0 {
+			t.Errorf("%d %T rows of the user kept", n, check.model)
+		}
+	}
+	if count(&entities.Day{}, "trip_id = ?", trip.ID) != 0 || count(&entities.UserTrip{}, "trip_id = ?", trip.ID) != 0 {
+		t.Error("days or members of the trips of the user kept")
+	}
+
+	// the reviews stay without their author, the votes of the user no longer count
+	if count(&entities.Comment{}, "id = ? AND user_id IS NULL", review.ID) != 1 {
+		t.Error("review of the user not kept anonymously")
+	}
+	if count(&entities.CommentVote{}, "comment_id = ?", review.ID) != 1 {
+		t.Error("votes of the other users on the review dropped")
+	}
+	if count(&entities.Comment{}, "id = ? AND helpful_count = 0", otherReview.ID) != 1 {
+		t.Error("vote counts not refreshed")
+	}
+
+	// the data of the other users stays
+	if count(&entities.UserToken{}, "user_id = ?", staying.ID) != 1 || count(&entities.Trip{}, "id = ?", otherTrip.ID) != 1 {
+		t.Error("data of the other users erased")
+	}
+	if len(sessions.users) != 1 || sessions.users[0] != leaving.ID {
+		t.Fatalf("websockets of the erased user not closed: %v", sessions.users)
+	}
+}
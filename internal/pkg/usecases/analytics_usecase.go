@@ -0,0 +1,168 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"go-server/internal/pkg/domains/interfaces"
+	"go-server/internal/pkg/domains/models/dtos"
+	"go-server/internal/pkg/domains/models/entities"
+	"go-server/pkg/shared/database"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+var (
+	TrackContentNotFound = errors.New("Content not found")
+
+	ReportContentNotFound = errors.New("Content not found")
+	ReportRangeInvalid    = errors.New("Report end date must not be before its start date")
+)
+
+const dateLayout = "2006-01-02"
+
+type analyticsUsecase struct {
+	analyticsRepo interfaces.AnalyticsRepository
+	logger        *logrus.Logger
+}
+
+func NewAnalyticsUsecase(
+	analyticsRepo interfaces.AnalyticsRepository,
+	logger *logrus.Logger,
+) interfaces.AnalyticsUsecase {
+	return &analyticsUsecase{
+		analyticsRepo,
+		logger,
+	}
+}
+
+func (u *analyticsUsecase) Track(
+	ctx context.Context,
+	db *gorm.DB,
+	userID int,
+	req dtos.TrackEventsRequestDto,
+) error {
+	type content struct {
+		contentType string
+		contentID   int
+	}
+
+	checked := make(map[content]bool)
+	for _, event := range req.Events {
+		key := content{event.ContentType, event.ContentID}
+		if checked[key] {
+			continue
+		}
+
+		exists, err := u.analyticsRepo.ContentExists(ctx, event.ContentType, event.ContentID)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return TrackContentNotFound
+		}
+		checked[key] = true
+	}
+
+	now := time.Now()
+	today := startOfDay(now)
+
+	return database.Transaction(ctx, db, func(tx *gorm.DB) error {
+		for _, event := range req.Events {
+			_, err := u.analyticsRepo.CreateEventWithTx(tx, entities.ContentEvent{
+				ContentType: event.ContentType,
+				ContentID:   event.ContentID,
+				UserID:      userID,
+				Event:       event.Event,
+				BaseEntity: entities.BaseEntity{
+					CreatedAt: &now,
+					UpdatedAt: &now,
+				},
+			})
+			if err != nil {
+				return err
+			}
+
+			stat := entities.ContentDailyStat{
+				ContentType: event.ContentType,
+				ContentID:   event.ContentID,
+				Date:        today,
+			}
+			switch event.Event {
+			case entities.ContentEventImpression:
+				stat.Impressions = 1
+			case entities.ContentEventClick:
+				stat.Clicks = 1
+			}
+
+			err = u.analyticsRepo.IncrementDailyStatWithTx(tx, stat)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// Report sums the daily counters of the content between from and to (both days included)
+func (u *analyticsUsecase) Report(
+	ctx context.Context,
+	contentType string,
+	contentID int,
+	from time.Time,
+	to time.Time,
+) (dtos.ContentReportDto, error) {
+	from = startOfDay(from)
+	to = startOfDay(to)
+	if to.Before(from) {
+		return dtos.ContentReportDto{}, ReportRangeInvalid
+	}
+
+	exists, err := u.analyticsRepo.ContentExists(ctx, contentType, contentID)
+	if err != nil {
+		return dtos.ContentReportDto{}, err
+	}
+	if !exists {
+		return dtos.ContentReportDto{}, ReportContentNotFound
+	}
+
+	stats, err := u.analyticsRepo.FindDailyStats(ctx, contentType, contentID, from, to)
+	if err != nil {
+		return dtos.ContentReportDto{}, err
+	}
+
+	report := dtos.ContentReportDto{
+		ContentType: contentType,
+		ContentID:   contentID,
+		From:        from.Format(dateLayout),
+		To:          to.Format(dateLayout),
+		Days:        []dtos.ContentDailyReportDto{},
+	}
+	for _, stat := range stats {
+		report.Impressions += stat.Impressions
+		report.Clicks += stat.Clicks
+		report.Days = append(report.Days, dtos.ContentDailyReportDto{
+			Date:        stat.Date.Format(dateLayout),
+			Impressions: stat.Impressions,
+			Clicks:      stat.Clicks,
+			CTR:         clickThroughRate(stat.Clicks, stat.Impressions),
+		})
+	}
+	report.CTR = clickThroughRate(report.Clicks, report.Impressions)
+
+	return report, nil
+}
+
+func clickThroughRate(clicks int64, impressions int64) float64 {
+	if impressions == 0 {
+		return 0
+	}
+	return float64(clicks) / float64(impressions)
+}
+
+func startOfDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
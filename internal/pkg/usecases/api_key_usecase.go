@@ -0,0 +1,163 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"go-server/internal/pkg/domains/interfaces"
+	"go-server/internal/pkg/domains/models/dtos"
+	"go-server/internal/pkg/domains/models/entities"
+	"go-server/pkg/shared/auth"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+var (
+	CreateAPIKeyScopeInvalid  = errors.New("Unknown API key scope")
+	CreateAPIKeyExpiryInvalid = errors.New("API key expiry must be in the future")
+
+	APIKeyNotFound       = errors.New("API key not found")
+	APIKeyAlreadyRevoked = errors.New("API key already revoked")
+)
+
+type apiKeyUsecase struct {
+	apiKeyRepo interfaces.APIKeyRepository
+	logger     *logrus.Logger
+}
+
+func NewAPIKeyUsecase(
+	apiKeyRepo interfaces.APIKeyRepository,
+	logger *logrus.Logger,
+) interfaces.APIKeyUsecase {
+	return &apiKeyUsecase{
+		apiKeyRepo,
+		logger,
+	}
+}
+
+// Create generates a key with the scopes, the key is returned once and only its hash is kept
+func (u *apiKeyUsecase) Create(
+	ctx context.Context,
+	createdBy int,
+	req dtos.CreateAPIKeyRequestDto,
+) (dtos.CreateAPIKeyResponseDto, error) {
+	scopes := []string{}
+	for _, scope := range req.Scopes {
+		if !slices.Contains(entities.APIKeyScopes, scope) {
+			return dtos.CreateAPIKeyResponseDto{}, CreateAPIKeyScopeInvalid
+		}
+		if !slices.Contains(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return dtos.CreateAPIKeyResponseDto{}, CreateAPIKeyExpiryInvalid
+	}
+
+	key, prefix, hash, err := auth.GenerateAPIKey()
+	if err != nil {
+		return dtos.CreateAPIKeyResponseDto{}, err
+	}
+
+	apiKey, err := u.apiKeyRepo.Create(ctx, entities.APIKey{
+		Name:      req.Name,
+		Prefix:    prefix,
+		KeyHash:   hash,
+		Scopes:    strings.Join(scopes, ","),
+		ExpiresAt: req.ExpiresAt,
+		CreatedBy: createdBy,
+	})
+	if err != nil {
+		return dtos.CreateAPIKeyResponseDto{}, err
+	}
+	apiKey.ScopeList = scopes
+
+	return dtos.CreateAPIKeyResponseDto{
+		APIKey: apiKey,
+		Key:    key,
+	}, nil
+}
+
+func (u *apiKeyUsecase) FindAll(
+	ctx context.Context,
+) ([]entities.APIKey, error) {
+	return u.apiKeyRepo.FindAll(ctx)
+}
+
+// Revoke stops the key from authenticating, it is kept for its usage history
+func (u *apiKeyUsecase) Revoke(
+	ctx context.Context,
+	apiKeyID int,
+) error {
+	apiKey, err := u.takeAPIKey(ctx, apiKeyID)
+	if err != nil {
+		return err
+	}
+	if apiKey.RevokedAt != nil {
+		return APIKeyAlreadyRevoked
+	}
+
+	return u.apiKeyRepo.UpdateColumns(ctx, apiKey, map[string]interface{}{
+		"revoked_at": time.Now(),
+	})
+}
+
+// Usage sums the daily requests of the key between from and to (both days included)
+func (u *apiKeyUsecase) Usage(
+	ctx context.Context,
+	apiKeyID int,
+	from time.Time,
+	to time.Time,
+) (dtos.APIKeyUsageReportDto, error) {
+	from = startOfDay(from)
+	to = startOfDay(to)
+	if to.Before(from) {
+		return dtos.APIKeyUsageReportDto{}, ReportRangeInvalid
+	}
+
+	_, err := u.takeAPIKey(ctx, apiKeyID)
+	if err != nil {
+		return dtos.APIKeyUsageReportDto{}, err
+	}
+
+	usages, err := u.apiKeyRepo.FindDailyUsages(ctx, apiKeyID, from, to)
+	if err != nil {
+		return dtos.APIKeyUsageReportDto{}, err
+	}
+
+	report := dtos.APIKeyUsageReportDto{
+		APIKeyID: apiKeyID,
+		From:     from.Format(dateLayout),
+		To:       to.Format(dateLayout),
+		Days:     []dtos.APIKeyDailyUsageDto{},
+	}
+	for _, usage := range usages {
+		report.Requests += usage.Requests
+		report.Days = append(report.Days, dtos.APIKeyDailyUsageDto{
+			Date:     usage.Date.Format(dateLayout),
+			Requests: usage.Requests,
+		})
+	}
+
+	return report, nil
+}
+
+func (u *apiKeyUsecase) takeAPIKey(
+	ctx context.Context,
+	apiKeyID int,
+) (entities.APIKey, error) {
+	apiKey, err := u.apiKeyRepo.TakeByConditions(ctx, map[string]interface{}{
+		"id": apiKeyID,
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return entities.APIKey{}, APIKeyNotFound
+		}
+		return entities.APIKey{}, err
+	}
+
+	return apiKey, nil
+}
@@ -6,6 +6,8 @@ import (
 	"go-server/internal/pkg/domains/interfaces"
 	"go-server/internal/pkg/domains/models/dtos"
 	"go-server/internal/pkg/domains/models/entities"
+	"go-server/pkg/shared/database"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
@@ -14,34 +16,86 @@ import (
 var (
 	UpdateBannerIDNotFound = errors.New("Banner not found")
 	DeleteBannerIDNotFound = errors.New("Banner not found")
+
+	BannerScheduleInvalid = errors.New("Banner end time must be after its start time")
+	BannerTargetInvalid   = errors.New("Banner target must have a target_id, or a target_url for url targets")
+	BannerTargetNotFound  = errors.New("Banner target not found")
 )
 
+// bannerTargetNone removes the target of a banner on update
+const bannerTargetNone = "none"
+
 type bannerUsecase struct {
-	bannerRepo interfaces.BannerRepository
-	logger     *logrus.Logger
+	bannerRepo      interfaces.BannerRepository
+	translationRepo interfaces.TranslationRepository
+	logger          *logrus.Logger
 }
 
 func NewBannerUsecase(
 	bannerRepo interfaces.BannerRepository,
+	translationRepo interfaces.TranslationRepository,
 	logger *logrus.Logger,
 ) interfaces.BannerUsecase {
 	return &bannerUsecase{
 		bannerRepo,
+		translationRepo,
 		logger,
 	}
 }
 
 func (u *bannerUsecase) Create(
 	ctx context.Context,
+	db *gorm.DB,
 	req dtos.CreateBannerRequestDto,
 ) (entities.Banner, error) {
+	translations, err := buildTranslations(req.Translations)
+	if err != nil {
+		return entities.Banner{}, err
+	}
+
+	startAt := unixTime(req.StartAt)
+	endAt := unixTime(req.EndAt)
+	if !validBannerSchedule(startAt, endAt) {
+		return entities.Banner{}, BannerScheduleInvalid
+	}
+
+	targetID, targetURL, err := u.validateTarget(ctx, req.TargetType, req.TargetID, req.TargetURL)
+	if err != nil {
+		return entities.Banner{}, err
+	}
+
+	active := true
+	if req.Active != nil {
+		active = *req.Active
+	}
+
 	banner := entities.Banner{
-		Name:  req.Name,
-		Image: req.Image,
+		Name:       req.Name,
+		Image:      req.Image,
+		StartAt:    startAt,
+		EndAt:      endAt,
+		Position:   req.Position,
+		Active:     &active,
+		TargetType: req.TargetType,
+		TargetID:   targetID,
+		TargetURL:  targetURL,
+	}
+
+	err = database.Transaction(ctx, db, func(tx *gorm.DB) error {
+		banner, err = u.bannerRepo.CreateWithTx(tx, banner)
+		if err != nil {
+			return err
+		}
+
+		return u.translationRepo.ReplaceWithTx(tx, entities.TranslationEntityBanner, banner.ID, translations)
+	})
+	if err != nil {
+		return entities.Banner{}, err
 	}
 
-	banner, err := u.bannerRepo.Create(ctx, banner)
-	return banner, err
+	return u.bannerRepo.TakeByConditions(ctx, map[string]interface{}{
+		"id": banner.ID,
+	})
 }
 
 func (u *bannerUsecase) FindByConditions(
@@ -55,6 +109,7 @@ func (u *bannerUsecase) FindByConditions(
 
 func (u *bannerUsecase) Update(
 	ctx context.Context,
+	db *gorm.DB,
 	req dtos.UpdateBannerRequestDto,
 	conditions map[string]interface{},
 ) (entities.Banner, error) {
@@ -66,17 +121,84 @@ func (u *bannerUsecase) Update(
 		return entities.Banner{}, err
 	}
 
+	translations, err := buildTranslations(req.Translations)
+	if err != nil {
+		return entities.Banner{}, err
+	}
+
+	// columns which may be set to a zero value, Updates with a struct would skip them
+	columns := make(map[string]interface{})
+
+	startAt, endAt := banner.StartAt, banner.EndAt
+	if req.StartAt != nil {
+		startAt = unixTime(*req.StartAt)
+		columns["start_at"] = startAt
+	}
+	if req.EndAt != nil {
+		endAt = unixTime(*req.EndAt)
+		columns["end_at"] = endAt
+	}
+	if !validBannerSchedule(startAt, endAt) {
+		return entities.Banner{}, BannerScheduleInvalid
+	}
+
+	if req.Position != nil {
+		columns["position"] = *req.Position
+	}
+	if req.Active != nil {
+		columns["active"] = *req.Active
+	}
+
+	if req.TargetType != nil {
+		targetType := *req.TargetType
+		if targetType == bannerTargetNone {
+			targetType = ""
+		}
+
+		targetID, targetURL, err := u.validateTarget(ctx, targetType, req.TargetID, req.TargetURL)
+		if err != nil {
+			return entities.Banner{}, err
+		}
+		columns["target_type"] = targetType
+		columns["target_id"] = targetID
+		columns["target_url"] = targetURL
+	}
+
 	newBanner := entities.Banner{
 		Name:  req.Name,
 		Image: req.Image,
 	}
 
-	banner, err = u.bannerRepo.Update(ctx, banner, newBanner)
+	err = database.Transaction(ctx, db, func(tx *gorm.DB) error {
+		banner, err = u.bannerRepo.UpdateWithTx(tx, banner, newBanner)
+		if err != nil {
+			return err
+		}
+
+		if len(columns) > 0 {
+			banner, err = u.bannerRepo.UpdateColumnsWithTx(tx, banner, columns)
+			if err != nil {
+				return err
+			}
+		}
+
+		// translations are left untouched when the request does not send them
+		if req.Translations != nil {
+			err = u.translationRepo.ReplaceWithTx(tx, entities.TranslationEntityBanner, banner.ID, translations)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
 	if err != nil {
 		return entities.Banner{}, err
 	}
 
-	return banner, nil
+	return u.bannerRepo.TakeByConditions(ctx, map[string]interface{}{
+		"id": banner.ID,
+	})
 }
 
 func (u *bannerUsecase) TakeByConditions(
@@ -97,3 +219,63 @@ func (u *bannerUsecase) DeleteByConditions(
 
 	return u.bannerRepo.DeleteByConditions(ctx, conditions)
 }
+
+func (u *bannerUsecase) FindLive(
+	ctx context.Context,
+) ([]entities.Banner, error) {
+	return u.bannerRepo.FindLive(ctx, time.Now())
+}
+
+func (u *bannerUsecase) TakeLive(
+	ctx context.Context,
+	bannerID int,
+) (entities.Banner, error) {
+	return u.bannerRepo.TakeLive(ctx, bannerID, time.Now())
+}
+
+// validateTarget checks the banner target exists and returns the target id and url to store,
+// only one of them is kept depending on the target type.
+func (u *bannerUsecase) validateTarget(
+	ctx context.Context,
+	targetType string,
+	targetID int,
+	targetURL string,
+) (int, string, error) {
+	switch targetType {
+	case "":
+		return 0, "", nil
+	case entities.BannerTargetURL:
+		if targetURL == "" {
+			return 0, "", BannerTargetInvalid
+		}
+		return 0, targetURL, nil
+	}
+
+	if targetID <= 0 {
+		return 0, "", BannerTargetInvalid
+	}
+
+	exists, err := u.bannerRepo.TargetExists(ctx, targetType, targetID)
+	if err != nil {
+		return 0, "", err
+	}
+	if !exists {
+		return 0, "", BannerTargetNotFound
+	}
+
+	return targetID, "", nil
+}
+
+func validBannerSchedule(startAt *time.Time, endAt *time.Time) bool {
+	return startAt == nil || endAt == nil || endAt.After(*startAt)
+}
+
+// unixTime converts a unix timestamp from a request, 0 meaning not set
+func unixTime(value int) *time.Time {
+	if value == 0 {
+		return nil
+	}
+
+	t := time.Unix(int64(value), 0)
+	return &t
+}
@@ -6,6 +6,8 @@ import (
 	"go-server/internal/pkg/domains/interfaces"
 	"go-server/internal/pkg/domains/models/dtos"
 	"go-server/internal/pkg/domains/models/entities"
+	"go-server/pkg/shared/database"
+	"slices"
 
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
@@ -15,34 +17,75 @@ var (
 	UpdateCategoryIDNotFound       = errors.New("Category not found")
 	DeleteCategoryIDNotFound       = errors.New("Category not found")
 	DeleteCategoryCategoryHasPlace = errors.New("Cannot delete category that has places")
+	DeleteCategoryHasChildren      = errors.New("Cannot delete category that has child categories")
+
+	CreateCategoryParentNotFound = errors.New("Parent category not found")
+	UpdateCategoryParentNotFound = errors.New("Parent category not found")
+	UpdateCategoryParentCycle    = errors.New("Parent category cannot be the category itself or one of its children")
 )
 
 type categoryUsecase struct {
-	categoryRepo interfaces.CategoryRepository
-	logger       *logrus.Logger
+	categoryRepo    interfaces.CategoryRepository
+	translationRepo interfaces.TranslationRepository
+	logger          *logrus.Logger
 }
 
 func NewCategoryRepository(
 	categoryRepo interfaces.CategoryRepository,
+	translationRepo interfaces.TranslationRepository,
 	logger *logrus.Logger,
 ) interfaces.CategoryUsecase {
 	return &categoryUsecase{
 		categoryRepo,
+		translationRepo,
 		logger,
 	}
 }
 
 func (u *categoryUsecase) Create(
 	ctx context.Context,
+	db *gorm.DB,
 	req dtos.CreateCategoryRequestDto,
 ) (entities.Category, error) {
+	translations, err := buildTranslations(req.Translations)
+	if err != nil {
+		return entities.Category{}, err
+	}
+
 	category := entities.Category{
 		Name:        req.Name,
 		Icon:        req.Icon,
 		Description: req.Description,
 	}
 
-	category, err := u.categoryRepo.Create(ctx, category)
+	if req.ParentID != nil && *req.ParentID != 0 {
+		_, err = u.categoryRepo.TakeByConditions(ctx, map[string]interface{}{
+			"id": *req.ParentID,
+		})
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return entities.Category{}, CreateCategoryParentNotFound
+			}
+			return entities.Category{}, err
+		}
+		category.ParentID = req.ParentID
+	}
+
+	err = database.Transaction(ctx, db, func(tx *gorm.DB) error {
+		category, err = u.categoryRepo.CreateWithTx(tx, category)
+		if err != nil {
+			return err
+		}
+
+		err = u.translationRepo.ReplaceWithTx(tx, entities.TranslationEntityCategory, category.ID, translations)
+		if err != nil {
+			return err
+		}
+
+		category.Translations = translations
+
+		return nil
+	})
 	return category, err
 }
 
@@ -51,12 +94,16 @@ func (u *categoryUsecase) FindByConditions(
 	conditions map[string]interface{},
 ) ([]entities.Category, error) {
 	categories, err := u.categoryRepo.FindByConditions(ctx, map[string]interface{}{})
+	if err != nil {
+		return []entities.Category{}, err
+	}
 
-	return categories, err
+	return entities.BuildCategoryTree(categories), nil
 }
 
 func (u *categoryUsecase) Update(
 	ctx context.Context,
+	db *gorm.DB,
 	req dtos.UpdateCategoryRequestDto,
 	conditions map[string]interface{},
 ) (entities.Category, error) {
@@ -68,13 +115,47 @@ func (u *categoryUsecase) Update(
 		return entities.Category{}, err
 	}
 
+	translations, err := buildTranslations(req.Translations)
+	if err != nil {
+		return entities.Category{}, err
+	}
+
+	parentID, err := u.validateParent(ctx, category, req.ParentID)
+	if err != nil {
+		return entities.Category{}, err
+	}
+
 	updatedCategory := entities.Category{
 		Name:        req.Name,
 		Icon:        req.Icon,
 		Description: req.Description,
 	}
 
-	category, err = u.categoryRepo.Update(ctx, category, updatedCategory)
+	err = database.Transaction(ctx, db, func(tx *gorm.DB) error {
+		category, err = u.categoryRepo.UpdateWithTx(tx, category, updatedCategory)
+		if err != nil {
+			return err
+		}
+
+		if req.ParentID != nil {
+			category, err = u.categoryRepo.UpdateParentWithTx(tx, category, parentID)
+			if err != nil {
+				return err
+			}
+		}
+
+		// translations are left untouched when the request does not send them
+		if req.Translations != nil {
+			err = u.translationRepo.ReplaceWithTx(tx, entities.TranslationEntityCategory, category.ID, translations)
+			if err != nil {
+				return err
+			}
+
+			category.Translations = translations
+		}
+
+		return nil
+	})
 	if err != nil {
 		return entities.Category{}, err
 	}
@@ -102,5 +183,47 @@ func (u *categoryUsecase) DeleteByConditions(
 		return DeleteCategoryCategoryHasPlace
 	}
 
+	if len(category.Children) > 0 {
+		return DeleteCategoryHasChildren
+	}
+
 	return u.categoryRepo.DeleteByConditions(ctx, conditions)
 }
+
+// validateParent checks the requested parent of category and returns the parent id to store,
+// nil meaning the category becomes a root.
+func (u *categoryUsecase) validateParent(
+	ctx context.Context,
+	category entities.Category,
+	parentID *int,
+) (*int, error) {
+	if parentID == nil {
+		return category.ParentID, nil
+	}
+	if *parentID == 0 {
+		return nil, nil
+	}
+	if *parentID == category.ID {
+		return nil, UpdateCategoryParentCycle
+	}
+
+	_, err := u.categoryRepo.TakeByConditions(ctx, map[string]interface{}{
+		"id": *parentID,
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, UpdateCategoryParentNotFound
+		}
+		return nil, err
+	}
+
+	categories, err := u.categoryRepo.FindByConditions(ctx, map[string]interface{}{})
+	if err != nil {
+		return nil, err
+	}
+	if slices.Contains(entities.CategoryDescendantIDs(categories, category.ID), *parentID) {
+		return nil, UpdateCategoryParentCycle
+	}
+
+	return parentID, nil
+}
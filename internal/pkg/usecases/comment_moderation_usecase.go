@@ -0,0 +1,242 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"go-server/internal/pkg/domains/interfaces"
+	"go-server/internal/pkg/domains/models/dtos"
+	"go-server/internal/pkg/domains/models/entities"
+	"go-server/pkg/shared/database"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+var (
+	ReportCommentNotFound = errors.New("Comment not found")
+	ReportCommentIsOwn    = errors.New("Cannot report your own comment")
+
+	ModerationQueueInvalid         = errors.New("Queue must be one of held, hidden, reported")
+	ModerateCommentNotFound        = errors.New("Comment not found")
+	ModerateCommentStatusUnchanged = errors.New("Comment already has this status")
+)
+
+// moderationOutcomeTitles are the notification titles of the actions, by action
+var moderationOutcomeTitles = map[string]string{
+	entities.CommentModerationHide:    "Your comment was hidden",
+	entities.CommentModerationRestore: "Your comment was restored",
+	entities.CommentModerationDelete:  "Your comment was deleted",
+}
+
+type commentModerationUsecase struct {
+	commentRepo         interfaces.CommentRepository
+	moderationRepo      interfaces.CommentModerationRepository
+	notificationUsecase interfaces.NotificationUsecase
+	logger              *logrus.Logger
+}
+
+func NewCommentModerationUsecase(
+	commentRepo interfaces.CommentRepository,
+	moderationRepo interfaces.CommentModerationRepository,
+	notificationUsecase interfaces.NotificationUsecase,
+	logger *logrus.Logger,
+) interfaces.CommentModerationUsecase {
+	return &commentModerationUsecase{
+		commentRepo,
+		moderationRepo,
+		notificationUsecase,
+		logger,
+	}
+}
+
+func (u *commentModerationUsecase) Report(
+	ctx context.Context,
+	userID int,
+	commentID int,
+	req dtos.ReportCommentRequestDto,
+) error {
+	comment, err := u.commentRepo.TakeByConditions(ctx, map[string]interface{}{
+		"id": commentID,
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ReportCommentNotFound
+		}
+		return err
+	}
+	if comment.UserID == userID {
+		return ReportCommentIsOwn
+	}
+
+	return u.moderationRepo.UpsertReport(ctx, entities.CommentReport{
+		CommentID: commentID,
+		UserID:    userID,
+		Reason:    req.Reason,
+		Text:      req.Text,
+		Status:    entities.CommentReportStatusOpen,
+	})
+}
+
+func (u *commentModerationUsecase) FindQueuePaginate(
+	ctx context.Context,
+	pageData map[string]int,
+	queue string,
+) ([]entities.Comment, int64, error) {
+	switch queue {
+	case entities.CommentQueuePending, entities.CommentQueueHeld, entities.CommentQueueHidden, entities.CommentQueueReported:
+	default:
+		return nil, 0, ModerationQueueInvalid
+	}
+
+	return u.moderationRepo.FindQueuePaginate(ctx, pageData, queue)
+}
+
+func (u *commentModerationUsecase) Hide(
+	ctx context.Context,
+	db *gorm.DB,
+	adminID int,
+	commentID int,
+	req dtos.ModerateCommentRequestDto,
+) error {
+	return u.moderate(ctx, db, adminID, commentID, entities.CommentModerationHide, entities.CommentStatusHidden, req.Note)
+}
+
+func (u *commentModerationUsecase) Restore(
+	ctx context.Context,
+	db *gorm.DB,
+	adminID int,
+	commentID int,
+	req dtos.ModerateCommentRequestDto,
+) error {
+	return u.moderate(ctx, db, adminID, commentID, entities.CommentModerationRestore, entities.CommentStatusPublished, req.Note)
+}
+
+func (u *commentModerationUsecase) Delete(
+	ctx context.Context,
+	db *gorm.DB,
+	adminID int,
+	commentID int,
+	req dtos.ModerateCommentRequestDto,
+) error {
+	comment, err := u.takeComment(ctx, commentID)
+	if err != nil {
+		return err
+	}
+
+	err = database.Transaction(ctx, db, func(tx *gorm.DB) error {
+		err := u.commentRepo.DeleteThreadWithTx(tx, comment.ID)
+		if err != nil {
+			return err
+		}
+
+		err = u.moderationRepo.ResolveReportsWithTx(tx, comment.ID)
+		if err != nil {
+			return err
+		}
+
+		return u.moderationRepo.CreateLogWithTx(tx, entities.CommentModerationLog{
+			CommentID:  comment.ID,
+			ActorID:    adminID,
+			Action:     entities.CommentModerationDelete,
+			FromStatus: comment.Status,
+			Note:       req.Note,
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	u.notifyOutcome(ctx, comment, entities.CommentModerationDelete, req.Note)
+	return nil
+}
+
+func (u *commentModerationUsecase) FindLogs(
+	ctx context.Context,
+	commentID int,
+) ([]entities.CommentModerationLog, error) {
+	// the trail stays readable after the comment is deleted
+	return u.moderationRepo.FindLogs(ctx, commentID)
+}
+
+// moderate moves the comment to status, resolves its reports and records the action
+func (u *commentModerationUsecase) moderate(
+	ctx context.Context,
+	db *gorm.DB,
+	adminID int,
+	commentID int,
+	action string,
+	status string,
+	note string,
+) error {
+	comment, err := u.takeComment(ctx, commentID)
+	if err != nil {
+		return err
+	}
+	if comment.Status == status {
+		return ModerateCommentStatusUnchanged
+	}
+
+	err = database.Transaction(ctx, db, func(tx *gorm.DB) error {
+		err := u.moderationRepo.UpdateStatusWithTx(tx, comment.ID, status)
+		if err != nil {
+			return err
+		}
+
+		err = u.moderationRepo.ResolveReportsWithTx(tx, comment.ID)
+		if err != nil {
+			return err
+		}
+
+		return u.moderationRepo.CreateLogWithTx(tx, entities.CommentModerationLog{
+			CommentID:  comment.ID,
+			ActorID:    adminID,
+			Action:     action,
+			FromStatus: comment.Status,
+			ToStatus:   status,
+			Note:       note,
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	u.notifyOutcome(ctx, comment, action, note)
+	return nil
+}
+
+// notifyOutcome tells the author what the moderators did with their comment, the note
+// of the moderator is the explanation
+func (u *commentModerationUsecase) notifyOutcome(
+	ctx context.Context,
+	comment entities.Comment,
+	action string,
+	note string,
+) {
+	notify(ctx, u.notificationUsecase, u.logger, comment.UserID, entities.Notification{
+		Type:  entities.NotificationModerationOutcome,
+		Title: moderationOutcomeTitles[action],
+		Body:  note,
+		Data: map[string]interface{}{
+			"comment_id": comment.ID,
+			"place_id":   comment.PlaceID,
+			"action":     action,
+		},
+	})
+}
+
+func (u *commentModerationUsecase) takeComment(
+	ctx context.Context,
+	commentID int,
+) (entities.Comment, error) {
+	comment, err := u.commentRepo.TakeByConditions(ctx, map[string]interface{}{
+		"id": commentID,
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return entities.Comment{}, ModerateCommentNotFound
+		}
+		return entities.Comment{}, err
+	}
+
+	return comment, nil
+}
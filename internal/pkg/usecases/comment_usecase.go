@@ -0,0 +1,670 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"go-server/internal/pkg/domains/interfaces"
+	"go-server/internal/pkg/domains/models/dtos"
+	"go-server/internal/pkg/domains/models/entities"
+	"go-server/pkg/shared/database"
+	"go-server/pkg/shared/moderation"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+var (
+	UpdateCommentNotFound  = errors.New("Comment not found")
+	UpdateCommentForbidden = errors.New("Only the author can edit this comment")
+	DeleteCommentNotFound  = errors.New("Comment not found")
+	DeleteCommentForbidden = errors.New("Only the author can delete this comment")
+
+	CreateCommentParentNotFound = errors.New("Parent comment not found")
+	CreateCommentParentIsReply  = errors.New("Cannot reply to a reply")
+
+	ListRepliesCommentNotFound = errors.New("Comment not found")
+
+	OfficialResponseCommentNotFound = errors.New("Comment not found")
+	OfficialResponseCommentIsReply  = errors.New("Official responses can only be posted on reviews")
+
+	CommentImagesTooMany = errors.New("Too many images")
+	CommentImageInvalid  = errors.New("Images must be uploaded through the upload API")
+
+	VoteCommentNotFound = errors.New("Comment not found")
+	VoteCommentIsOwn    = errors.New("Cannot vote on your own comment")
+)
+
+// replies are listed in the order of the conversation
+const commentRepliesOrder = "created_at ASC, id ASC"
+
+// replies embedded per review at most, the rest is paged through FindRepliesPaginate
+const maxRepliesPerPage = 20
+
+const defaultCommentMaxImages = 5
+
+// CommentMaxImages is the number of images a comment can have, configured by COMMENT_MAX_IMAGES
+func CommentMaxImages() int {
+	maxImages, err := strconv.Atoi(os.Getenv("COMMENT_MAX_IMAGES"))
+	if err != nil || maxImages < 0 {
+		return defaultCommentMaxImages
+	}
+
+	return maxImages
+}
+
+type commentUsecase struct {
+	commentRepo         interfaces.CommentRepository
+	moderationRepo      interfaces.CommentModerationRepository
+	uploadUsecase       interfaces.UploadUsecase
+	contentFilter       moderation.ContentFilter
+	notificationUsecase interfaces.NotificationUsecase
+	logger              *logrus.Logger
+}
+
+func NewCommentUsecase(
+	commentRepo interfaces.CommentRepository,
+	moderationRepo interfaces.CommentModerationRepository,
+	uploadUsecase interfaces.UploadUsecase,
+	contentFilter moderation.ContentFilter,
+	notificationUsecase interfaces.NotificationUsecase,
+	logger *logrus.Logger,
+) interfaces.CommentUsecase {
+	return &commentUsecase{
+		commentRepo,
+		moderationRepo,
+		uploadUsecase,
+		contentFilter,
+		notificationUsecase,
+		logger,
+	}
+}
+
+// Create posts the comment of the user. A user rates a place once: a further rated post
+// updates the existing review, which is returned with created false, and an unrated one
+// becomes a reply to it.
+func (u *commentUsecase) Create(
+	ctx context.Context,
+	db *gorm.DB,
+	userID int,
+	req dtos.CreateCommentRequestDto,
+) (entities.Comment, bool, error) {
+	return u.create(ctx, db, userID, req, false)
+}
+
+// create is Create, retried once when a concurrent post created the review first
+func (u *commentUsecase) create(
+	ctx context.Context,
+	db *gorm.DB,
+	userID int,
+	req dtos.CreateCommentRequestDto,
+	retried bool,
+) (entities.Comment, bool, error) {
+	err := u.validateImages(req.Images)
+	if err != nil {
+		return entities.Comment{}, false, err
+	}
+
+	comment := entities.Comment{
+		PlaceID: req.PlaceID,
+		UserID:  userID,
+		Comment: req.Comment,
+		Rate:    req.Rate,
+	}
+
+	if req.ParentCommentID == 0 {
+		review, err := u.commentRepo.TakeByConditions(ctx, map[string]interface{}{
+			"place_id":          req.PlaceID,
+			"user_id":           userID,
+			"parent_comment_id": nil,
+		})
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return entities.Comment{}, false, err
+		}
+		if err == nil {
+			if req.Rate > 0 {
+				// a re-post without images keeps the photos of the review
+				var images *[]string
+				if req.Images != nil {
+					images = &req.Images
+				}
+				review, err = u.update(ctx, db, review, req.Comment, req.Rate, images)
+				return review, false, err
+			}
+			req.ParentCommentID = review.ID
+		}
+	}
+
+	var parent entities.Comment
+	if req.ParentCommentID != 0 {
+		parent, err = u.takeReview(ctx, req.ParentCommentID, CreateCommentParentNotFound, CreateCommentParentIsReply)
+		if err != nil {
+			return entities.Comment{}, false, err
+		}
+
+		comment.ParentCommentID = &parent.ID
+		comment.PlaceID = parent.PlaceID
+		comment.Rate = 0
+	}
+
+	comment.Status = entities.CommentStatusPublished
+	hold, held := u.filterHold(comment, comment.Comment)
+	if held {
+		comment.Status = entities.CommentStatusHeld
+	}
+
+	err = database.Transaction(ctx, db, func(tx *gorm.DB) error {
+		var err error
+		comment, err = u.commentRepo.CreateWithTx(tx, comment)
+		if err != nil {
+			return err
+		}
+
+		err = u.commentRepo.ReplaceImagesWithTx(tx, comment.ID, req.Images)
+		if err != nil || !held {
+			return err
+		}
+
+		hold.CommentID = comment.ID
+		return u.moderationRepo.CreateLogWithTx(tx, hold)
+	})
+	if database.IsDuplicateKey(err) && comment.ParentCommentID == nil && !retried {
+		// a concurrent post created the review first, this one now updates or replies to it
+		return u.create(ctx, db, userID, req, true)
+	}
+	if err != nil {
+		return entities.Comment{}, false, err
+	}
+
+	// a held reply is not visible yet, the author of the review is not told about it
+	if comment.ParentCommentID != nil && !held && parent.UserID != userID {
+		notify(ctx, u.notificationUsecase, u.logger, parent.UserID, entities.Notification{
+			Type:  entities.NotificationCommentReply,
+			Title: "New reply to your review",
+			Body:  comment.Comment,
+			Data: map[string]interface{}{
+				"comment_id": comment.ID,
+				"review_id":  parent.ID,
+				"place_id":   comment.PlaceID,
+			},
+		})
+	}
+
+	return comment, true, nil
+}
+
+func (u *commentUsecase) Update(
+	ctx context.Context,
+	db *gorm.DB,
+	userID int,
+	moderator bool,
+	commentID int,
+	req dtos.UpdateCommentRequestDto,
+) (entities.Comment, error) {
+	comment, err := u.commentRepo.TakeByConditions(ctx, map[string]interface{}{
+		"id": commentID,
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return entities.Comment{}, UpdateCommentNotFound
+		}
+		return entities.Comment{}, err
+	}
+	if comment.UserID != userID && !moderator {
+		return entities.Comment{}, UpdateCommentForbidden
+	}
+	if req.Images != nil {
+		err = u.validateImages(*req.Images)
+		if err != nil {
+			return entities.Comment{}, err
+		}
+	}
+
+	rate := req.Rate
+	if comment.ParentCommentID != nil {
+		rate = 0
+	}
+
+	return u.update(ctx, db, comment, req.Comment, rate, req.Images)
+}
+
+// update edits a comment, holding it when the new text is caught by the content filter.
+// The images are replaced unless nil.
+func (u *commentUsecase) update(
+	ctx context.Context,
+	db *gorm.DB,
+	comment entities.Comment,
+	text string,
+	rate int,
+	images *[]string,
+) (entities.Comment, error) {
+	columns := map[string]interface{}{
+		"comment": text,
+		"rate":    rate,
+	}
+	hold, held := u.filterHold(comment, text)
+	if held {
+		columns["status"] = entities.CommentStatusHeld
+	}
+
+	err := database.Transaction(ctx, db, func(tx *gorm.DB) error {
+		var err error
+		comment, err = u.commentRepo.UpdateColumnsWithTx(tx, comment, columns)
+		if err != nil {
+			return err
+		}
+
+		if images != nil {
+			err = u.commentRepo.ReplaceImagesWithTx(tx, comment.ID, *images)
+			if err != nil {
+				return err
+			}
+		}
+		if !held {
+			return nil
+		}
+
+		return u.moderationRepo.CreateLogWithTx(tx, hold)
+	})
+
+	return comment, err
+}
+
+// validateImages checks the count of the images and that they come from our upload API
+func (u *commentUsecase) validateImages(
+	urls []string,
+) error {
+	if len(urls) > CommentMaxImages() {
+		return CommentImagesTooMany
+	}
+	for _, url := range urls {
+		if !u.uploadUsecase.IsUploadedURL(url) {
+			return CommentImageInvalid
+		}
+	}
+
+	return nil
+}
+
+// filterHold runs the content filter on the text of a published comment and returns
+// the audit log of holding it when it matches
+func (u *commentUsecase) filterHold(
+	comment entities.Comment,
+	text string,
+) (entities.CommentModerationLog, bool) {
+	if comment.IsOfficial || comment.Status != entities.CommentStatusPublished {
+		return entities.CommentModerationLog{}, false
+	}
+
+	matched := u.contentFilter.Match(text)
+	if len(matched) == 0 {
+		return entities.CommentModerationLog{}, false
+	}
+
+	return entities.CommentModerationLog{
+		CommentID:  comment.ID,
+		Action:     entities.CommentModerationHold,
+		FromStatus: comment.Status,
+		ToStatus:   entities.CommentStatusHeld,
+		Note:       "Content filter: " + strings.Join(matched, ", "),
+	}, true
+}
+
+func (u *commentUsecase) Delete(
+	ctx context.Context,
+	db *gorm.DB,
+	userID int,
+	moderator bool,
+	commentID int,
+) error {
+	comment, err := u.commentRepo.TakeByConditions(ctx, map[string]interface{}{
+		"id": commentID,
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return DeleteCommentNotFound
+		}
+		return err
+	}
+	if comment.UserID != userID && !moderator {
+		return DeleteCommentForbidden
+	}
+
+	return database.Transaction(ctx, db, func(tx *gorm.DB) error {
+		return u.commentRepo.DeleteThreadWithTx(tx, comment.ID)
+	})
+}
+
+func (u *commentUsecase) FindReviewsPaginate(
+	ctx context.Context,
+	userID int,
+	pageData map[string]int,
+	conditions map[string]interface{},
+	order string,
+	repliesPerPage int,
+) ([]entities.Comment, int64, error) {
+	if repliesPerPage > maxRepliesPerPage {
+		repliesPerPage = maxRepliesPerPage
+	}
+
+	conditions["parent_comment_id"] = nil
+	conditions["status"] = entities.CommentStatusPublished
+	reviews, count, err := u.commentRepo.FindListPaginate(ctx, pageData, conditions, order)
+	if err != nil {
+		return reviews, count, err
+	}
+
+	reviewIDs := make([]int, len(reviews))
+	for i := range reviews {
+		reviewIDs[i] = reviews[i].ID
+	}
+
+	// the first replies are embedded, the rest is paged through FindRepliesPaginate
+	replyCounts, err := u.commentRepo.CountReplies(ctx, reviewIDs)
+	if err != nil {
+		return reviews, count, err
+	}
+	replies, err := u.commentRepo.FindFirstReplies(ctx, reviewIDs, repliesPerPage)
+	if err != nil {
+		return reviews, count, err
+	}
+	responses, err := u.commentRepo.FindOfficialResponses(ctx, reviewIDs)
+	if err != nil {
+		return reviews, count, err
+	}
+
+	repliesByReview := make(map[int][]entities.Comment, len(reviews))
+	for _, reply := range replies {
+		repliesByReview[*reply.ParentCommentID] = append(repliesByReview[*reply.ParentCommentID], reply)
+	}
+	responseByReview := make(map[int]entities.Comment, len(responses))
+	for _, response := range responses {
+		responseByReview[*response.ParentCommentID] = response
+	}
+
+	for i := range reviews {
+		reviews[i].ReplyCount = replyCounts[reviews[i].ID]
+		if repliesPerPage > 0 {
+			reviews[i].Replies = repliesByReview[reviews[i].ID]
+		}
+		if response, ok := responseByReview[reviews[i].ID]; ok {
+			reviews[i].OfficialResponse = &response
+		}
+	}
+
+	return reviews, count, u.fillMyVotes(ctx, userID, reviews)
+}
+
+func (u *commentUsecase) FindRepliesPaginate(
+	ctx context.Context,
+	userID int,
+	commentID int,
+	pageData map[string]int,
+) ([]entities.Comment, int64, error) {
+	_, err := u.commentRepo.TakeByConditions(ctx, map[string]interface{}{
+		"id":     commentID,
+		"status": entities.CommentStatusPublished,
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, 0, ListRepliesCommentNotFound
+		}
+		return nil, 0, err
+	}
+
+	replies, count, err := u.commentRepo.FindListPaginate(ctx, pageData, replyConditions(commentID), commentRepliesOrder)
+	if err != nil {
+		return replies, count, err
+	}
+
+	return replies, count, u.fillMyVotes(ctx, userID, replies)
+}
+
+// FindPlacePhotosPaginate lists the photos of the published reviews and replies of a place
+func (u *commentUsecase) FindPlacePhotosPaginate(
+	ctx context.Context,
+	placeID int,
+	pageData map[string]int,
+) ([]dtos.VisitorPhotoDto, int64, error) {
+	images, count, err := u.commentRepo.FindPlaceImagesPaginate(ctx, placeID, pageData)
+	if err != nil {
+		return nil, count, err
+	}
+
+	photos := make([]dtos.VisitorPhotoDto, 0, len(images))
+	for _, image := range images {
+		photo := dtos.VisitorPhotoDto{
+			ID:        image.ID,
+			URL:       image.URL,
+			CommentID: image.CommentID,
+		}
+		if image.CreatedAt != nil {
+			photo.CreatedAt = image.CreatedAt.Unix()
+		}
+		if image.Comment != nil {
+			photo.UserID = image.Comment.UserID
+			photo.Username = image.Comment.User.Username
+			photo.Avatar = image.Comment.User.Avatar
+		}
+		photos = append(photos, photo)
+	}
+
+	return photos, count, nil
+}
+
+func (u *commentUsecase) RespondOfficially(
+	ctx context.Context,
+	userID int,
+	commentID int,
+	req dtos.OfficialResponseRequestDto,
+) (entities.Comment, error) {
+	review, err := u.takeReview(ctx, commentID, OfficialResponseCommentNotFound, OfficialResponseCommentIsReply)
+	if err != nil {
+		return entities.Comment{}, err
+	}
+
+	// a review has a single official response, posting again edits it
+	response, err := u.commentRepo.TakeByConditions(ctx, map[string]interface{}{
+		"parent_comment_id": review.ID,
+		"is_official":       true,
+	})
+	if err == nil {
+		return u.commentRepo.UpdateColumns(ctx, response, map[string]interface{}{
+			"comment": req.Comment,
+			"user_id": userID,
+		})
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return entities.Comment{}, err
+	}
+
+	response, err = u.commentRepo.Create(ctx, entities.Comment{
+		PlaceID:         review.PlaceID,
+		UserID:          userID,
+		Comment:         req.Comment,
+		ParentCommentID: &review.ID,
+		IsOfficial:      true,
+	})
+	if err != nil {
+		return entities.Comment{}, err
+	}
+
+	notify(ctx, u.notificationUsecase, u.logger, review.UserID, entities.Notification{
+		Type:  entities.NotificationOfficialResponse,
+		Title: "Travelix responded to your review",
+		Body:  response.Comment,
+		Data: map[string]interface{}{
+			"comment_id": response.ID,
+			"review_id":  review.ID,
+			"place_id":   review.PlaceID,
+		},
+	})
+
+	return response, nil
+}
+
+func (u *commentUsecase) Vote(
+	ctx context.Context,
+	db *gorm.DB,
+	userID int,
+	commentID int,
+	req dtos.VoteCommentRequestDto,
+) (entities.Comment, error) {
+	// held and hidden comments are not visible, they cannot be voted on
+	comment, err := u.commentRepo.TakeByConditions(ctx, map[string]interface{}{
+		"id":     commentID,
+		"status": entities.CommentStatusPublished,
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return entities.Comment{}, VoteCommentNotFound
+		}
+		return entities.Comment{}, err
+	}
+	if comment.UserID == userID {
+		return entities.Comment{}, VoteCommentIsOwn
+	}
+
+	err = database.Transaction(ctx, db, func(tx *gorm.DB) error {
+		err := u.commentRepo.UpsertVoteWithTx(tx, entities.CommentVote{
+			CommentID: commentID,
+			UserID:    userID,
+			Helpful:   req.Vote == entities.CommentVoteHelpful,
+		})
+		if err != nil {
+			return err
+		}
+
+		return u.commentRepo.RefreshVoteCountsWithTx(tx, commentID)
+	})
+	if err != nil {
+		return entities.Comment{}, err
+	}
+
+	return u.takeWithMyVote(ctx, userID, commentID)
+}
+
+func (u *commentUsecase) DeleteVote(
+	ctx context.Context,
+	db *gorm.DB,
+	userID int,
+	commentID int,
+) (entities.Comment, error) {
+	_, err := u.commentRepo.TakeByConditions(ctx, map[string]interface{}{
+		"id": commentID,
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return entities.Comment{}, VoteCommentNotFound
+		}
+		return entities.Comment{}, err
+	}
+
+	err = database.Transaction(ctx, db, func(tx *gorm.DB) error {
+		err := u.commentRepo.DeleteVoteWithTx(tx, commentID, userID)
+		if err != nil {
+			return err
+		}
+
+		return u.commentRepo.RefreshVoteCountsWithTx(tx, commentID)
+	})
+	if err != nil {
+		return entities.Comment{}, err
+	}
+
+	return u.takeWithMyVote(ctx, userID, commentID)
+}
+
+func (u *commentUsecase) takeWithMyVote(
+	ctx context.Context,
+	userID int,
+	commentID int,
+) (entities.Comment, error) {
+	comment, err := u.commentRepo.TakeByConditions(ctx, map[string]interface{}{
+		"id": commentID,
+	})
+	if err != nil {
+		return entities.Comment{}, err
+	}
+
+	comments := []entities.Comment{comment}
+	err = u.fillMyVotes(ctx, userID, comments)
+
+	return comments[0], err
+}
+
+// fillMyVotes sets the vote of the user on the comments, their replies and official responses
+func (u *commentUsecase) fillMyVotes(
+	ctx context.Context,
+	userID int,
+	comments []entities.Comment,
+) error {
+	var commentIDs []int
+	for _, comment := range comments {
+		commentIDs = append(commentIDs, comment.ID)
+		for _, reply := range comment.Replies {
+			commentIDs = append(commentIDs, reply.ID)
+		}
+		if comment.OfficialResponse != nil {
+			commentIDs = append(commentIDs, comment.OfficialResponse.ID)
+		}
+	}
+
+	votes, err := u.commentRepo.FindVotesByUser(ctx, userID, commentIDs)
+	if err != nil {
+		return err
+	}
+
+	myVotes := make(map[int]string)
+	for _, vote := range votes {
+		myVotes[vote.CommentID] = vote.Vote()
+	}
+
+	for i := range comments {
+		comments[i].MyVote = myVotes[comments[i].ID]
+		for j := range comments[i].Replies {
+			comments[i].Replies[j].MyVote = myVotes[comments[i].Replies[j].ID]
+		}
+		if comments[i].OfficialResponse != nil {
+			comments[i].OfficialResponse.MyVote = myVotes[comments[i].OfficialResponse.ID]
+		}
+	}
+
+	return nil
+}
+
+// takeReview loads a published top level comment, replies are only one level deep and
+// held or hidden reviews cannot be answered
+func (u *commentUsecase) takeReview(
+	ctx context.Context,
+	commentID int,
+	errNotFound error,
+	errIsReply error,
+) (entities.Comment, error) {
+	review, err := u.commentRepo.TakeByConditions(ctx, map[string]interface{}{
+		"id":     commentID,
+		"status": entities.CommentStatusPublished,
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return entities.Comment{}, errNotFound
+		}
+		return entities.Comment{}, err
+	}
+	if review.ParentCommentID != nil {
+		return entities.Comment{}, errIsReply
+	}
+
+	return review, nil
+}
+
+// replyConditions selects the published user replies of a review, the official response is returned apart
+func replyConditions(commentID int) map[string]interface{} {
+	return map[string]interface{}{
+		"parent_comment_id": commentID,
+		"is_official":       false,
+		"status":            entities.CommentStatusPublished,
+	}
+}
@@ -0,0 +1,166 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go-server/internal/pkg/domains/interfaces"
+	"go-server/internal/pkg/domains/models/dtos"
+	"go-server/internal/pkg/domains/models/entities"
+	"go-server/internal/pkg/repositories"
+	"go-server/pkg/shared/moderation"
+
+	"gorm.io/gorm"
+)
+
+// fakeNotificationUsecase records the notifications instead of delivering them
+type fakeNotificationUsecase struct {
+	interfaces.NotificationUsecase
+	notified []entities.Notification
+}
+
+func (u *fakeNotificationUsecase) Notify(ctx context.Context, userID int, notification entities.Notification) error {
+	u.notified = append(u.notified, notification)
+	return nil
+}
+
+type commentFixture struct {
+	usecase       interfaces.CommentUsecase
+	db            *gorm.DB
+	notifications *fakeNotificationUsecase
+	author        entities.User
+	visitor       entities.User
+	place         entities.Place
+}
+
+func newTestCommentUsecase(t *testing.T, commentRepo func(interfaces.CommentRepository) interfaces.CommentRepository) commentFixture {
+	t.Helper()
+
+	db := newTestDB(t, &entities.User{}, &entities.Place{}, &entities.Comment{}, &entities.CommentImage{},
+		&entities.CommentVote{}, &entities.CommentModerationLog{})
+	logger := newTestLogger()
+	repo := repositories.NewCommentRepository(db, logger)
+	if commentRepo != nil {
+		repo = commentRepo(repo)
+	}
+	notifications := &fakeNotificationUsecase{}
+	usecase := NewCommentUsecase(
+		repo,
+		repositories.NewCommentModerationRepository(db, logger),
+		nil,
+		moderation.NewBannedWordFilter(nil),
+		notifications,
+		logger,
+	)
+
+	fixture := commentFixture{
+		usecase:       usecase,
+		db:            db,
+		notifications: notifications,
+		author:        entities.User{Username: "author", Email: "author@example.com", Active: true},
+		visitor:       entities.User{Username: "visitor", Email: "visitor@example.com", Active: true},
+		place:         entities.Place{Name: "place", Images: "|image|"},
+	}
+	db.Create(&fixture.author)
+	db.Create(&fixture.visitor)
+	db.Create(&fixture.place)
+
+	return fixture
+}
+
+func TestUnpublishedReviewsCannotBeAnswered(t *testing.T) {
+	for _, status := range []string{entities.CommentStatusHeld, entities.CommentStatusHidden} {
+		fixture := newTestCommentUsecase(t, nil)
+		review := entities.Comment{PlaceID: fixture.place.ID, UserID: fixture.author.ID, Comment: "review", Rate: 4, Status: status}
+		fixture.db.Create(&review)
+		ctx := context.Background()
+
+		_, _, err := fixture.usecase.Create(ctx, fixture.db, fixture.visitor.ID, dtos.CreateCommentRequestDto{
+			Comment:         "reply",
+			ParentCommentID: review.ID,
+		})
+		if !errors.Is(err, CreateCommentParentNotFound) {
+			t.Errorf("reply to a %s review returned %v", status, err)
+		}
+		if len(fixture.notifications.notified) != 0 {
+			t.Errorf("author of a %s review notified of %+v", status, fixture.notifications.notified)
+		}
+
+		_, err = fixture.usecase.Vote(ctx, fixture.db, fixture.visitor.ID, review.ID, dtos.VoteCommentRequestDto{Vote: entities.CommentVoteHelpful})
+		if !errors.Is(err, VoteCommentNotFound) {
+			t.Errorf("vote on a %s review returned %v", status, err)
+		}
+
+		_, _, err = fixture.usecase.FindRepliesPaginate(ctx, fixture.visitor.ID, review.ID, map[string]int{"page": 1, "per_page": 10})
+		if !errors.Is(err, ListRepliesCommentNotFound) {
+			t.Errorf("replies of a %s review returned %v", status, err)
+		}
+	}
+}
+
+func TestPublishedReviewIsAnswered(t *testing.T) {
+	fixture := newTestCommentUsecase(t, nil)
+	review := entities.Comment{PlaceID: fixture.place.ID, UserID: fixture.author.ID, Comment: "review", Rate: 4, Status: entities.CommentStatusPublished}
+	fixture.db.Create(&review)
+
+	reply, created, err := fixture.usecase.Create(context.Background(), fixture.db, fixture.visitor.ID, dtos.CreateCommentRequestDto{
+		Comment:         "reply",
+		ParentCommentID: review.ID,
+	})
+	if err != nil || !created || reply.ParentCommentID == nil || *reply.ParentCommentID != review.ID {
+		t.Fatalf("reply returned %+v, %v, %v", reply, created, err)
+	}
+	if len(fixture.notifications.notified) != 1 || fixture.notifications.notified[0].Type != entities.NotificationCommentReply {
+		t.Fatalf("author notified of %+v", fixture.notifications.notified)
+	}
+}
+
+// duplicateCommentRepository fails every insert like a review created concurrently
+type duplicateCommentRepository struct {
+	interfaces.CommentRepository
+	creates int
+}
+
+func (r *duplicateCommentRepository) CreateWithTx(tx *gorm.DB, comment entities.Comment) (entities.Comment, error) {
+	r.creates++
+	return comment, gorm.ErrDuplicatedKey
+}
+
+func TestCreateRetriesDuplicateReviewOnce(t *testing.T) {
+	repo := &duplicateCommentRepository{}
+	fixture := newTestCommentUsecase(t, func(comments interfaces.CommentRepository) interfaces.CommentRepository {
+		repo.CommentRepository = comments
+		return repo
+	})
+
+	_, _, err := fixture.usecase.Create(context.Background(), fixture.db, fixture.visitor.ID, dtos.CreateCommentRequestDto{
+		PlaceID: fixture.place.ID,
+		Comment: "review",
+		Rate:    5,
+	})
+	if !errors.Is(err, gorm.ErrDuplicatedKey) {
+		t.Fatalf("create returned %v", err)
+	}
+	if repo.creates != 2 {
+		t.Fatalf("review inserted %d times, want one retry", repo.creates)
+	}
+}
+
+func TestFindReviewsCapsEmbeddedReplies(t *testing.T) {
+	fixture := newTestCommentUsecase(t, nil)
+	review := entities.Comment{PlaceID: fixture.place.ID, UserID: fixture.author.ID, Comment: "review", Rate: 4, Status: entities.CommentStatusPublished}
+	fixture.db.Create(&review)
+	for i := 0; i < maxRepliesPerPage+5; i++ {
+		fixture.db.Create(&entities.Comment{PlaceID: fixture.place.ID, UserID: fixture.visitor.ID, Comment: "reply", ParentCommentID: &review.ID, Status: entities.CommentStatusPublished})
+	}
+
+	reviews, _, err := fixture.usecase.FindReviewsPaginate(context.Background(), fixture.visitor.ID,
+		map[string]int{"page": 1, "per_page": 10}, map[string]interface{}{"place_id": fixture.place.ID}, "id DESC", 1000000)
+	if err != nil {
+		t.Fatalf("find reviews: %v", err)
+	}
+	if len(reviews) != 1 || len(reviews[0].Replies) != maxRepliesPerPage || reviews[0].ReplyCount != maxRepliesPerPage+5 {
+		t.Fatalf("unexpected reviews %+v", reviews)
+	}
+}
@@ -0,0 +1,91 @@
+package usecases
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/schema"
+)
+
+func TestMain(m *testing.M) {
+	// the keyring is loaded once, the signed links of the tests use the HS256 secret
+	os.Setenv("JWT_KEY", "test-secret")
+	os.Setenv("JWT_SIGNING_KEYS", "")
+
+	os.Exit(m.Run())
+}
+
+// newTestDB opens an sqlite database of the test with the tables of the models
+func newTestDB(t *testing.T, models ...interface{}) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{
+		Logger:         logger.Discard,
+		TranslateError: true,
+	})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+
+	// sqlite only auto increments integer primary keys, not the bigint ones of mysql
+	for _, model := range models {
+		statement := &gorm.Statement{DB: db}
+		err = statement.Parse(model)
+		if err != nil {
+			t.Fatalf("parse %T: %v", model, err)
+		}
+		for _, field := range statement.Schema.Fields {
+			if field.AutoIncrement && field.DataType == "bigint" {
+				field.DataType = schema.Int
+			}
+		}
+	}
+
+	err = db.AutoMigrate(models...)
+	if err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	return db
+}
+
+func newTestLogger() *logrus.Logger {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return logger
+}
+
+// fakeMailService records the mails instead of sending them
+type fakeMailService struct {
+	sent []fakeMail
+}
+
+type fakeMail struct {
+	template string
+	data     map[string]interface{}
+}
+
+func (s *fakeMailService) SendMail(templateName string, subject string, data map[string]interface{}) error {
+	s.sent = append(s.sent, fakeMail{templateName, data})
+	return nil
+}
+
+// fakeSessionCloser records the sessions and users whose websockets are closed
+type fakeSessionCloser struct {
+	sessions []string
+	users    []int
+}
+
+func (s *fakeSessionCloser) CloseSession(tokenID string) {
+	s.sessions = append(s.sessions, tokenID)
+}
+
+func (s *fakeSessionCloser) CloseUser(userID int) {
+	s.users = append(s.users, userID)
+}
@@ -0,0 +1,307 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"go-server/internal/pkg/domains/interfaces"
+	"go-server/internal/pkg/services"
+	"go-server/pkg/shared/utils"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+var (
+	LoginTooManyAttempts = errors.New("Too many failed attempts, try again later")
+	LoginAccountLocked   = errors.New("Account temporarily locked after too many failed attempts")
+
+	UnlockUserNotFound = errors.New("User not found")
+
+	PasswordResetTooManyRequests = errors.New("Too many password reset requests, try again later")
+)
+
+// LoginBlockedError rejects a login attempt before the credentials are checked
+type LoginBlockedError struct {
+	Err        error
+	RetryAfter time.Duration
+}
+
+func (e *LoginBlockedError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *LoginBlockedError) Unwrap() error {
+	return e.Err
+}
+
+// loginAttemptPolicy: after BackoffAfter failures of an account each attempt waits twice as
+// long as the previous one, after LockoutAfter the account is locked for LockoutDuration.
+// An address is locked after IPLockoutAfter failures on any account
+type loginAttemptPolicy struct {
+	BackoffAfter    int
+	LockoutAfter    int
+	IPLockoutAfter  int
+	LockoutDuration time.Duration
+	// failures older than the window are forgotten
+	Window time.Duration
+	// password reset requests allowed per email and per address within ResetWindow
+	ResetEmailLimit int
+	ResetIPLimit    int
+	ResetWindow     time.Duration
+}
+
+func loginAttemptPolicyFromEnv() loginAttemptPolicy {
+	return loginAttemptPolicy{
+		BackoffAfter:    utils.IntFromEnv("LOGIN_BACKOFF_AFTER", 3),
+		LockoutAfter:    utils.IntFromEnv("LOGIN_LOCKOUT_AFTER", 10),
+		IPLockoutAfter:  utils.IntFromEnv("LOGIN_IP_LOCKOUT_AFTER", 100),
+		LockoutDuration: utils.DurationFromEnv("LOGIN_LOCKOUT_DURATION", 15*time.Minute),
+		Window:          utils.DurationFromEnv("LOGIN_FAILURE_WINDOW", time.Hour),
+		ResetEmailLimit: utils.IntFromEnv("PASSWORD_RESET_EMAIL_LIMIT", 3),
+		ResetIPLimit:    utils.IntFromEnv("PASSWORD_RESET_IP_LIMIT", 20),
+		ResetWindow:     utils.DurationFromEnv("PASSWORD_RESET_WINDOW", time.Hour),
+	}
+}
+
+func (p loginAttemptPolicy) backoff(failures int) time.Duration {
+	if failures < p.BackoffAfter {
+		return 0
+	}
+
+	shift := failures - p.BackoffAfter
+	if shift > 16 {
+		shift = 16
+	}
+	delay := time.Second << shift
+	if delay > p.LockoutDuration {
+		return p.LockoutDuration
+	}
+	return delay
+}
+
+type loginAttemptUsecase struct {
+	store       interfaces.LoginAttemptStore
+	userRepo    interfaces.UserRepository
+	mailService services.MailServiceInterface
+	policy      loginAttemptPolicy
+	logger      *logrus.Logger
+}
+
+func NewLoginAttemptUsecase(
+	store interfaces.LoginAttemptStore,
+	userRepo interfaces.UserRepository,
+	mailService services.MailServiceInterface,
+	logger *logrus.Logger,
+) interfaces.LoginAttemptUsecase {
+	return &loginAttemptUsecase{
+		store,
+		userRepo,
+		mailService,
+		loginAttemptPolicyFromEnv(),
+		logger,
+	}
+}
+
+// Check rejects the attempt with a *LoginBlockedError while the account or the address
+// is locked or backing off
+func (u *loginAttemptUsecase) Check(
+	ctx context.Context,
+	email string,
+	ip string,
+) error {
+	now := time.Now()
+
+	account, err := u.store.Take(ctx, accountAttemptKey(email))
+	if err != nil {
+		return err
+	}
+	if account.LockedUntil != nil && account.LockedUntil.After(now) {
+		return &LoginBlockedError{Err: LoginAccountLocked, RetryAfter: account.LockedUntil.Sub(now)}
+	}
+	if account.LastFailureAt != nil && account.LastFailureAt.After(now.Add(-u.policy.Window)) {
+		next := account.LastFailureAt.Add(u.policy.backoff(account.Failures))
+		if next.After(now) {
+			return &LoginBlockedError{Err: LoginTooManyAttempts, RetryAfter: next.Sub(now)}
+		}
+	}
+
+	if ip == "" {
+		return nil
+	}
+	address, err := u.store.Take(ctx, ipAttemptKey(ip))
+	if err != nil {
+		return err
+	}
+	if address.LockedUntil != nil && address.LockedUntil.After(now) {
+		return &LoginBlockedError{Err: LoginTooManyAttempts, RetryAfter: address.LockedUntil.Sub(now)}
+	}
+
+	return nil
+}
+
+// RegisterFailure counts a wrong email or password and locks the account or the address
+// past the thresholds, the owner of the account is emailed when it gets locked
+func (u *loginAttemptUsecase) RegisterFailure(
+	ctx context.Context,
+	email string,
+	ip string,
+) error {
+	now := time.Now()
+	since := now.Add(-u.policy.Window)
+
+	account, err := u.store.AddFailure(ctx, accountAttemptKey(email), since)
+	if err != nil {
+		return err
+	}
+	if account.Failures >= u.policy.LockoutAfter {
+		lockedUntil := now.Add(u.policy.LockoutDuration)
+		err = u.store.Lock(ctx, account.Key, lockedUntil)
+		if err != nil {
+			return err
+		}
+		// once per lockout, later failures only extend it
+		if account.Failures == u.policy.LockoutAfter {
+			u.notifyLockout(ctx, email, lockedUntil)
+		}
+	}
+
+	if ip == "" {
+		return nil
+	}
+	address, err := u.store.AddFailure(ctx, ipAttemptKey(ip), since)
+	if err != nil {
+		return err
+	}
+	if address.Failures >= u.policy.IPLockoutAfter {
+		return u.store.Lock(ctx, address.Key, now.Add(u.policy.LockoutDuration))
+	}
+
+	return nil
+}
+
+// RegisterSuccess clears the counters of the account, the ones of the address are kept
+// so that logging into an own account does not lift them
+func (u *loginAttemptUsecase) RegisterSuccess(
+	ctx context.Context,
+	email string,
+) error {
+	return u.store.Reset(ctx, accountAttemptKey(email))
+}
+
+// Lock locks the account right away for the lockout duration, when its second factor failed
+// too often, and emails the owner
+func (u *loginAttemptUsecase) Lock(
+	ctx context.Context,
+	email string,
+) error {
+	now := time.Now()
+	lockedUntil := now.Add(u.policy.LockoutDuration)
+
+	// the store only locks existing counters, the failure creates them
+	account, err := u.store.AddFailure(ctx, accountAttemptKey(email), now.Add(-u.policy.Window))
+	if err != nil {
+		return err
+	}
+	err = u.store.Lock(ctx, account.Key, lockedUntil)
+	if err != nil {
+		return err
+	}
+
+	u.notifyLockout(ctx, email, lockedUntil)
+	return nil
+}
+
+// Unlock lifts the lockout of the account of the user, from the CMS
+func (u *loginAttemptUsecase) Unlock(
+	ctx context.Context,
+	userID int,
+) error {
+	user, err := u.userRepo.TakeByConditions(ctx, map[string]interface{}{
+		"id": userID,
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return UnlockUserNotFound
+		}
+		return err
+	}
+
+	return u.store.Reset(ctx, accountAttemptKey(user.Email))
+}
+
+// RegisterPasswordReset counts a password reset request of the email from the address and
+// rejects it with a *LoginBlockedError past the limits, known and unknown emails alike
+func (u *loginAttemptUsecase) RegisterPasswordReset(
+	ctx context.Context,
+	email string,
+	ip string,
+) error {
+	err := u.countPasswordReset(ctx, passwordResetAttemptKey(accountAttemptKey(email)), u.policy.ResetEmailLimit)
+	if err != nil || ip == "" {
+		return err
+	}
+
+	return u.countPasswordReset(ctx, passwordResetAttemptKey(ipAttemptKey(ip)), u.policy.ResetIPLimit)
+}
+
+func (u *loginAttemptUsecase) countPasswordReset(
+	ctx context.Context,
+	key string,
+	limit int,
+) error {
+	now := time.Now()
+
+	attempt, err := u.store.Take(ctx, key)
+	if err != nil {
+		return err
+	}
+	if attempt.Failures >= limit && attempt.LastFailureAt != nil {
+		resetAt := attempt.LastFailureAt.Add(u.policy.ResetWindow)
+		if resetAt.After(now) {
+			return &LoginBlockedError{Err: PasswordResetTooManyRequests, RetryAfter: resetAt.Sub(now)}
+		}
+	}
+
+	_, err = u.store.AddFailure(ctx, key, now.Add(-u.policy.ResetWindow))
+	return err
+}
+
+// notifyLockout mails the owner of the account, failures are only logged
+func (u *loginAttemptUsecase) notifyLockout(
+	ctx context.Context,
+	email string,
+	lockedUntil time.Time,
+) {
+	// the failures are counted on the trimmed email, see accountAttemptKey
+	user, err := u.userRepo.TakeByConditions(ctx, map[string]interface{}{
+		"email": strings.TrimSpace(email),
+	})
+	if err != nil {
+		// unknown emails are counted too, there is nobody to notify
+		return
+	}
+
+	err = u.mailService.SendMail("account_locked_template.html", "Your account has been temporarily locked", map[string]interface{}{
+		"to":           user.Email,
+		"username":     user.Username,
+		"locked_until": lockedUntil.Format("15:04 02/01/2006 MST"),
+		"year":         time.Now().Year(),
+	})
+	if err != nil {
+		u.logger.Errorf("send lockout email to user %d failed: %v", user.ID, err)
+	}
+}
+
+func accountAttemptKey(email string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func ipAttemptKey(ip string) string {
+	return "ip:" + ip
+}
+
+func passwordResetAttemptKey(key string) string {
+	return "password_reset:" + key
+}
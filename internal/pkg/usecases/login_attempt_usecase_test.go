@@ -0,0 +1,205 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go-server/internal/pkg/domains/interfaces"
+	"go-server/internal/pkg/domains/models/entities"
+	"go-server/internal/pkg/repositories"
+
+	"gorm.io/gorm"
+)
+
+func newTestLoginAttemptUsecase(t *testing.T, env map[string]string) (interfaces.LoginAttemptUsecase, *fakeMailService, *gorm.DB) {
+	t.Helper()
+
+	for key, value := range env {
+		t.Setenv(key, value)
+	}
+	db := newTestDB(t, &entities.User{})
+	logger := newTestLogger()
+	mailService := &fakeMailService{}
+	usecase := NewLoginAttemptUsecase(
+		repositories.NewMemoryLoginAttemptStore(),
+		repositories.NewUserRepository(db, logger),
+		mailService,
+		logger,
+	)
+
+	return usecase, mailService, db
+}
+
+func failLogins(t *testing.T, usecase interfaces.LoginAttemptUsecase, email string, ip string, n int) {
+	t.Helper()
+
+	for i := 0; i < n; i++ {
+		err := usecase.RegisterFailure(context.Background(), email, ip)
+		if err != nil {
+			t.Fatalf("register failure: %v", err)
+		}
+	}
+}
+
+func TestLoginBackoff(t *testing.T) {
+	policy := loginAttemptPolicy{BackoffAfter: 3, LockoutDuration: 15 * time.Minute}
+
+	for failures, want := range map[int]time.Duration{
+		2:  0,
+		3:  time.Second,
+		5:  4 * time.Second,
+		30: 15 * time.Minute,
+	} {
+		if got := policy.backoff(failures); got != want {
+			t.Errorf("backoff after %d failures is %v, want %v", failures, got, want)
+		}
+	}
+}
+
+func TestLoginBacksOffAfterFailures(t *testing.T) {
+	usecase, _, _ := newTestLoginAttemptUsecase(t, map[string]string{
+		"LOGIN_BACKOFF_AFTER": "1",
+		"LOGIN_LOCKOUT_AFTER": "10",
+	})
+	ctx := context.Background()
+
+	failLogins(t, usecase, "owner@example.com", "", 1)
+
+	var blocked *LoginBlockedError
+	err := usecase.Check(ctx, "owner@example.com", "")
+	if !errors.As(err, &blocked) || !errors.Is(err, LoginTooManyAttempts) || blocked.RetryAfter > time.Second {
+		t.Fatalf("check returned %v", err)
+	}
+
+	err = usecase.RegisterSuccess(ctx, "owner@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = usecase.Check(ctx, "owner@example.com", "")
+	if err != nil {
+		t.Fatalf("check after a success returned %v", err)
+	}
+}
+
+func TestLoginLocksAccount(t *testing.T) {
+	usecase, mailService, db := newTestLoginAttemptUsecase(t, map[string]string{
+		"LOGIN_BACKOFF_AFTER":    "100",
+		"LOGIN_LOCKOUT_AFTER":    "3",
+		"LOGIN_LOCKOUT_DURATION": "15m",
+	})
+	ctx := context.Background()
+	user := entities.User{Username: "owner", Email: "owner@example.com", Password: "hash", Active: true}
+	db.Create(&user)
+
+	failLogins(t, usecase, "owner@example.com", "", 2)
+	err := usecase.Check(ctx, "owner@example.com", "")
+	if err != nil {
+		t.Fatalf("check before the lockout returned %v", err)
+	}
+
+	// the key ignores the spaces and the case of the email
+	failLogins(t, usecase, " owner@example.com ", "", 2)
+	err = usecase.Check(ctx, "Owner@Example.com", "")
+	if !errors.Is(err, LoginAccountLocked) {
+		t.Fatalf("check of the locked account returned %v", err)
+	}
+	if len(mailService.sent) != 1 || mailService.sent[0].template != "account_locked_template.html" {
+		t.Fatalf("lockout notified %+v, want one mail", mailService.sent)
+	}
+
+	err = usecase.Unlock(ctx, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = usecase.Check(ctx, "owner@example.com", "")
+	if err != nil {
+		t.Fatalf("check of the unlocked account returned %v", err)
+	}
+	if !errors.Is(usecase.Unlock(ctx, user.ID+1), UnlockUserNotFound) {
+		t.Fatal("unlock of an unknown user succeeded")
+	}
+}
+
+func TestLoginLocksAddress(t *testing.T) {
+	usecase, _, _ := newTestLoginAttemptUsecase(t, map[string]string{
+		"LOGIN_BACKOFF_AFTER":    "100",
+		"LOGIN_LOCKOUT_AFTER":    "100",
+		"LOGIN_IP_LOCKOUT_AFTER": "3",
+	})
+	ctx := context.Background()
+
+	failLogins(t, usecase, "first@example.com", "203.0.113.1", 2)
+	failLogins(t, usecase, "second@example.com", "203.0.113.1", 1)
+
+	err := usecase.Check(ctx, "third@example.com", "203.0.113.1")
+	if !errors.Is(err, LoginTooManyAttempts) {
+		t.Fatalf("check from the locked address returned %v", err)
+	}
+	err = usecase.Check(ctx, "third@example.com", "203.0.113.2")
+	if err != nil {
+		t.Fatalf("check from another address returned %v", err)
+	}
+}
+
+func TestPasswordResetLimits(t *testing.T) {
+	usecase, _, _ := newTestLoginAttemptUsecase(t, map[string]string{
+		"PASSWORD_RESET_EMAIL_LIMIT": "2",
+		"PASSWORD_RESET_IP_LIMIT":    "3",
+		"PASSWORD_RESET_WINDOW":      "1h",
+	})
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		err := usecase.RegisterPasswordReset(ctx, "owner@example.com", "203.0.113.1")
+		if err != nil {
+			t.Fatalf("request %d returned %v", i+1, err)
+		}
+	}
+	var blocked *LoginBlockedError
+	err := usecase.RegisterPasswordReset(ctx, "Owner@example.com", "203.0.113.2")
+	if !errors.As(err, &blocked) || !errors.Is(err, PasswordResetTooManyRequests) || blocked.RetryAfter <= 0 {
+		t.Fatalf("request past the email limit returned %v", err)
+	}
+
+	// unknown emails count against the address like known ones
+	err = usecase.RegisterPasswordReset(ctx, "nobody@example.com", "203.0.113.1")
+	if err != nil {
+		t.Fatalf("third request of the address returned %v", err)
+	}
+	err = usecase.RegisterPasswordReset(ctx, "someone@example.com", "203.0.113.1")
+	if !errors.Is(err, PasswordResetTooManyRequests) {
+		t.Fatalf("request past the address limit returned %v", err)
+	}
+
+	// the login counters are separate
+	err = usecase.Check(ctx, "owner@example.com", "203.0.113.1")
+	if err != nil {
+		t.Fatalf("login check returned %v", err)
+	}
+}
+
+func TestLoginAttemptRepositoryForgetsFailuresBeforeTheWindow(t *testing.T) {
+	db := newTestDB(t, &entities.LoginAttempt{})
+	store := repositories.NewLoginAttemptRepository(db, newTestLogger())
+	ctx := context.Background()
+
+	for want := 1; want <= 2; want++ {
+		attempt, err := store.AddFailure(ctx, "account:owner@example.com", time.Now().Add(-time.Hour))
+		if err != nil {
+			t.Fatalf("add failure: %v", err)
+		}
+		if attempt.Failures != want {
+			t.Fatalf("%d failures counted, want %d", attempt.Failures, want)
+		}
+	}
+
+	attempt, err := store.AddFailure(ctx, "account:owner@example.com", time.Now().Add(time.Second))
+	if err != nil {
+		t.Fatalf("add failure: %v", err)
+	}
+	if attempt.Failures != 1 {
+		t.Fatalf("%d failures counted past the window, want 1", attempt.Failures)
+	}
+}
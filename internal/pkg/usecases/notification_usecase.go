@@ -0,0 +1,241 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"go-server/internal/pkg/domains/interfaces"
+	"go-server/internal/pkg/domains/models/dtos"
+	"go-server/internal/pkg/domains/models/entities"
+	"go-server/internal/pkg/services"
+	"go-server/pkg/shared/realtime"
+	"sort"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+var (
+	NotificationNotFound              = errors.New("Notification not found")
+	NotificationPreferenceTypeInvalid = errors.New("Unknown notification type")
+)
+
+type notificationUsecase struct {
+	notificationRepo interfaces.NotificationRepository
+	userRepo         interfaces.UserRepository
+	mailService      services.MailServiceInterface
+	publisher        realtime.Publisher
+	logger           *logrus.Logger
+}
+
+func NewNotificationUsecase(
+	notificationRepo interfaces.NotificationRepository,
+	userRepo interfaces.UserRepository,
+	mailService services.MailServiceInterface,
+	publisher realtime.Publisher,
+	logger *logrus.Logger,
+) interfaces.NotificationUsecase {
+	return &notificationUsecase{
+		notificationRepo,
+		userRepo,
+		mailService,
+		publisher,
+		logger,
+	}
+}
+
+func (u *notificationUsecase) Notify(
+	ctx context.Context,
+	userID int,
+	notification entities.Notification,
+) error {
+	preference, err := u.preference(ctx, userID, notification.Type)
+	if err != nil {
+		return err
+	}
+
+	if preference.InApp {
+		notification.UserID = userID
+		notification, err = u.notificationRepo.Create(ctx, notification)
+		if err != nil {
+			return err
+		}
+		u.publisher.Publish(realtime.UserTopic(userID), realtime.EventNotificationCreated, notification)
+	}
+	if !preference.Email {
+		return nil
+	}
+
+	user, err := u.userRepo.TakeByConditions(ctx, map[string]interface{}{
+		"id": userID,
+	})
+	if err != nil {
+		return err
+	}
+	// only mail addresses the user proved to own
+	if user.EmailVerifiedAt == nil {
+		return nil
+	}
+
+	return u.mailService.SendMail("notification_template.html", notification.Title, map[string]interface{}{
+		"to":       user.Email,
+		"username": user.Username,
+		"title":    notification.Title,
+		"body":     notification.Body,
+		"link":     appURL(),
+		"year":     time.Now().Year(),
+	})
+}
+
+func (u *notificationUsecase) FindPaginate(
+	ctx context.Context,
+	userID int,
+	pageData map[string]int,
+	unreadOnly bool,
+) ([]entities.Notification, int64, error) {
+	return u.notificationRepo.FindPaginate(ctx, userID, pageData, unreadOnly)
+}
+
+func (u *notificationUsecase) CountUnread(
+	ctx context.Context,
+	userID int,
+) (int64, error) {
+	return u.notificationRepo.CountUnread(ctx, userID)
+}
+
+func (u *notificationUsecase) MarkRead(
+	ctx context.Context,
+	userID int,
+	notificationID int,
+) (entities.Notification, error) {
+	notification, err := u.notificationRepo.TakeByConditions(ctx, map[string]interface{}{
+		"id":      notificationID,
+		"user_id": userID,
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return entities.Notification{}, NotificationNotFound
+		}
+		return entities.Notification{}, err
+	}
+	if notification.ReadAt != nil {
+		return notification, nil
+	}
+
+	_, err = u.notificationRepo.MarkRead(ctx, userID, []int{notification.ID})
+	if err != nil {
+		return entities.Notification{}, err
+	}
+
+	now := time.Now()
+	notification.ReadAt = &now
+	return notification, nil
+}
+
+func (u *notificationUsecase) MarkAllRead(
+	ctx context.Context,
+	userID int,
+) (int64, error) {
+	return u.notificationRepo.MarkRead(ctx, userID, nil)
+}
+
+// FindPreferences lists the preference of the user for every type, defaults included
+func (u *notificationUsecase) FindPreferences(
+	ctx context.Context,
+	userID int,
+) ([]entities.NotificationPreference, error) {
+	stored, err := u.notificationRepo.FindPreferences(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	byType := make(map[string]entities.NotificationPreference, len(stored))
+	for _, preference := range stored {
+		byType[preference.Type] = preference
+	}
+
+	preferences := make([]entities.NotificationPreference, 0, len(entities.NotificationTypes))
+	for notificationType, preference := range entities.NotificationTypes {
+		if storedPreference, ok := byType[notificationType]; ok {
+			preference = storedPreference
+		}
+		preference.UserID = userID
+		preference.Type = notificationType
+		preferences = append(preferences, preference)
+	}
+	sort.Slice(preferences, func(i, j int) bool {
+		return preferences[i].Type < preferences[j].Type
+	})
+
+	return preferences, nil
+}
+
+func (u *notificationUsecase) UpdatePreferences(
+	ctx context.Context,
+	userID int,
+	req dtos.UpdateNotificationPreferencesRequestDto,
+) ([]entities.NotificationPreference, error) {
+	preferences := make([]entities.NotificationPreference, 0, len(req.Preferences))
+	for _, preference := range req.Preferences {
+		if _, ok := entities.NotificationTypes[preference.Type]; !ok {
+			return nil, NotificationPreferenceTypeInvalid
+		}
+
+		preferences = append(preferences, entities.NotificationPreference{
+			UserID: userID,
+			Type:   preference.Type,
+			InApp:  *preference.InApp,
+			Email:  *preference.Email,
+		})
+	}
+
+	err := u.notificationRepo.UpsertPreferences(ctx, preferences)
+	if err != nil {
+		return nil, err
+	}
+
+	return u.FindPreferences(ctx, userID)
+}
+
+func (u *notificationUsecase) preference(
+	ctx context.Context,
+	userID int,
+	notificationType string,
+) (entities.NotificationPreference, error) {
+	preference, ok := entities.NotificationTypes[notificationType]
+	if !ok {
+		return entities.NotificationPreference{}, NotificationPreferenceTypeInvalid
+	}
+
+	stored, err := u.notificationRepo.FindPreferences(ctx, userID)
+	if err != nil {
+		return entities.NotificationPreference{}, err
+	}
+	for _, storedPreference := range stored {
+		if storedPreference.Type == notificationType {
+			return storedPreference, nil
+		}
+	}
+
+	return preference, nil
+}
+
+// notify delivers a notification on behalf of another feature, whose action already
+// succeeded and must not fail because of it
+func notify(
+	ctx context.Context,
+	notificationUsecase interfaces.NotificationUsecase,
+	logger *logrus.Logger,
+	userID int,
+	notification entities.Notification,
+) {
+	// anonymized comments have no author left to notify
+	if userID == 0 {
+		return
+	}
+
+	err := notificationUsecase.Notify(ctx, userID, notification)
+	if err != nil {
+		logger.Errorf("notify user %d of %s failed: %v", userID, notification.Type, err)
+	}
+}
@@ -0,0 +1,259 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-server/internal/pkg/domains/interfaces"
+	"go-server/internal/pkg/domains/models/dtos"
+	"go-server/internal/pkg/domains/models/entities"
+	"go-server/internal/pkg/services"
+	"go-server/pkg/shared/database"
+	"go-server/pkg/shared/realtime"
+	"go-server/pkg/shared/utils"
+	"math/rand"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+var (
+	OAuthEmailNotVerified = errors.New("Email not verified by the identity provider")
+	OAuthUserNotActive    = errors.New("User not active")
+	OAuthEmailInUse       = errors.New("Email already used by an account, log in with it instead")
+
+	OAuthEmailVerificationPending = errors.New("Email not verified, follow the link sent to it")
+)
+
+const maxUsernameLength = 20
+
+var nonAlphaNumeric = regexp.MustCompile(`[^a-zA-Z0-9]`)
+
+type oauthUsecase struct {
+	userRepo         interfaces.UserRepository
+	userIdentityRepo interfaces.UserIdentityRepository
+	userTokenRepo    interfaces.UserTokenRepository
+	mailService      services.MailServiceInterface
+	sessions         realtime.SessionCloser
+	logger           *logrus.Logger
+}
+
+func NewOAuthUsecase(
+	userRepo interfaces.UserRepository,
+	userIdentityRepo interfaces.UserIdentityRepository,
+	userTokenRepo interfaces.UserTokenRepository,
+	mailService services.MailServiceInterface,
+	sessions realtime.SessionCloser,
+	logger *logrus.Logger,
+) interfaces.OAuthUsecase {
+	return &oauthUsecase{
+		userRepo,
+		userIdentityRepo,
+		userTokenRepo,
+		mailService,
+		sessions,
+		logger,
+	}
+}
+
+// Login returns the user linked to the identity. A new identity is linked to a newly created
+// user, only when the provider verified the email, or to the user of the same email when the
+// provider is also trusted with it (see OAuthIdentityDto.TrustEmail). The session is started
+// by TokenUsecase.Issue like a password login, and refused like one while the email of a user
+// created through an untrusted provider is not verified by the link mailed to it
+func (u *oauthUsecase) Login(
+	ctx context.Context,
+	db *gorm.DB,
+	identity dtos.OAuthIdentityDto,
+) (entities.User, error) {
+	linked, err := u.userIdentityRepo.TakeByConditions(ctx, map[string]interface{}{
+		"provider": identity.Provider,
+		"subject":  identity.Subject,
+	})
+	if err == nil {
+		user, err := u.userRepo.TakeByConditions(ctx, map[string]interface{}{
+			"id": linked.UserID,
+		})
+		if err != nil {
+			return entities.User{}, err
+		}
+		if !user.Active {
+			return entities.User{}, OAuthUserNotActive
+		}
+		if verificationPending(user) {
+			return entities.User{}, OAuthEmailVerificationPending
+		}
+		return user, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return entities.User{}, err
+	}
+
+	if identity.Email == "" || !identity.EmailVerified {
+		return entities.User{}, OAuthEmailNotVerified
+	}
+
+	user, err := u.userRepo.TakeByConditions(ctx, map[string]interface{}{
+		"email": identity.Email,
+	})
+	exists := err == nil
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return entities.User{}, err
+	}
+	if exists && !identity.TrustEmail {
+		return entities.User{}, OAuthEmailInUse
+	}
+	if exists && !user.Active {
+		return entities.User{}, OAuthUserNotActive
+	}
+	// linking an unverified user drops its sessions, see linkUserWithTx
+	dropsSessions := exists && !user.EmailVerified
+
+	err = database.Transaction(ctx, db, func(tx *gorm.DB) error {
+		var err error
+		if exists {
+			user, err = u.linkUserWithTx(tx, user)
+		} else {
+			user, err = u.createUserWithTx(ctx, tx, identity)
+		}
+		if err != nil {
+			return err
+		}
+
+		_, err = u.userIdentityRepo.CreateWithTx(tx, entities.UserIdentity{
+			UserID:   user.ID,
+			Provider: identity.Provider,
+			Subject:  identity.Subject,
+			Email:    identity.Email,
+		})
+		return err
+	})
+	if err != nil {
+		return entities.User{}, err
+	}
+	if dropsSessions {
+		u.sessions.CloseUser(user.ID)
+	}
+	if !user.EmailVerified {
+		// the account is created anyway, the user can ask for a new link
+		err = sendVerificationEmail(ctx, u.userRepo, u.mailService, user)
+		if err != nil {
+			u.logger.Errorf("send verification email to user %d failed: %v", user.ID, err)
+		}
+	}
+	if verificationPending(user) {
+		return entities.User{}, OAuthEmailVerificationPending
+	}
+
+	return user, nil
+}
+
+// verificationPending tells whether the login is refused until the email is verified, admins
+// are created from the CMS and are never blocked, see UserUsecase.Login
+func verificationPending(user entities.User) bool {
+	return EmailVerificationRequired() && !user.EmailVerified && !user.IsAdmin
+}
+
+// linkUserWithTx prepares an existing user for its first provider login. When the email of the
+// user was never verified, whoever registered it may not own it: its password and sessions are
+// dropped so that only the owner of the email, proven by the provider, keeps access
+func (u *oauthUsecase) linkUserWithTx(
+	tx *gorm.DB,
+	user entities.User,
+) (entities.User, error) {
+	if user.EmailVerified {
+		return user, nil
+	}
+
+	password, err := randomPasswordHash()
+	if err != nil {
+		return entities.User{}, err
+	}
+
+	now := time.Now()
+	user, err = u.userRepo.UpdateColumnsWithTx(tx, user, map[string]interface{}{
+		"password":          password,
+		"email_verified_at": &now,
+	})
+	if err != nil {
+		return entities.User{}, err
+	}
+	user.EmailVerified = true
+
+	err = u.userTokenRepo.DeleteByConditionsWithTx(tx, map[string]interface{}{
+		"user_id": user.ID,
+	})
+	return user, err
+}
+
+func (u *oauthUsecase) createUserWithTx(
+	ctx context.Context,
+	tx *gorm.DB,
+	identity dtos.OAuthIdentityDto,
+) (entities.User, error) {
+	username, err := u.availableUsername(ctx, identity.Email)
+	if err != nil {
+		return entities.User{}, err
+	}
+
+	// the user logs in with the provider, a password can be set later by ForgotPassword
+	password, err := randomPasswordHash()
+	if err != nil {
+		return entities.User{}, err
+	}
+
+	user := entities.User{
+		Username: username,
+		Email:    identity.Email,
+		Password: password,
+		Avatar:   identity.Avatar,
+		Active:   true,
+	}
+	// the email of an untrusted provider is verified by the link Login mails, like a registration
+	if identity.TrustEmail {
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+	}
+
+	return u.userRepo.CreateWithTx(tx, user)
+}
+
+// availableUsername derives an alphanumeric username from the local part of the email,
+// suffixed by random digits when it is taken
+func (u *oauthUsecase) availableUsername(ctx context.Context, email string) (string, error) {
+	base := nonAlphaNumeric.ReplaceAllString(strings.SplitN(email, "@", 2)[0], "")
+	if base == "" {
+		base = "user"
+	}
+	if len(base) > maxUsernameLength-4 {
+		base = base[:maxUsernameLength-4]
+	}
+
+	username := base
+	for attempt := 0; attempt < 5; attempt++ {
+		_, err := u.userRepo.TakeByConditions(ctx, map[string]interface{}{
+			"username": username,
+		})
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return username, nil
+		}
+		if err != nil {
+			return "", err
+		}
+		username = fmt.Sprintf("%s%04d", base, rand.Intn(10000))
+	}
+
+	return "", fmt.Errorf("no available username for %s", base)
+}
+
+func randomPasswordHash() (string, error) {
+	password, err := utils.GeneratePassword(32)
+	if err != nil {
+		return "", err
+	}
+
+	return utils.HashPassword(password)
+}
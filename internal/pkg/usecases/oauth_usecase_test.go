@@ -0,0 +1,276 @@
+package usecases
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go-server/internal/pkg/domains/interfaces"
+	"go-server/internal/pkg/domains/models/dtos"
+	"go-server/internal/pkg/domains/models/entities"
+	"go-server/internal/pkg/repositories"
+	"go-server/internal/pkg/services"
+	"go-server/pkg/shared/utils"
+
+	"gorm.io/gorm"
+)
+
+// fakeGoogle stands in for the token and userinfo endpoints of google through the
+// GOOGLE_TOKEN_URL and GOOGLE_USERINFO_URL overrides
+func fakeGoogle(t *testing.T, userInfo map[string]interface{}) *services.GoogleOAuthService {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("code") != "code" {
+			http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": "access",
+			"token_type":   "Bearer",
+			"expires_in":   3600,
+		})
+	})
+	mux.HandleFunc("/userinfo", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer access" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(userInfo)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	t.Setenv("GOOGLE_CLIENT_ID", "client")
+	t.Setenv("GOOGLE_CLIENT_SECRET", "secret")
+	t.Setenv("GOOGLE_AUTH_URL", server.URL+"/auth")
+	t.Setenv("GOOGLE_TOKEN_URL", server.URL+"/token")
+	t.Setenv("GOOGLE_USERINFO_URL", server.URL+"/userinfo")
+
+	return services.NewGoogleOAuthService(utils.SetupConfig(), utils.GoogleUserInfoURL())
+}
+
+func newTestOAuthUsecase(t *testing.T) (interfaces.OAuthUsecase, *gorm.DB, *fakeSessionCloser, *fakeMailService) {
+	t.Helper()
+
+	db := newTestDB(t, &entities.User{}, &entities.UserIdentity{}, &entities.UserToken{})
+	logger := newTestLogger()
+	sessions := &fakeSessionCloser{}
+	mailService := &fakeMailService{}
+	usecase := NewOAuthUsecase(
+		repositories.NewUserRepository(db, logger),
+		repositories.NewUserIdentityRepository(db, logger),
+		repositories.NewUserTokenRepository(db, logger),
+		mailService,
+		sessions,
+		logger,
+	)
+
+	return usecase, db, sessions, mailService
+}
+
+func googleLogin(t *testing.T, usecase interfaces.OAuthUsecase, db *gorm.DB, userInfo map[string]interface{}) (entities.User, error) {
+	t.Helper()
+
+	identity, err := fakeGoogle(t, userInfo).FetchIdentity(context.Background(), "code")
+	if err != nil {
+		t.Fatalf("fetch identity: %v", err)
+	}
+
+	return usecase.Login(context.Background(), db, identity)
+}
+
+func TestOAuthLoginCreatesUser(t *testing.T) {
+	usecase, db, _, _ := newTestOAuthUsecase(t)
+	userInfo := map[string]interface{}{
+		"id":             "google-1",
+		"email":          "new.user@example.com",
+		"verified_email": true,
+		"picture":        "https://example.com/avatar.png",
+	}
+
+	user, err := googleLogin(t, usecase, db, userInfo)
+	if err != nil {
+		t.Fatalf("login: %v", err)
+	}
+	if user.ID == 0 || user.Username != "newuser" || !user.Active || user.EmailVerifiedAt == nil {
+		t.Fatalf("unexpected user %+v", user)
+	}
+
+	var identity entities.UserIdentity
+	err = db.Where("provider = ? AND subject = ?", services.GoogleProvider, "google-1").Take(&identity).Error
+	if err != nil || identity.UserID != user.ID {
+		t.Fatalf("identity not linked: %+v, %v", identity, err)
+	}
+
+	again, err := googleLogin(t, usecase, db, userInfo)
+	if err != nil || again.ID != user.ID {
+		t.Fatalf("second login returned %+v, %v", again, err)
+	}
+}
+
+func TestOAuthLoginLinksUnverifiedUser(t *testing.T) {
+	usecase, db, closed, _ := newTestOAuthUsecase(t)
+	existing := entities.User{Username: "owner", Email: "owner@example.com", Password: "hash", Active: true}
+	db.Create(&existing)
+	db.Create(&entities.UserToken{UserID: existing.ID, TokenID: "session"})
+
+	user, err := googleLogin(t, usecase, db, map[string]interface{}{
+		"id":             "google-2",
+		"email":          "owner@example.com",
+		"verified_email": true,
+	})
+	if err != nil {
+		t.Fatalf("login: %v", err)
+	}
+	if user.ID != existing.ID || !user.EmailVerified {
+		t.Fatalf("unexpected user %+v", user)
+	}
+
+	var stored entities.User
+	db.Take(&stored, existing.ID)
+	if stored.Password == "hash" || stored.EmailVerifiedAt == nil {
+		t.Fatalf("password of the unverified account kept: %+v", stored)
+	}
+	var sessions int64
+	db.Model(&entities.UserToken{}).Where("user_id = ?", existing.ID).Count(&sessions)
+	if sessions != 0 {
+		t.Fatalf("%d sessions of the unverified account kept", sessions)
+	}
+	if len(closed.users) != 1 || closed.users[0] != existing.ID {
+		t.Fatalf("websockets of the unverified account not closed: %v", closed.users)
+	}
+}
+
+func TestOAuthLoginKeepsVerifiedUser(t *testing.T) {
+	usecase, db, closed, _ := newTestOAuthUsecase(t)
+	now := time.Now()
+	existing := entities.User{Username: "owner", Email: "owner@example.com", Password: "hash", Active: true, EmailVerifiedAt: &now}
+	db.Create(&existing)
+	db.Create(&entities.UserToken{UserID: existing.ID, TokenID: "session"})
+
+	user, err := googleLogin(t, usecase, db, map[string]interface{}{
+		"id":             "google-3",
+		"email":          "owner@example.com",
+		"verified_email": true,
+	})
+	if err != nil || user.ID != existing.ID {
+		t.Fatalf("login returned %+v, %v", user, err)
+	}
+
+	var stored entities.User
+	db.Take(&stored, existing.ID)
+	var sessions int64
+	db.Model(&entities.UserToken{}).Where("user_id = ?", existing.ID).Count(&sessions)
+	if stored.Password != "hash" || sessions != 1 {
+		t.Fatalf("verified account changed: password %q, %d sessions", stored.Password, sessions)
+	}
+	if len(closed.users) != 0 {
+		t.Fatalf("websockets of the verified account closed: %v", closed.users)
+	}
+}
+
+func TestOAuthLoginRejectsUnverifiedEmail(t *testing.T) {
+	usecase, db, _, _ := newTestOAuthUsecase(t)
+
+	_, err := googleLogin(t, usecase, db, map[string]interface{}{
+		"id":             "google-4",
+		"email":          "unverified@example.com",
+		"verified_email": false,
+	})
+	if !errors.Is(err, OAuthEmailNotVerified) {
+		t.Fatalf("got %v, want OAuthEmailNotVerified", err)
+	}
+
+	var users int64
+	db.Model(&entities.User{}).Count(&users)
+	if users != 0 {
+		t.Fatalf("%d users created", users)
+	}
+}
+
+func TestOAuthLoginRejectsInactiveUser(t *testing.T) {
+	usecase, db, _, _ := newTestOAuthUsecase(t)
+	now := time.Now()
+	inactive := entities.User{Username: "inactive", Email: "inactive@example.com", Password: "hash", EmailVerifiedAt: &now}
+	db.Create(&inactive)
+	db.Create(&entities.UserIdentity{UserID: inactive.ID, Provider: services.GoogleProvider, Subject: "google-linked"})
+
+	for _, subject := range []string{"google-linked", "google-new"} {
+		_, err := googleLogin(t, usecase, db, map[string]interface{}{
+			"id":             subject,
+			"email":          "inactive@example.com",
+			"verified_email": true,
+		})
+		if !errors.Is(err, OAuthUserNotActive) {
+			t.Fatalf("%s: got %v, want OAuthUserNotActive", subject, err)
+		}
+	}
+}
+
+func TestOAuthLoginUntrustedProviderDoesNotLink(t *testing.T) {
+	usecase, db, _, mailService := newTestOAuthUsecase(t)
+	existing := entities.User{Username: "owner", Email: "owner@example.com", Password: "hash", Active: true}
+	db.Create(&existing)
+
+	identity := dtos.OAuthIdentityDto{
+		Provider:      "oidc:partner",
+		Subject:       "partner-1",
+		Email:         "owner@example.com",
+		EmailVerified: true,
+	}
+	_, err := usecase.Login(context.Background(), db, identity)
+	if !errors.Is(err, OAuthEmailInUse) {
+		t.Fatalf("got %v, want OAuthEmailInUse", err)
+	}
+
+	var stored entities.User
+	db.Take(&stored, existing.ID)
+	if stored.Password != "hash" || stored.EmailVerifiedAt != nil {
+		t.Fatalf("account changed by an untrusted provider: %+v", stored)
+	}
+
+	identity.Email = "partner.user@example.com"
+	user, err := usecase.Login(context.Background(), db, identity)
+	if err != nil || user.EmailVerifiedAt != nil {
+		t.Fatalf("untrusted provider created %+v, %v", user, err)
+	}
+	if len(mailService.sent) != 1 || mailService.sent[0].template != "email_verification_template.html" {
+		t.Fatalf("verification link not mailed: %+v", mailService.sent)
+	}
+}
+
+func TestOAuthLoginRequiresVerificationOfUntrustedEmail(t *testing.T) {
+	t.Setenv("EMAIL_VERIFICATION_REQUIRED", "true")
+	usecase, db, _, mailService := newTestOAuthUsecase(t)
+
+	identity := dtos.OAuthIdentityDto{
+		Provider:      "oidc:partner",
+		Subject:       "partner-1",
+		Email:         "partner.user@example.com",
+		EmailVerified: true,
+	}
+	for attempt := 0; attempt < 2; attempt++ {
+		_, err := usecase.Login(context.Background(), db, identity)
+		if !errors.Is(err, OAuthEmailVerificationPending) {
+			t.Fatalf("login %d returned %v, want OAuthEmailVerificationPending", attempt, err)
+		}
+	}
+	if len(mailService.sent) != 1 {
+		t.Fatalf("%d verification mails sent, want the one of the account creation", len(mailService.sent))
+	}
+
+	now := time.Now()
+	db.Model(&entities.User{}).Where("email = ?", identity.Email).Update("email_verified_at", &now)
+	user, err := usecase.Login(context.Background(), db, identity)
+	if err != nil || user.Email != identity.Email {
+		t.Fatalf("verified login returned %+v, %v", user, err)
+	}
+}
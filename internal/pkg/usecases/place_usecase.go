@@ -2,12 +2,14 @@ package usecases
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"go-server/internal/pkg/domains/interfaces"
 	"go-server/internal/pkg/domains/models/dtos"
 	"go-server/internal/pkg/domains/models/entities"
 	"go-server/pkg/shared/database"
 	"go-server/pkg/shared/utils"
+	"math"
 	"strings"
 
 	"github.com/sirupsen/logrus"
@@ -27,12 +29,25 @@ var (
 	DetailPlaceIDNotFound = errors.New("Place not found")
 
 	DeletePlaceIDNotFound = errors.New("Place not found")
+
+	MergePlaceIDNotFound          = errors.New("Place not found")
+	MergePlaceDuplicateIDNotFound = errors.New("Duplicate place not found")
+	MergePlaceSameID              = errors.New("Cannot merge a place into itself")
+)
+
+const (
+	// places closer than this (in km) with similar names are reported as duplicates
+	duplicatePlaceDistanceThreshold = 0.5
+	// minimum NameSimilarity score for two place names to be considered the same
+	duplicatePlaceNameSimilarityThreshold = 0.8
 )
 
 type placeUsecase struct {
 	placeRepo         interfaces.PlaceRepository
 	placeCategoryRepo interfaces.PlaceCategoryRepository
 	categoryRepo      interfaces.CategoryRepository
+	translationRepo   interfaces.TranslationRepository
+	bannerRepo        interfaces.BannerRepository
 	logger            *logrus.Logger
 }
 
@@ -40,12 +55,16 @@ func NewPlaceUsecase(
 	placeRepo interfaces.PlaceRepository,
 	placeCategoryRepo interfaces.PlaceCategoryRepository,
 	categoryRepo interfaces.CategoryRepository,
+	translationRepo interfaces.TranslationRepository,
+	bannerRepo interfaces.BannerRepository,
 	logger *logrus.Logger,
 ) interfaces.PlaceUsecase {
 	return &placeUsecase{
 		placeRepo,
 		placeCategoryRepo,
 		categoryRepo,
+		translationRepo,
+		bannerRepo,
 		logger,
 	}
 }
@@ -83,6 +102,13 @@ func (u *placeUsecase) Create(
 		}
 	}
 
+	translations, err := buildTranslations(req.Translations)
+	if err != nil {
+		return entities.Place{}, err, map[string]interface{}{
+			"translations": req.Translations,
+		}
+	}
+
 	place := entities.Place{
 		Name:        req.Name,
 		Address:     req.Address,
@@ -111,6 +137,13 @@ func (u *placeUsecase) Create(
 			return err
 		}
 
+		err = u.translationRepo.ReplaceWithTx(tx, entities.TranslationEntityPlace, place.ID, translations)
+		if err != nil {
+			return err
+		}
+
+		place.Translations = translations
+
 		return nil
 	}); err != nil {
 		return entities.Place{}, err, map[string]interface{}{
@@ -126,6 +159,11 @@ func (u *placeUsecase) FindListPaginate(
 	pageData map[string]int,
 	conditions map[string]interface{},
 ) ([]entities.Place, int64, error) {
+	err := u.expandCategoryCondition(ctx, conditions)
+	if err != nil {
+		return []entities.Place{}, 0, err
+	}
+
 	return u.placeRepo.FindListPaginate(ctx, pageData, conditions)
 }
 
@@ -172,6 +210,13 @@ func (u *placeUsecase) Update(
 		}
 	}
 
+	translations, err := buildTranslations(req.Translations)
+	if err != nil {
+		return entities.Place{}, err, map[string]interface{}{
+			"translations": req.Translations,
+		}
+	}
+
 	newPlace := entities.Place{
 		Name:        req.Name,
 		Address:     req.Address,
@@ -207,6 +252,16 @@ func (u *placeUsecase) Update(
 			return err
 		}
 
+		// translations are left untouched when the request does not send them
+		if req.Translations != nil {
+			err = u.translationRepo.ReplaceWithTx(tx, entities.TranslationEntityPlace, place.ID, translations)
+			if err != nil {
+				return err
+			}
+
+			place.Translations = translations
+		}
+
 		return nil
 	}); err != nil {
 		return entities.Place{}, err, map[string]interface{}{
@@ -272,5 +327,164 @@ func (u *placeUsecase) FindByConditions(
 	ctx context.Context,
 	conditions map[string]interface{},
 ) ([]entities.Place, error) {
+	err := u.expandCategoryCondition(ctx, conditions)
+	if err != nil {
+		return []entities.Place{}, err
+	}
+
 	return u.placeRepo.FindByConditions(ctx, conditions)
 }
+
+// expandCategoryCondition replaces the category_id condition by the category and all its descendants
+func (u *placeUsecase) expandCategoryCondition(
+	ctx context.Context,
+	conditions map[string]interface{},
+) error {
+	categoryID, ok := conditions["category_id"].(int)
+	if !ok {
+		return nil
+	}
+
+	categories, err := u.categoryRepo.FindByConditions(ctx, map[string]interface{}{})
+	if err != nil {
+		return err
+	}
+
+	conditions["category_id"] = entities.CategoryDescendantIDs(categories, categoryID)
+	return nil
+}
+
+func (u *placeUsecase) FindDuplicates(
+	ctx context.Context,
+	place entities.Place,
+) ([]dtos.DuplicatePlaceDto, error) {
+	latitudeDelta := duplicatePlaceDistanceThreshold / 111.0
+	longitudeDelta := latitudeDelta
+	if cos := math.Cos(place.Latitude * math.Pi / 180); cos > 0.01 {
+		longitudeDelta = latitudeDelta / cos
+	}
+
+	candidates, err := u.placeRepo.FindInBoundingBox(
+		ctx,
+		place.Latitude-latitudeDelta,
+		place.Latitude+latitudeDelta,
+		place.Longitude-longitudeDelta,
+		place.Longitude+longitudeDelta,
+	)
+	if err != nil {
+		return []dtos.DuplicatePlaceDto{}, err
+	}
+
+	duplicates := []dtos.DuplicatePlaceDto{}
+	for _, candidate := range candidates {
+		if candidate.ID == place.ID {
+			continue
+		}
+
+		distance := utils.Haversine(place.Latitude, place.Longitude, candidate.Latitude, candidate.Longitude)
+		if distance > duplicatePlaceDistanceThreshold {
+			continue
+		}
+
+		similarity := utils.NameSimilarity(place.Name, candidate.Name)
+		if similarity < duplicatePlaceNameSimilarityThreshold {
+			continue
+		}
+
+		duplicates = append(duplicates, dtos.DuplicatePlaceDto{
+			Place:      candidate,
+			Distance:   distance,
+			Similarity: similarity,
+		})
+	}
+
+	return duplicates, nil
+}
+
+// Merge moves the reviews, categories, trip days, banners and translations of the duplicate
+// to the survivor and deletes the duplicate
+func (u *placeUsecase) Merge(
+	ctx context.Context,
+	db *gorm.DB,
+	survivorID int,
+	duplicateID int,
+) (entities.Place, error) {
+	if survivorID == duplicateID {
+		return entities.Place{}, MergePlaceSameID
+	}
+
+	survivor, err := u.placeRepo.TakeByConditions(ctx, map[string]interface{}{
+		"id": survivorID,
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return entities.Place{}, MergePlaceIDNotFound
+		}
+		return entities.Place{}, err
+	}
+
+	duplicate, err := u.placeRepo.TakeByConditions(ctx, map[string]interface{}{
+		"id": duplicateID,
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return entities.Place{}, MergePlaceDuplicateIDNotFound
+		}
+		return entities.Place{}, err
+	}
+
+	if err := database.Transaction(ctx, db, func(tx *gorm.DB) error {
+		err = u.placeRepo.MoveCommentsWithTx(tx, duplicate.ID, survivor.ID)
+		if err != nil {
+			return err
+		}
+
+		err = u.placeCategoryRepo.MovePlaceWithTx(tx, duplicate.ID, survivor.ID)
+		if err != nil {
+			return err
+		}
+
+		days, err := u.placeRepo.FindDaysByPlaceIDWithTx(tx, duplicate.ID)
+		if err != nil {
+			return err
+		}
+
+		for _, day := range days {
+			for i, place := range day.PlacesJson {
+				if place.ID == duplicate.ID {
+					day.PlacesJson[i].Place = survivor
+				}
+			}
+
+			places, err := json.Marshal(&day.PlacesJson)
+			if err != nil {
+				return err
+			}
+
+			err = u.placeRepo.UpdateDayPlacesWithTx(tx, day, string(places))
+			if err != nil {
+				return err
+			}
+		}
+
+		err = u.bannerRepo.RetargetWithTx(tx, entities.BannerTargetPlace, duplicate.ID, survivor.ID)
+		if err != nil {
+			return err
+		}
+
+		err = u.translationRepo.MergeWithTx(tx, entities.TranslationEntityPlace, duplicate.ID, survivor.ID)
+		if err != nil {
+			return err
+		}
+
+		return u.placeRepo.DeleteByConditionsWithTx(tx, map[string]interface{}{
+			"id": duplicate.ID,
+		})
+	}); err != nil {
+		return entities.Place{}, err
+	}
+
+	return u.placeRepo.TakeByConditionsWithPreload(ctx, map[string]interface{}{
+		"id": survivor.ID,
+	})
+}
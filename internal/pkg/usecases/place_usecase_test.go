@@ -0,0 +1,60 @@
+package usecases
+
+import (
+	"context"
+	"testing"
+
+	"go-server/internal/pkg/domains/models/entities"
+	"go-server/internal/pkg/repositories"
+)
+
+func TestMergeMovesBannersAndTranslations(t *testing.T) {
+	db := newTestDB(t, &entities.Place{}, &entities.Category{}, &entities.Comment{}, &entities.Day{},
+		&entities.PlaceCategory{}, &entities.Banner{}, &entities.Translation{})
+	logger := newTestLogger()
+	usecase := NewPlaceUsecase(
+		repositories.NewPlaceRepository(db, logger),
+		repositories.NewPlaceCategoryRepository(db, logger),
+		repositories.NewCategoryRepository(db, logger),
+		repositories.NewTranslationRepository(db, logger),
+		repositories.NewBannerRepository(db, logger),
+		logger,
+	)
+
+	survivor := entities.Place{Name: "survivor", Images: "|image|"}
+	duplicate := entities.Place{Name: "duplicate", Images: "|image|"}
+	db.Create(&survivor)
+	db.Create(&duplicate)
+	banner := entities.Banner{Name: "banner", TargetType: entities.BannerTargetPlace, TargetID: duplicate.ID}
+	db.Create(&banner)
+	db.Create(&[]entities.Translation{
+		{EntityType: entities.TranslationEntityPlace, EntityID: survivor.ID, Locale: "vi", Field: entities.TranslationFieldName, Value: "kept"},
+		{EntityType: entities.TranslationEntityPlace, EntityID: duplicate.ID, Locale: "vi", Field: entities.TranslationFieldName, Value: "dropped"},
+		{EntityType: entities.TranslationEntityPlace, EntityID: duplicate.ID, Locale: "vi", Field: entities.TranslationFieldDescription, Value: "moved"},
+	})
+
+	_, err := usecase.Merge(context.Background(), db, survivor.ID, duplicate.ID)
+	if err != nil {
+		t.Fatalf("merge: %v", err)
+	}
+
+	db.First(&banner, banner.ID)
+	if banner.TargetID != survivor.ID {
+		t.Fatalf("banner still targets place %d", banner.TargetID)
+	}
+
+	var translations []entities.Translation
+	db.Where("entity_type = ?", entities.TranslationEntityPlace).Order("field").Find(&translations)
+	want := map[string]string{
+		entities.TranslationFieldDescription: "moved",
+		entities.TranslationFieldName:        "kept",
+	}
+	if len(translations) != len(want) {
+		t.Fatalf("unexpected translations %+v", translations)
+	}
+	for _, translation := range translations {
+		if translation.EntityID != survivor.ID || want[translation.Field] != translation.Value {
+			t.Fatalf("unexpected translation %+v", translation)
+		}
+	}
+}
@@ -0,0 +1,319 @@
+package usecases
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"go-server/internal/pkg/domains/interfaces"
+	"go-server/internal/pkg/domains/models/dtos"
+	"go-server/internal/pkg/domains/models/entities"
+	"sort"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+var (
+	ProfileUserNotFound = errors.New("User not found")
+	FollowSelf          = errors.New("Users cannot follow themselves")
+	FeedCursorInvalid   = errors.New("Feed cursor invalid")
+)
+
+const (
+	defaultFeedLimit = 20
+	maxFeedLimit     = 100
+)
+
+type profileUsecase struct {
+	profileRepo interfaces.ProfileRepository
+	logger      *logrus.Logger
+}
+
+func NewProfileUsecase(
+	profileRepo interfaces.ProfileRepository,
+	logger *logrus.Logger,
+) interfaces.ProfileUsecase {
+	return &profileUsecase{
+		profileRepo,
+		logger,
+	}
+}
+
+// Detail is the public profile of an active user as seen by the viewer
+func (u *profileUsecase) Detail(
+	ctx context.Context,
+	viewerID int,
+	userID int,
+) (dtos.ProfileDto, error) {
+	user, err := u.takeUser(ctx, userID)
+	if err != nil {
+		return dtos.ProfileDto{}, err
+	}
+
+	profile := dtos.ProfileDto{
+		PublicUserDto: publicUser(user),
+	}
+	profile.ReviewCount, err = u.profileRepo.CountReviews(ctx, userID)
+	if err != nil {
+		return dtos.ProfileDto{}, err
+	}
+	profile.FollowerCount, err = u.profileRepo.CountFollowers(ctx, userID)
+	if err != nil {
+		return dtos.ProfileDto{}, err
+	}
+	profile.FollowingCount, err = u.profileRepo.CountFollowing(ctx, userID)
+	if err != nil {
+		return dtos.ProfileDto{}, err
+	}
+	profile.Following, err = u.profileRepo.IsFollowing(ctx, viewerID, userID)
+	if err != nil {
+		return dtos.ProfileDto{}, err
+	}
+	profile.Trips, err = u.profileRepo.FindPublicTrips(ctx, userID)
+	if err != nil {
+		return dtos.ProfileDto{}, err
+	}
+
+	return profile, nil
+}
+
+// Follow is idempotent, following a followed user does nothing
+func (u *profileUsecase) Follow(
+	ctx context.Context,
+	followerID int,
+	followeeID int,
+) error {
+	if followerID == followeeID {
+		return FollowSelf
+	}
+
+	_, err := u.takeUser(ctx, followeeID)
+	if err != nil {
+		return err
+	}
+
+	return u.profileRepo.Follow(ctx, followerID, followeeID)
+}
+
+func (u *profileUsecase) Unfollow(
+	ctx context.Context,
+	followerID int,
+	followeeID int,
+) error {
+	return u.profileRepo.Unfollow(ctx, followerID, followeeID)
+}
+
+func (u *profileUsecase) FindFollowersPaginate(
+	ctx context.Context,
+	userID int,
+	pageData map[string]int,
+) ([]dtos.PublicUserDto, int64, error) {
+	_, err := u.takeUser(ctx, userID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	users, count, err := u.profileRepo.FindFollowersPaginate(ctx, userID, pageData)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return publicUsers(users), count, nil
+}
+
+func (u *profileUsecase) FindFollowingPaginate(
+	ctx context.Context,
+	userID int,
+	pageData map[string]int,
+) ([]dtos.PublicUserDto, int64, error) {
+	_, err := u.takeUser(ctx, userID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	users, count, err := u.profileRepo.FindFollowingPaginate(ctx, userID, pageData)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return publicUsers(users), count, nil
+}
+
+// Feed merges the newest public trips and reviews of the users followed by the user,
+// the page starts after the cursor returned with the previous one
+func (u *profileUsecase) Feed(
+	ctx context.Context,
+	userID int,
+	cursor string,
+	limit int,
+) (dtos.FeedDto, error) {
+	if limit <= 0 {
+		limit = defaultFeedLimit
+	}
+	if limit > maxFeedLimit {
+		limit = maxFeedLimit
+	}
+
+	after, err := decodeFeedCursor(cursor)
+	if err != nil {
+		return dtos.FeedDto{}, err
+	}
+
+	// one more item than the page tells whether there is a next one
+	trips, err := u.profileRepo.FindFeedTrips(ctx, userID, after, limit+1)
+	if err != nil {
+		return dtos.FeedDto{}, err
+	}
+	reviews, err := u.profileRepo.FindFeedReviews(ctx, userID, after, limit+1)
+	if err != nil {
+		return dtos.FeedDto{}, err
+	}
+
+	items := make([]dtos.FeedItemDto, 0, len(trips)+len(reviews))
+	for i := range trips {
+		items = append(items, dtos.FeedItemDto{
+			Type:      dtos.FeedItemTrip,
+			CreatedAt: unixOrZero(trips[i].CreatedAt),
+			User:      dtos.PublicUserDto{ID: trips[i].Owner},
+			Trip:      &trips[i],
+		})
+	}
+	for i := range reviews {
+		items = append(items, dtos.FeedItemDto{
+			Type:      dtos.FeedItemReview,
+			CreatedAt: unixOrZero(reviews[i].CreatedAt),
+			User:      dtos.PublicUserDto{ID: reviews[i].UserID},
+			Review:    &reviews[i],
+		})
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return feedItemBefore(items[i], items[j])
+	})
+
+	feed := dtos.FeedDto{Items: items}
+	if len(items) > limit {
+		feed.Items = items[:limit]
+		last := feed.Items[limit-1]
+		feed.NextCursor = encodeFeedCursor(dtos.FeedCursor{
+			CreatedAt: last.CreatedAt,
+			Type:      last.Type,
+			ID:        feedItemID(last),
+		})
+	}
+
+	err = u.fillFeedUsers(ctx, feed.Items)
+	if err != nil {
+		return dtos.FeedDto{}, err
+	}
+
+	return feed, nil
+}
+
+func (u *profileUsecase) fillFeedUsers(
+	ctx context.Context,
+	items []dtos.FeedItemDto,
+) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	userIDs := make([]int, 0, len(items))
+	for _, item := range items {
+		userIDs = append(userIDs, item.User.ID)
+	}
+	users, err := u.profileRepo.FindUsersByIDs(ctx, userIDs)
+	if err != nil {
+		return err
+	}
+
+	usersByID := make(map[int]dtos.PublicUserDto, len(users))
+	for _, user := range users {
+		usersByID[user.ID] = publicUser(user)
+	}
+	for i := range items {
+		items[i].User = usersByID[items[i].User.ID]
+	}
+
+	return nil
+}
+
+func (u *profileUsecase) takeUser(
+	ctx context.Context,
+	userID int,
+) (entities.User, error) {
+	user, err := u.profileRepo.TakeActiveUser(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return entities.User{}, ProfileUserNotFound
+		}
+		return entities.User{}, err
+	}
+
+	return user, nil
+}
+
+func publicUser(user entities.User) dtos.PublicUserDto {
+	return dtos.PublicUserDto{
+		ID:       user.ID,
+		Username: user.Username,
+		Avatar:   user.Avatar,
+	}
+}
+
+func publicUsers(users []entities.User) []dtos.PublicUserDto {
+	result := make([]dtos.PublicUserDto, 0, len(users))
+	for _, user := range users {
+		result = append(result, publicUser(user))
+	}
+	return result
+}
+
+// feedItemBefore is the order of the feed, see dtos.FeedCursor
+func feedItemBefore(a dtos.FeedItemDto, b dtos.FeedItemDto) bool {
+	if a.CreatedAt != b.CreatedAt {
+		return a.CreatedAt > b.CreatedAt
+	}
+	if a.Type != b.Type {
+		return a.Type < b.Type
+	}
+	return feedItemID(a) > feedItemID(b)
+}
+
+func feedItemID(item dtos.FeedItemDto) int {
+	if item.Trip != nil {
+		return item.Trip.ID
+	}
+	return item.Review.ID
+}
+
+func encodeFeedCursor(cursor dtos.FeedCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeFeedCursor(value string) (*dtos.FeedCursor, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, FeedCursorInvalid
+	}
+	cursor := dtos.FeedCursor{}
+	err = json.Unmarshal(data, &cursor)
+	if err != nil || (cursor.Type != dtos.FeedItemTrip && cursor.Type != dtos.FeedItemReview) {
+		return nil, FeedCursorInvalid
+	}
+
+	return &cursor, nil
+}
+
+func unixOrZero(t *time.Time) int64 {
+	if t == nil {
+		return 0
+	}
+	return t.Unix()
+}
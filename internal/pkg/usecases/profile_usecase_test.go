@@ -0,0 +1,124 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"go-server/internal/pkg/domains/models/dtos"
+	"go-server/internal/pkg/domains/models/entities"
+	"go-server/internal/pkg/repositories"
+)
+
+func TestDecodeFeedCursor(t *testing.T) {
+	cursor, err := decodeFeedCursor("")
+	if cursor != nil || err != nil {
+		t.Fatalf("empty cursor returned %+v, %v", cursor, err)
+	}
+
+	want := dtos.FeedCursor{CreatedAt: 1700000000, Type: dtos.FeedItemReview, ID: 42}
+	cursor, err = decodeFeedCursor(encodeFeedCursor(want))
+	if err != nil || cursor == nil || *cursor != want {
+		t.Fatalf("round trip returned %+v, %v", cursor, err)
+	}
+
+	for _, value := range []string{
+		"not base64!",
+		"bm90IGpzb24",
+		encodeFeedCursor(dtos.FeedCursor{CreatedAt: 1700000000, Type: "photo", ID: 1}),
+	} {
+		_, err = decodeFeedCursor(value)
+		if !errors.Is(err, FeedCursorInvalid) {
+			t.Errorf("cursor %q returned %v", value, err)
+		}
+	}
+}
+
+// TestFeedPagesWithoutGapsOrDuplicates walks the feed a page at a time across items of both
+// types created in the same second, the timestamps have the second precision of MySQL
+func TestFeedPagesWithoutGapsOrDuplicates(t *testing.T) {
+	db := newTestDB(t, &entities.User{}, &entities.Follow{}, &entities.Trip{}, &entities.Day{},
+		&entities.Place{}, &entities.Comment{}, &entities.CommentImage{})
+	logger := newTestLogger()
+	usecase := NewProfileUsecase(repositories.NewProfileRepository(db, logger), logger)
+
+	follower := entities.User{Username: "follower", Email: "follower@example.com", Active: true}
+	followee := entities.User{Username: "followee", Email: "followee@example.com", Active: true}
+	stranger := entities.User{Username: "stranger", Email: "stranger@example.com", Active: true}
+	db.Create(&follower)
+	db.Create(&followee)
+	db.Create(&stranger)
+	db.Create(&entities.Follow{FollowerID: follower.ID, FolloweeID: followee.ID})
+
+	base := time.Unix(1700000000, 0)
+	at := func(seconds int) *time.Time {
+		created := base.Add(time.Duration(seconds) * time.Second)
+		return &created
+	}
+
+	// in feed order: newest first, then reviews before trips, then the highest id first.
+	// Items of the same second and type are created oldest first to get the ids of that order
+	items := []struct {
+		itemType string
+		seconds  int
+	}{
+		{dtos.FeedItemTrip, 20},
+		{dtos.FeedItemReview, 10},
+		{dtos.FeedItemReview, 10},
+		{dtos.FeedItemTrip, 10},
+		{dtos.FeedItemTrip, 10},
+		{dtos.FeedItemReview, 5},
+		{dtos.FeedItemTrip, 1},
+	}
+	want := make([]string, len(items))
+	for i := len(items) - 1; i >= 0; i-- {
+		item := items[i]
+		if item.itemType == dtos.FeedItemTrip {
+			trip := entities.Trip{Name: "trip", Owner: followee.ID, IsPublic: true}
+			trip.CreatedAt = at(item.seconds)
+			db.Create(&trip)
+			want[i] = fmt.Sprintf("%s:%d", item.itemType, trip.ID)
+			continue
+		}
+		// images are stored as |url|url|
+		place := entities.Place{Name: "place", Images: "|image|"}
+		db.Create(&place)
+		review := entities.Comment{Rate: 5, UserID: followee.ID, PlaceID: place.ID, Status: entities.CommentStatusPublished}
+		review.CreatedAt = at(item.seconds)
+		db.Create(&review)
+		want[i] = fmt.Sprintf("%s:%d", item.itemType, review.ID)
+	}
+
+	// not in the feed: a private trip of the followee and a trip of someone not followed
+	hidden := entities.Trip{Name: "hidden", Owner: followee.ID}
+	hidden.CreatedAt = at(15)
+	db.Create(&hidden)
+	other := entities.Trip{Name: "other", Owner: stranger.ID, IsPublic: true}
+	other.CreatedAt = at(15)
+	db.Create(&other)
+
+	var got []string
+	cursor := ""
+	for page := 0; page < 10; page++ {
+		feed, err := usecase.Feed(context.Background(), follower.ID, cursor, 2)
+		if err != nil {
+			t.Fatalf("page %d: %v", page, err)
+		}
+		for _, item := range feed.Items {
+			got = append(got, fmt.Sprintf("%s:%d", item.Type, feedItemID(item)))
+			if item.User.ID != followee.ID || item.User.Username != "followee" {
+				t.Fatalf("item %s has user %+v", got[len(got)-1], item.User)
+			}
+		}
+		if feed.NextCursor == "" {
+			break
+		}
+		cursor = feed.NextCursor
+	}
+
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("feed\n got %v\nwant %v", got, want)
+	}
+}
@@ -0,0 +1,155 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"go-server/internal/pkg/domains/interfaces"
+	"go-server/internal/pkg/domains/models/dtos"
+	"go-server/internal/pkg/domains/models/entities"
+	"go-server/pkg/shared/database"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+var (
+	AssignRolesUserNotFound   = errors.New("User not found")
+	AssignRolesRoleNotFound   = errors.New("Role not found")
+	AssignRolesLastSuperAdmin = errors.New("The last super admin cannot lose the role")
+)
+
+type roleUsecase struct {
+	roleRepo interfaces.RoleRepository
+	userRepo interfaces.UserRepository
+	logger   *logrus.Logger
+}
+
+func NewRoleUsecase(
+	roleRepo interfaces.RoleRepository,
+	userRepo interfaces.UserRepository,
+	logger *logrus.Logger,
+) interfaces.RoleUsecase {
+	return &roleUsecase{
+		roleRepo,
+		userRepo,
+		logger,
+	}
+}
+
+func (u *roleUsecase) FindAll(
+	ctx context.Context,
+) ([]entities.Role, error) {
+	return u.roleRepo.FindAll(ctx)
+}
+
+func (u *roleUsecase) FindUserRoles(
+	ctx context.Context,
+	userID int,
+) ([]entities.Role, error) {
+	_, err := u.userRepo.TakeByConditions(ctx, map[string]interface{}{
+		"id": userID,
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, AssignRolesUserNotFound
+		}
+		return nil, err
+	}
+
+	return u.roleRepo.FindByUser(ctx, userID)
+}
+
+// AssignUserRoles replaces the roles of the user, having a role is what makes a user an admin
+func (u *roleUsecase) AssignUserRoles(
+	ctx context.Context,
+	db *gorm.DB,
+	userID int,
+	req dtos.AssignRolesRequestDto,
+) ([]entities.Role, error) {
+	user, err := u.userRepo.TakeByConditions(ctx, map[string]interface{}{
+		"id": userID,
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, AssignRolesUserNotFound
+		}
+		return nil, err
+	}
+
+	names := make([]string, 0, len(req.Roles))
+	seen := make(map[string]bool)
+	for _, name := range req.Roles {
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+
+	roles := []entities.Role{}
+	if len(names) > 0 {
+		roles, err = u.roleRepo.FindByNames(ctx, names)
+		if err != nil {
+			return nil, err
+		}
+		if len(roles) != len(names) {
+			return nil, AssignRolesRoleNotFound
+		}
+	}
+
+	err = database.Transaction(ctx, db, func(tx *gorm.DB) error {
+		if !hasRole(roles, entities.RoleSuperAdmin) {
+			err := guardLastSuperAdminWithTx(tx, u.roleRepo, user.ID, AssignRolesLastSuperAdmin)
+			if err != nil {
+				return err
+			}
+		}
+
+		err := u.roleRepo.ReplaceUserRolesWithTx(tx, user, roles)
+		if err != nil {
+			return err
+		}
+
+		_, err = u.userRepo.UpdateColumnsWithTx(tx, user, map[string]interface{}{
+			"is_admin": len(roles) > 0,
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return u.roleRepo.FindByUser(ctx, userID)
+}
+
+// guardLastSuperAdminWithTx fails with err when the user is the last active super admin. The
+// super admins stay locked until the end of tx, concurrent demotions are checked one at a time
+func guardLastSuperAdminWithTx(
+	tx *gorm.DB,
+	roleRepo interfaces.RoleRepository,
+	userID int,
+	err error,
+) error {
+	superAdminIDs, lockErr := roleRepo.LockUserIDsWithRoleWithTx(tx, entities.RoleSuperAdmin)
+	if lockErr != nil {
+		return lockErr
+	}
+	if len(superAdminIDs) > 1 {
+		return nil
+	}
+	for _, superAdminID := range superAdminIDs {
+		if superAdminID == userID {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func hasRole(roles []entities.Role, name string) bool {
+	for _, role := range roles {
+		if role.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,372 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"go-server/internal/pkg/domains/interfaces"
+	"go-server/internal/pkg/domains/models/dtos"
+	"go-server/internal/pkg/domains/models/entities"
+	"go-server/pkg/shared/auth"
+	"go-server/pkg/shared/database"
+	"go-server/pkg/shared/realtime"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+var (
+	RefreshTokenInvalid  = errors.New("Refresh token invalid")
+	RefreshTokenExpired  = errors.New("Refresh token expired")
+	RefreshTokenReused   = errors.New("Refresh token already used, the session has been revoked")
+	RefreshUserNotActive = errors.New("User not active")
+
+	RevokeSessionNotFound = errors.New("Session not found")
+)
+
+type tokenUsecase struct {
+	userRepo      interfaces.UserRepository
+	userTokenRepo interfaces.UserTokenRepository
+	sessions      realtime.SessionCloser
+	logger        *logrus.Logger
+}
+
+func NewTokenUsecase(
+	userRepo interfaces.UserRepository,
+	userTokenRepo interfaces.UserTokenRepository,
+	sessions realtime.SessionCloser,
+	logger *logrus.Logger,
+) interfaces.TokenUsecase {
+	return &tokenUsecase{
+		userRepo,
+		userTokenRepo,
+		sessions,
+		logger,
+	}
+}
+
+// Issue starts a new session of the user
+func (u *tokenUsecase) Issue(
+	ctx context.Context,
+	user entities.User,
+	client dtos.ClientDto,
+) (dtos.TokenPairDto, error) {
+	return u.issue(ctx, user, client, false)
+}
+
+// IssueTwoFactor starts a new session of a user who also passed the second factor
+func (u *tokenUsecase) IssueTwoFactor(
+	ctx context.Context,
+	user entities.User,
+	client dtos.ClientDto,
+) (dtos.TokenPairDto, error) {
+	return u.issue(ctx, user, client, true)
+}
+
+func (u *tokenUsecase) issue(
+	ctx context.Context,
+	user entities.User,
+	client dtos.ClientDto,
+	twoFactor bool,
+) (dtos.TokenPairDto, error) {
+	token, pair, err := newUserToken(user, uuid.New().String(), client, twoFactor)
+	if err != nil {
+		return dtos.TokenPairDto{}, err
+	}
+
+	_, err = u.userTokenRepo.Create(ctx, token)
+	if err != nil {
+		return dtos.TokenPairDto{}, err
+	}
+
+	return pair, nil
+}
+
+// Refresh rotates the refresh token. Presenting a rotated token again means it leaked,
+// the whole family is then revoked.
+func (u *tokenUsecase) Refresh(
+	ctx context.Context,
+	db *gorm.DB,
+	req dtos.RefreshTokenRequestDto,
+	client dtos.ClientDto,
+) (dtos.TokenPairDto, error) {
+	current, err := u.userTokenRepo.TakeByConditions(ctx, map[string]interface{}{
+		"refresh_token_hash": auth.HashToken(req.RefreshToken),
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return dtos.TokenPairDto{}, RefreshTokenInvalid
+		}
+		return dtos.TokenPairDto{}, err
+	}
+	if current.RotatedAt != nil {
+		return dtos.TokenPairDto{}, u.revokeReusedFamily(ctx, current)
+	}
+	if current.ExpiresAt == nil || current.ExpiresAt.Before(time.Now()) {
+		return dtos.TokenPairDto{}, RefreshTokenExpired
+	}
+
+	user, err := u.userRepo.TakeByConditions(ctx, map[string]interface{}{
+		"id": current.UserID,
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return dtos.TokenPairDto{}, RefreshTokenInvalid
+		}
+		return dtos.TokenPairDto{}, err
+	}
+	if !user.Active {
+		return dtos.TokenPairDto{}, RefreshUserNotActive
+	}
+
+	next, pair, err := newUserToken(user, current.FamilyID, client, current.TwoFactor)
+	if err != nil {
+		return dtos.TokenPairDto{}, err
+	}
+	// the session keeps its age
+	next.CreatedAt = current.CreatedAt
+
+	reused := false
+	err = database.Transaction(ctx, db, func(tx *gorm.DB) error {
+		rotated, err := u.userTokenRepo.MarkRotatedWithTx(tx, current)
+		if err != nil {
+			return err
+		}
+		if !rotated {
+			// refreshed concurrently with the same token
+			reused = true
+			return nil
+		}
+
+		_, err = u.userTokenRepo.CreateWithTx(tx, next)
+		return err
+	})
+	if err != nil {
+		return dtos.TokenPairDto{}, err
+	}
+	if reused {
+		return dtos.TokenPairDto{}, u.revokeReusedFamily(ctx, current)
+	}
+	// the rotated access token no longer authenticates, its websockets reconnect with the new one
+	u.sessions.CloseSession(current.TokenID)
+
+	return pair, nil
+}
+
+// Revoke ends the session of the access token
+func (u *tokenUsecase) Revoke(
+	ctx context.Context,
+	tokenID string,
+) error {
+	token, err := u.userTokenRepo.TakeByConditions(ctx, map[string]interface{}{
+		"token_id": tokenID,
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	return u.revoke(ctx, token)
+}
+
+func (u *tokenUsecase) FindSessions(
+	ctx context.Context,
+	userID int,
+	currentTokenID string,
+) ([]dtos.SessionDto, error) {
+	tokens, err := u.userTokenRepo.FindActiveByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	sessions := make([]dtos.SessionDto, 0, len(tokens))
+	for _, token := range tokens {
+		if token.ExpiresAt != nil && token.ExpiresAt.Before(now) {
+			continue
+		}
+
+		sessions = append(sessions, sessionDto(token, currentTokenID))
+	}
+
+	return sessions, nil
+}
+
+func sessionDto(token entities.UserToken, currentTokenID string) dtos.SessionDto {
+	session := dtos.SessionDto{
+		ID:        token.ID,
+		UserAgent: token.UserAgent,
+		IP:        token.IP,
+		Current:   token.TokenID == currentTokenID,
+	}
+	if token.CreatedAt != nil {
+		session.CreatedAt = token.CreatedAt.Unix()
+	}
+	if token.LastUsedAt != nil {
+		session.LastUsedAt = token.LastUsedAt.Unix()
+	}
+	if token.ExpiresAt != nil {
+		session.ExpiresAt = token.ExpiresAt.Unix()
+	}
+
+	return session
+}
+
+func (u *tokenUsecase) RevokeSession(
+	ctx context.Context,
+	userID int,
+	sessionID int,
+) error {
+	token, err := u.userTokenRepo.TakeByConditions(ctx, map[string]interface{}{
+		"id":      sessionID,
+		"user_id": userID,
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return RevokeSessionNotFound
+		}
+		return err
+	}
+
+	return u.revoke(ctx, token)
+}
+
+// RevokeOtherSessions ends every session of the user but the current one, all of them
+// when currentTokenID is empty
+func (u *tokenUsecase) RevokeOtherSessions(
+	ctx context.Context,
+	userID int,
+	currentTokenID string,
+) error {
+	tokens, err := u.userTokenRepo.FindActiveByUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	for _, token := range tokens {
+		if currentTokenID != "" && token.TokenID == currentTokenID {
+			continue
+		}
+		err = u.revoke(ctx, token)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// revoke deletes the session of the token with its rotated refresh tokens and closes
+// its websockets
+func (u *tokenUsecase) revoke(
+	ctx context.Context,
+	token entities.UserToken,
+) error {
+	// sessions created before refresh tokens have no family
+	if token.FamilyID == "" {
+		err := u.userTokenRepo.DeleteByConditions(ctx, map[string]interface{}{
+			"id": token.ID,
+		})
+		if err != nil {
+			return err
+		}
+
+		u.sessions.CloseSession(token.TokenID)
+		return nil
+	}
+
+	tokenIDs, err := u.userTokenRepo.FindTokenIDsOfFamily(ctx, token.FamilyID)
+	if err != nil {
+		return err
+	}
+
+	err = u.userTokenRepo.DeleteFamily(ctx, token.FamilyID)
+	if err != nil {
+		return err
+	}
+
+	for _, tokenID := range tokenIDs {
+		u.sessions.CloseSession(tokenID)
+	}
+	return nil
+}
+
+func (u *tokenUsecase) revokeReusedFamily(
+	ctx context.Context,
+	token entities.UserToken,
+) error {
+	u.logger.WithField("user_id", token.UserID).Warn("refresh token reused, revoking its session")
+
+	err := u.revoke(ctx, token)
+	if err != nil {
+		return err
+	}
+
+	return RefreshTokenReused
+}
+
+// newUserToken builds the session row of a new token pair of the family
+func newUserToken(
+	user entities.User,
+	familyID string,
+	client dtos.ClientDto,
+	twoFactor bool,
+) (entities.UserToken, dtos.TokenPairDto, error) {
+	tokenID := uuid.New().String()
+	accessToken, expiresAt, err := auth.GenerateAccessToken(accessTokenClaims(user, tokenID, twoFactor))
+	if err != nil {
+		return entities.UserToken{}, dtos.TokenPairDto{}, err
+	}
+
+	refreshToken, refreshTokenHash, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		return entities.UserToken{}, dtos.TokenPairDto{}, err
+	}
+	now := time.Now()
+	refreshExpiresAt := now.Add(auth.RefreshTokenTTL())
+
+	token := entities.UserToken{
+		UserID:           user.ID,
+		TokenID:          tokenID,
+		FamilyID:         familyID,
+		RefreshTokenHash: refreshTokenHash,
+		ExpiresAt:        &refreshExpiresAt,
+		UserAgent:        truncate(client.UserAgent, 512),
+		IP:               client.IP,
+		LastUsedAt:       &now,
+		TwoFactor:        twoFactor,
+	}
+	pair := dtos.TokenPairDto{
+		AccessToken:      accessToken,
+		ExpiresAt:        expiresAt.Unix(),
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: refreshExpiresAt.Unix(),
+	}
+
+	return token, pair, nil
+}
+
+func accessTokenClaims(
+	user entities.User,
+	tokenID string,
+	twoFactor bool,
+) map[string]interface{} {
+	return map[string]interface{}{
+		"user_id":  user.ID,
+		"sub":      user.Username,
+		"email":    user.Email,
+		"is_admin": user.IsAdmin,
+		"token_id": tokenID,
+		"mfa":      twoFactor,
+	}
+}
+
+func truncate(s string, maxLen int) string {
+	if len(s) > maxLen {
+		return s[:maxLen]
+	}
+	return s
+}
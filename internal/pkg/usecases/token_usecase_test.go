@@ -0,0 +1,125 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go-server/internal/pkg/domains/interfaces"
+	"go-server/internal/pkg/domains/models/dtos"
+	"go-server/internal/pkg/domains/models/entities"
+	"go-server/internal/pkg/repositories"
+	"go-server/pkg/shared/auth"
+
+	"gorm.io/gorm"
+)
+
+func newTestTokenUsecase(t *testing.T) (interfaces.TokenUsecase, *gorm.DB, *fakeSessionCloser, entities.User) {
+	t.Helper()
+
+	db := newTestDB(t, &entities.User{}, &entities.UserToken{})
+	logger := newTestLogger()
+	sessions := &fakeSessionCloser{}
+	usecase := NewTokenUsecase(
+		repositories.NewUserRepository(db, logger),
+		repositories.NewUserTokenRepository(db, logger),
+		sessions,
+		logger,
+	)
+
+	user := entities.User{Username: "owner", Email: "owner@example.com", Password: "hash", Active: true}
+	db.Create(&user)
+
+	return usecase, db, sessions, user
+}
+
+func tokenIDOf(t *testing.T, pair dtos.TokenPairDto) string {
+	t.Helper()
+
+	claims, err := auth.ParseAccessToken(pair.AccessToken)
+	if err != nil {
+		t.Fatalf("parse access token: %v", err)
+	}
+	tokenID, _ := claims["token_id"].(string)
+	return tokenID
+}
+
+func refresh(usecase interfaces.TokenUsecase, db *gorm.DB, pair dtos.TokenPairDto) (dtos.TokenPairDto, error) {
+	return usecase.Refresh(context.Background(), db, dtos.RefreshTokenRequestDto{RefreshToken: pair.RefreshToken}, dtos.ClientDto{})
+}
+
+func TestRefreshRotatesSession(t *testing.T) {
+	usecase, db, sessions, user := newTestTokenUsecase(t)
+	first, err := usecase.Issue(context.Background(), user, dtos.ClientDto{})
+	if err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+
+	second, err := refresh(usecase, db, first)
+	if err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+	if second.RefreshToken == first.RefreshToken || tokenIDOf(t, second) == tokenIDOf(t, first) {
+		t.Fatal("refresh did not rotate the tokens")
+	}
+
+	var live []entities.UserToken
+	db.Where("rotated_at IS NULL").Find(&live)
+	if len(live) != 1 || live[0].TokenID != tokenIDOf(t, second) {
+		t.Fatalf("unexpected live sessions %+v", live)
+	}
+	if len(sessions.sessions) != 1 || sessions.sessions[0] != tokenIDOf(t, first) {
+		t.Fatalf("websockets of the rotated token not closed: %v", sessions.sessions)
+	}
+}
+
+func TestRefreshReuseRevokesFamily(t *testing.T) {
+	usecase, db, sessions, user := newTestTokenUsecase(t)
+	first, _ := usecase.Issue(context.Background(), user, dtos.ClientDto{})
+	second, err := refresh(usecase, db, first)
+	if err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+
+	_, err = refresh(usecase, db, first)
+	if !errors.Is(err, RefreshTokenReused) {
+		t.Fatalf("reuse returned %v", err)
+	}
+
+	var count int64
+	db.Model(&entities.UserToken{}).Count(&count)
+	if count != 0 {
+		t.Fatalf("%d tokens of the reused family kept", count)
+	}
+	_, err = refresh(usecase, db, second)
+	if !errors.Is(err, RefreshTokenInvalid) {
+		t.Fatalf("refresh of the revoked family returned %v", err)
+	}
+	closed := map[string]bool{}
+	for _, tokenID := range sessions.sessions {
+		closed[tokenID] = true
+	}
+	if !closed[tokenIDOf(t, second)] {
+		t.Fatalf("websockets of the live token not closed: %v", sessions.sessions)
+	}
+}
+
+func TestRevokeOtherSessionsKeepsCurrent(t *testing.T) {
+	usecase, db, sessions, user := newTestTokenUsecase(t)
+	current, _ := usecase.Issue(context.Background(), user, dtos.ClientDto{})
+	other, _ := usecase.Issue(context.Background(), user, dtos.ClientDto{})
+
+	err := usecase.RevokeOtherSessions(context.Background(), user.ID, tokenIDOf(t, current))
+	if err != nil {
+		t.Fatalf("revoke: %v", err)
+	}
+
+	var tokens []entities.UserToken
+	db.Find(&tokens)
+	if len(tokens) != 1 || tokens[0].TokenID != tokenIDOf(t, current) {
+		t.Fatalf("unexpected sessions %+v", tokens)
+	}
+	if len(sessions.sessions) != 1 || sessions.sessions[0] != tokenIDOf(t, other) {
+		t.Fatalf("closed websockets %v, want the other session", sessions.sessions)
+	}
+}
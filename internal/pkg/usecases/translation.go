@@ -0,0 +1,44 @@
+package usecases
+
+import (
+	"errors"
+	"go-server/internal/pkg/domains/models/entities"
+	"go-server/pkg/shared/i18n"
+)
+
+var (
+	TranslationLocaleNotSupported = errors.New("Translation locale not supported")
+)
+
+type translationFields interface {
+	Fields() map[string]string
+}
+
+// buildTranslations converts request translations keyed by locale into entities.
+// The default locale lives in the entity itself so it is not stored as a translation.
+func buildTranslations[T translationFields](values map[string]T) ([]entities.Translation, error) {
+	var translations []entities.Translation
+	for locale, value := range values {
+		if !i18n.IsSupported(locale) {
+			return nil, TranslationLocaleNotSupported
+		}
+
+		locale = i18n.Normalize(locale)
+		if locale == i18n.DefaultLocale() {
+			continue
+		}
+
+		for field, fieldValue := range value.Fields() {
+			if fieldValue == "" {
+				continue
+			}
+			translations = append(translations, entities.Translation{
+				Locale: locale,
+				Field:  field,
+				Value:  fieldValue,
+			})
+		}
+	}
+
+	return translations, nil
+}
@@ -0,0 +1,415 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"go-server/internal/pkg/domains/interfaces"
+	"go-server/internal/pkg/domains/models/dtos"
+	"go-server/internal/pkg/domains/models/entities"
+	"go-server/pkg/shared/auth"
+	"go-server/pkg/shared/database"
+	"go-server/pkg/shared/utils"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+var (
+	TwoFactorUserNotFound     = errors.New("User not found")
+	TwoFactorAlreadyEnabled   = errors.New("Two-factor authentication already enabled")
+	TwoFactorNotEnrolled      = errors.New("Two-factor authentication not enrolled")
+	TwoFactorNotEnabled       = errors.New("Two-factor authentication not enabled")
+	TwoFactorCodeInvalid      = errors.New("Code invalid")
+	TwoFactorRequiredForAdmin = errors.New("Two-factor authentication is mandatory for admins")
+	TwoFactorChallengeInvalid = errors.New("Challenge token invalid or expired")
+	TwoFactorTooManyAttempts  = errors.New("Too many failed codes, account temporarily locked")
+)
+
+const (
+	twoFactorChallengePurpose = "two_factor_login"
+	recoveryCodeCount         = 10
+	// failed codes before the account is locked, only a successful second step resets the count
+	maxTwoFactorFailures = 5
+)
+
+type twoFactorUsecase struct {
+	userRepo            interfaces.UserRepository
+	recoveryCodeRepo    interfaces.UserRecoveryCodeRepository
+	loginAttemptUsecase interfaces.LoginAttemptUsecase
+	logger              *logrus.Logger
+}
+
+func NewTwoFactorUsecase(
+	userRepo interfaces.UserRepository,
+	recoveryCodeRepo interfaces.UserRecoveryCodeRepository,
+	loginAttemptUsecase interfaces.LoginAttemptUsecase,
+	logger *logrus.Logger,
+) interfaces.TwoFactorUsecase {
+	return &twoFactorUsecase{
+		userRepo,
+		recoveryCodeRepo,
+		loginAttemptUsecase,
+		logger,
+	}
+}
+
+func (u *twoFactorUsecase) Status(
+	ctx context.Context,
+	userID int,
+) (dtos.TwoFactorStatusDto, error) {
+	user, err := u.takeUser(ctx, userID)
+	if err != nil {
+		return dtos.TwoFactorStatusDto{}, err
+	}
+	if !user.TwoFactorEnabled {
+		return dtos.TwoFactorStatusDto{}, nil
+	}
+
+	left, err := u.recoveryCodeRepo.CountUnused(ctx, userID)
+	if err != nil {
+		return dtos.TwoFactorStatusDto{}, err
+	}
+
+	return dtos.TwoFactorStatusDto{
+		Enabled:           true,
+		RecoveryCodesLeft: left,
+	}, nil
+}
+
+// Enroll generates a new pending secret, it is enabled once a code of it is confirmed by Enable
+func (u *twoFactorUsecase) Enroll(
+	ctx context.Context,
+	userID int,
+) (dtos.TwoFactorEnrollmentDto, error) {
+	user, err := u.takeUser(ctx, userID)
+	if err != nil {
+		return dtos.TwoFactorEnrollmentDto{}, err
+	}
+	if user.TwoFactorEnabled {
+		return dtos.TwoFactorEnrollmentDto{}, TwoFactorAlreadyEnabled
+	}
+
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		return dtos.TwoFactorEnrollmentDto{}, err
+	}
+
+	_, err = u.userRepo.UpdateColumns(ctx, user, map[string]interface{}{
+		"totp_secret":    secret,
+		"totp_last_step": 0,
+	})
+	if err != nil {
+		return dtos.TwoFactorEnrollmentDto{}, err
+	}
+
+	return dtos.TwoFactorEnrollmentDto{
+		Secret: secret,
+		URI:    auth.TOTPURI(secret, user.Email),
+	}, nil
+}
+
+// Enable turns two-factor authentication on with a code of the pending secret and returns
+// the recovery codes, they are shown only this once
+func (u *twoFactorUsecase) Enable(
+	ctx context.Context,
+	db *gorm.DB,
+	userID int,
+	req dtos.TwoFactorCodeRequestDto,
+) ([]string, error) {
+	user, err := u.takeUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TwoFactorEnabled {
+		return nil, TwoFactorAlreadyEnabled
+	}
+	if user.TOTPSecret == "" {
+		return nil, TwoFactorNotEnrolled
+	}
+
+	step, ok := auth.ValidateTOTP(user.TOTPSecret, req.Code, time.Now())
+	if !ok {
+		return nil, TwoFactorCodeInvalid
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	err = database.Transaction(ctx, db, func(tx *gorm.DB) error {
+		_, err := u.userRepo.UpdateColumnsWithTx(tx, user, map[string]interface{}{
+			"totp_last_step":        step,
+			"two_factor_enabled_at": &now,
+			"two_factor_failures":   0,
+		})
+		if err != nil {
+			return err
+		}
+
+		return u.recoveryCodeRepo.ReplaceWithTx(tx, user.ID, hashes)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// Disable turns two-factor authentication off, admins cannot while it is mandatory
+func (u *twoFactorUsecase) Disable(
+	ctx context.Context,
+	db *gorm.DB,
+	userID int,
+	req dtos.TwoFactorProofDto,
+) error {
+	user, err := u.takeUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !user.TwoFactorEnabled {
+		return TwoFactorNotEnabled
+	}
+	if user.IsAdmin && auth.AdminTwoFactorRequired() {
+		return TwoFactorRequiredForAdmin
+	}
+
+	err = u.verifyProof(ctx, user, req)
+	if err != nil {
+		return err
+	}
+
+	return database.Transaction(ctx, db, func(tx *gorm.DB) error {
+		_, err := u.userRepo.UpdateColumnsWithTx(tx, user, map[string]interface{}{
+			"totp_secret":           "",
+			"totp_last_step":        0,
+			"two_factor_enabled_at": nil,
+		})
+		if err != nil {
+			return err
+		}
+
+		return u.recoveryCodeRepo.DeleteByUserWithTx(tx, user.ID)
+	})
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes, the previous ones stop working
+func (u *twoFactorUsecase) RegenerateRecoveryCodes(
+	ctx context.Context,
+	db *gorm.DB,
+	userID int,
+	req dtos.TwoFactorCodeRequestDto,
+) ([]string, error) {
+	user, err := u.takeUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.TwoFactorEnabled {
+		return nil, TwoFactorNotEnabled
+	}
+
+	err = u.verifyProof(ctx, user, dtos.TwoFactorProofDto{Code: req.Code})
+	if err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	err = database.Transaction(ctx, db, func(tx *gorm.DB) error {
+		return u.recoveryCodeRepo.ReplaceWithTx(tx, user.ID, hashes)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// Challenge is the first login step of a user with two-factor authentication, admin marks
+// challenges of the admin login which the second step only completes for admins.
+// A new challenge replaces the pending one of the user
+func (u *twoFactorUsecase) Challenge(
+	ctx context.Context,
+	user entities.User,
+	admin bool,
+) (dtos.TwoFactorChallengeDto, error) {
+	nonce, nonceHash, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		return dtos.TwoFactorChallengeDto{}, err
+	}
+
+	_, err = u.userRepo.UpdateColumns(ctx, user, map[string]interface{}{
+		"two_factor_challenge_hash": nonceHash,
+	})
+	if err != nil {
+		return dtos.TwoFactorChallengeDto{}, err
+	}
+
+	ttl := utils.DurationFromEnv("TWO_FACTOR_CHALLENGE_TTL", 5*time.Minute)
+	token, expiresAt, err := auth.GenerateSignedToken(twoFactorChallengePurpose, map[string]interface{}{
+		"user_id": user.ID,
+		"admin":   admin,
+		"nonce":   nonce,
+	}, ttl)
+	if err != nil {
+		return dtos.TwoFactorChallengeDto{}, err
+	}
+
+	return dtos.TwoFactorChallengeDto{
+		TwoFactorRequired: true,
+		ChallengeToken:    token,
+		ExpiresAt:         expiresAt.Unix(),
+	}, nil
+}
+
+// VerifyChallenge is the second login step, the session is then started by TokenUsecase.IssueTwoFactor.
+// Each challenge completes once, the account is locked after maxTwoFactorFailures wrong codes
+func (u *twoFactorUsecase) VerifyChallenge(
+	ctx context.Context,
+	req dtos.TwoFactorLoginRequestDto,
+) (entities.User, error) {
+	claims, err := auth.ParseSignedToken(twoFactorChallengePurpose, req.ChallengeToken)
+	if err != nil {
+		return entities.User{}, TwoFactorChallengeInvalid
+	}
+	userID, ok := claims["user_id"].(float64)
+	if !ok {
+		return entities.User{}, TwoFactorChallengeInvalid
+	}
+
+	user, err := u.takeUser(ctx, int(userID))
+	if err != nil {
+		if errors.Is(err, TwoFactorUserNotFound) {
+			return entities.User{}, TwoFactorChallengeInvalid
+		}
+		return entities.User{}, err
+	}
+	if !user.Active || !user.TwoFactorEnabled {
+		return entities.User{}, TwoFactorChallengeInvalid
+	}
+	if admin, _ := claims["admin"].(bool); admin && !user.IsAdmin {
+		return entities.User{}, TwoFactorChallengeInvalid
+	}
+	nonce, _ := claims["nonce"].(string)
+	if nonce == "" || user.TwoFactorChallengeHash != auth.HashToken(nonce) {
+		return entities.User{}, TwoFactorChallengeInvalid
+	}
+	if user.TwoFactorFailures >= maxTwoFactorFailures {
+		return entities.User{}, u.lockOut(ctx, user)
+	}
+
+	err = u.verifyProof(ctx, user, req.TwoFactorProofDto)
+	if err != nil {
+		if !errors.Is(err, TwoFactorCodeInvalid) {
+			return entities.User{}, err
+		}
+		_, updateErr := u.userRepo.UpdateColumns(ctx, user, map[string]interface{}{
+			"two_factor_failures": gorm.Expr("two_factor_failures + 1"),
+		})
+		if updateErr != nil {
+			return entities.User{}, updateErr
+		}
+		if user.TwoFactorFailures+1 >= maxTwoFactorFailures {
+			return entities.User{}, u.lockOut(ctx, user)
+		}
+		return entities.User{}, err
+	}
+
+	consumed, err := u.userRepo.ConsumeTwoFactorChallenge(ctx, user.ID, user.TwoFactorChallengeHash)
+	if err != nil {
+		return entities.User{}, err
+	}
+	if !consumed {
+		return entities.User{}, TwoFactorChallengeInvalid
+	}
+
+	return user, nil
+}
+
+// lockOut locks the account whose second factor failed too often and drops its pending
+// challenge, the count starts over once the lockout is over
+func (u *twoFactorUsecase) lockOut(
+	ctx context.Context,
+	user entities.User,
+) error {
+	_, err := u.userRepo.UpdateColumns(ctx, user, map[string]interface{}{
+		"two_factor_failures":       0,
+		"two_factor_challenge_hash": "",
+	})
+	if err != nil {
+		return err
+	}
+
+	err = u.loginAttemptUsecase.Lock(ctx, user.Email)
+	if err != nil {
+		return err
+	}
+
+	return TwoFactorTooManyAttempts
+}
+
+// verifyProof checks the TOTP code, or consumes the recovery code
+func (u *twoFactorUsecase) verifyProof(
+	ctx context.Context,
+	user entities.User,
+	proof dtos.TwoFactorProofDto,
+) error {
+	if proof.Code == "" {
+		used, err := u.recoveryCodeRepo.Use(ctx, user.ID, auth.HashRecoveryCode(proof.RecoveryCode))
+		if err != nil {
+			return err
+		}
+		if !used {
+			return TwoFactorCodeInvalid
+		}
+		return nil
+	}
+
+	step, ok := auth.ValidateTOTP(user.TOTPSecret, proof.Code, time.Now())
+	if !ok || step <= user.TOTPLastStep {
+		return TwoFactorCodeInvalid
+	}
+
+	advanced, err := u.userRepo.AdvanceTOTPStep(ctx, user.ID, step)
+	if err != nil {
+		return err
+	}
+	if !advanced {
+		return TwoFactorCodeInvalid
+	}
+
+	return nil
+}
+
+func (u *twoFactorUsecase) takeUser(ctx context.Context, userID int) (entities.User, error) {
+	user, err := u.userRepo.TakeByConditions(ctx, map[string]interface{}{
+		"id": userID,
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return entities.User{}, TwoFactorUserNotFound
+		}
+		return entities.User{}, err
+	}
+
+	return user, nil
+}
+
+func newRecoveryCodes() ([]string, []string, error) {
+	codes, err := auth.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	hashes := make([]string, len(codes))
+	for i, code := range codes {
+		hashes[i] = auth.HashRecoveryCode(code)
+	}
+
+	return codes, hashes, nil
+}
@@ -0,0 +1,143 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go-server/internal/pkg/domains/interfaces"
+	"go-server/internal/pkg/domains/models/dtos"
+	"go-server/internal/pkg/domains/models/entities"
+	"go-server/internal/pkg/repositories"
+	"go-server/pkg/shared/auth"
+
+	"gorm.io/gorm"
+)
+
+const testRecoveryCode = "abcd-efgh"
+
+func newTestTwoFactorUsecase(t *testing.T) (interfaces.TwoFactorUsecase, interfaces.LoginAttemptUsecase, *gorm.DB, entities.User) {
+	t.Helper()
+
+	db := newTestDB(t, &entities.User{}, &entities.UserRecoveryCode{})
+	logger := newTestLogger()
+	userRepo := repositories.NewUserRepository(db, logger)
+	loginAttemptUsecase := NewLoginAttemptUsecase(repositories.NewMemoryLoginAttemptStore(), userRepo, &fakeMailService{}, logger)
+	usecase := NewTwoFactorUsecase(userRepo, repositories.NewUserRecoveryCodeRepository(db, logger), loginAttemptUsecase, logger)
+
+	enabledAt := time.Now()
+	secret, _ := auth.GenerateTOTPSecret()
+	user := entities.User{
+		Username:           "owner",
+		Email:              "owner@example.com",
+		Password:           "hash",
+		Active:             true,
+		TOTPSecret:         secret,
+		TwoFactorEnabledAt: &enabledAt,
+	}
+	db.Create(&user)
+	db.Create(&entities.UserRecoveryCode{UserID: user.ID, CodeHash: auth.HashRecoveryCode(testRecoveryCode)})
+	user.TwoFactorEnabled = true
+
+	return usecase, loginAttemptUsecase, db, user
+}
+
+func challenge(t *testing.T, usecase interfaces.TwoFactorUsecase, user entities.User) string {
+	t.Helper()
+
+	challenge, err := usecase.Challenge(context.Background(), user, false)
+	if err != nil {
+		t.Fatalf("challenge: %v", err)
+	}
+	return challenge.ChallengeToken
+}
+
+func verifyChallenge(usecase interfaces.TwoFactorUsecase, token string, recoveryCode string) error {
+	_, err := usecase.VerifyChallenge(context.Background(), dtos.TwoFactorLoginRequestDto{
+		ChallengeToken:    token,
+		TwoFactorProofDto: dtos.TwoFactorProofDto{RecoveryCode: recoveryCode},
+	})
+	return err
+}
+
+func TestChallengeCompletesOnce(t *testing.T) {
+	usecase, _, _, user := newTestTwoFactorUsecase(t)
+	replaced := challenge(t, usecase, user)
+	token := challenge(t, usecase, user)
+
+	err := verifyChallenge(usecase, replaced, testRecoveryCode)
+	if !errors.Is(err, TwoFactorChallengeInvalid) {
+		t.Fatalf("replaced challenge returned %v", err)
+	}
+	err = verifyChallenge(usecase, token, testRecoveryCode)
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	err = verifyChallenge(usecase, token, testRecoveryCode)
+	if !errors.Is(err, TwoFactorChallengeInvalid) {
+		t.Fatalf("used challenge returned %v", err)
+	}
+}
+
+func TestTwoFactorFailuresSurviveNewChallenges(t *testing.T) {
+	usecase, loginAttemptUsecase, db, user := newTestTwoFactorUsecase(t)
+
+	for i := 1; i < maxTwoFactorFailures; i++ {
+		err := verifyChallenge(usecase, challenge(t, usecase, user), "wrong-code")
+		if !errors.Is(err, TwoFactorCodeInvalid) {
+			t.Fatalf("failure %d returned %v", i, err)
+		}
+	}
+	err := verifyChallenge(usecase, challenge(t, usecase, user), "wrong-code")
+	if !errors.Is(err, TwoFactorTooManyAttempts) {
+		t.Fatalf("failure %d returned %v", maxTwoFactorFailures, err)
+	}
+
+	err = loginAttemptUsecase.Check(context.Background(), user.Email, "")
+	if !errors.Is(err, LoginAccountLocked) {
+		t.Fatalf("account not locked, check returned %v", err)
+	}
+	var stored entities.User
+	db.First(&stored, user.ID)
+	if stored.TwoFactorFailures != 0 || stored.TwoFactorChallengeHash != "" {
+		t.Fatalf("lockout kept %d failures and challenge %q", stored.TwoFactorFailures, stored.TwoFactorChallengeHash)
+	}
+}
+
+func TestTwoFactorSuccessResetsFailures(t *testing.T) {
+	usecase, _, db, user := newTestTwoFactorUsecase(t)
+	token := challenge(t, usecase, user)
+	_ = verifyChallenge(usecase, token, "wrong-code")
+
+	err := verifyChallenge(usecase, token, testRecoveryCode)
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+
+	var stored entities.User
+	db.First(&stored, user.ID)
+	if stored.TwoFactorFailures != 0 {
+		t.Fatalf("%d failures kept after a successful second step", stored.TwoFactorFailures)
+	}
+}
+
+func TestAdvanceTOTPStepAcceptsAStepOnce(t *testing.T) {
+	db := newTestDB(t, &entities.User{})
+	userRepo := repositories.NewUserRepository(db, newTestLogger())
+	user := entities.User{Username: "owner", Email: "owner@example.com", Password: "hash", TOTPLastStep: 10}
+	db.Create(&user)
+
+	for _, attempt := range []struct {
+		step int64
+		want bool
+	}{{10, false}, {11, true}, {11, false}} {
+		advanced, err := userRepo.AdvanceTOTPStep(context.Background(), user.ID, attempt.step)
+		if err != nil {
+			t.Fatalf("advance: %v", err)
+		}
+		if advanced != attempt.want {
+			t.Fatalf("step %d advanced %v, want %v", attempt.step, advanced, attempt.want)
+		}
+	}
+}
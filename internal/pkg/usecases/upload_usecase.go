@@ -3,7 +3,9 @@ package usecases
 import (
 	"context"
 	"go-server/internal/pkg/domains/interfaces"
+	"net/url"
 	"os"
+	"strings"
 
 	"github.com/cloudinary/cloudinary-go/v2"
 	"github.com/cloudinary/cloudinary-go/v2/api/uploader"
@@ -39,3 +41,18 @@ func (u *uploadUsecase) FileUpload(
 
 	return uploadParam.URL, nil
 }
+
+// IsUploadedURL tells whether rawURL is a file of our cloudinary account, as returned by FileUpload
+func (u *uploadUsecase) IsUploadedURL(
+	rawURL string,
+) bool {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return false
+	}
+
+	return parsed.Host == "res.cloudinary.com" && strings.HasPrefix(parsed.Path, "/"+u.cld.Config.Cloud.CloudName+"/")
+}
@@ -6,11 +6,16 @@ import (
 	"go-server/internal/pkg/domains/interfaces"
 	"go-server/internal/pkg/domains/models/dtos"
 	"go-server/internal/pkg/domains/models/entities"
+	"go-server/internal/pkg/services"
 	"go-server/pkg/shared/auth"
+	"go-server/pkg/shared/database"
+	"go-server/pkg/shared/realtime"
 	"go-server/pkg/shared/utils"
+	"net/url"
+	"os"
 	"strings"
+	"time"
 
-	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
 )
@@ -19,24 +24,58 @@ var (
 	RegisterUsernameExisted = errors.New("Username existed")
 	RegisterEmailExisted    = errors.New("Email existed")
 
-	EmailNotFound = errors.New("Email not found")
-	WrongPassword = errors.New("Wrong password")
+	EmailNotFound         = errors.New("Email not found")
+	WrongPassword         = errors.New("Wrong password")
+	LoginEmailNotVerified = errors.New("Email not verified")
 
 	UpdateUserIDNotFound = errors.New("User not found")
 	DetailUserIDNotFound = errors.New("User not found")
+
+	VerifyEmailTokenInvalid           = errors.New("Verification link invalid or expired")
+	ResendVerificationAlreadyVerified = errors.New("Email already verified")
+	ResendVerificationTooSoon         = errors.New("Verification email sent recently")
+
+	UpdateStatusLastSuperAdmin = errors.New("The last super admin cannot be deactivated")
+
+	ResetPasswordTokenInvalid = errors.New("Reset token invalid")
+	ResetPasswordTokenExpired = errors.New("Reset token expired")
+	ResetPasswordNotMatch     = errors.New("Password not match")
 )
 
+const emailVerificationPurpose = "email_verification"
+
+// EmailVerificationRequired reports whether users must verify their email before logging in,
+// set per environment with EMAIL_VERIFICATION_REQUIRED
+func EmailVerificationRequired() bool {
+	return utils.BoolFromEnv("EMAIL_VERIFICATION_REQUIRED", false)
+}
+
 type userUsecase struct {
-	userRepo interfaces.UserRepository
-	logger   *logrus.Logger
+	userRepo          interfaces.UserRepository
+	userTokenRepo     interfaces.UserTokenRepository
+	passwordResetRepo interfaces.PasswordResetTokenRepository
+	roleRepo          interfaces.RoleRepository
+	mailService       services.MailServiceInterface
+	sessions          realtime.SessionCloser
+	logger            *logrus.Logger
 }
 
 func NewUserUsecase(
 	userRepo interfaces.UserRepository,
+	userTokenRepo interfaces.UserTokenRepository,
+	passwordResetRepo interfaces.PasswordResetTokenRepository,
+	roleRepo interfaces.RoleRepository,
+	mailService services.MailServiceInterface,
+	sessions realtime.SessionCloser,
 	logger *logrus.Logger,
 ) interfaces.UserUsecase {
 	return &userUsecase{
 		userRepo,
+		userTokenRepo,
+		passwordResetRepo,
+		roleRepo,
+		mailService,
+		sessions,
 		logger,
 	}
 }
@@ -72,37 +111,31 @@ func (u *userUsecase) TakeByConditions(ctx context.Context, conditions map[strin
 	return user, err
 }
 
+// Login checks the credentials, the session is started by TokenUsecase.Issue
 func (u *userUsecase) Login(
 	ctx context.Context,
 	req dtos.LoginRequestDto,
-) (entities.User, string, string, error) {
+) (entities.User, error) {
 	user, err := u.userRepo.TakeByConditions(ctx, map[string]interface{}{
 		"email": req.Email,
 	})
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return entities.User{}, "", "", EmailNotFound
+			return entities.User{}, EmailNotFound
 		}
-		return entities.User{}, "", "", err
+		return entities.User{}, err
 	}
 
 	if !utils.CheckPasswordHash(req.Password, user.Password) {
-		return entities.User{}, "", "", WrongPassword
+		return entities.User{}, WrongPassword
 	}
 
-	tokenID := uuid.New().String()
-	accessToken, err := auth.GenerateHS256JWT(map[string]interface{}{
-		"user_id":  user.ID,
-		"sub":      user.Username,
-		"email":    user.Email,
-		"is_admin": user.IsAdmin,
-		"token_id": tokenID,
-	})
-	if err != nil {
-		return entities.User{}, "", "", err
+	// admins are created from the CMS and are never blocked
+	if EmailVerificationRequired() && !user.EmailVerified && !user.IsAdmin {
+		return entities.User{}, LoginEmailNotVerified
 	}
 
-	return user, accessToken, tokenID, nil
+	return user, nil
 }
 
 func (u *userUsecase) Update(
@@ -125,3 +158,270 @@ func (u *userUsecase) Update(
 
 	return user, nil
 }
+
+// SendVerificationEmail mails user a signed link to GET /api/auth/verify_email
+func (u *userUsecase) SendVerificationEmail(
+	ctx context.Context,
+	user entities.User,
+) error {
+	return sendVerificationEmail(ctx, u.userRepo, u.mailService, user)
+}
+
+// sendVerificationEmail mails the verification link to the user, on registration and on the
+// first login through a provider not trusted with the email
+func sendVerificationEmail(
+	ctx context.Context,
+	userRepo interfaces.UserRepository,
+	mailService services.MailServiceInterface,
+	user entities.User,
+) error {
+	ttl := utils.DurationFromEnv("EMAIL_VERIFICATION_TTL", 24*time.Hour)
+	token, _, err := auth.GenerateSignedToken(emailVerificationPurpose, map[string]interface{}{
+		"user_id": user.ID,
+		"email":   user.Email,
+	}, ttl)
+	if err != nil {
+		return err
+	}
+
+	err = mailService.SendMail("email_verification_template.html", "Verify your email", map[string]interface{}{
+		"to":         user.Email,
+		"username":   user.Username,
+		"link":       appURL() + "/api/auth/verify_email?token=" + url.QueryEscape(token),
+		"expires_in": ttl.String(),
+		"year":       time.Now().Year(),
+	})
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	_, err = userRepo.UpdateColumns(ctx, user, map[string]interface{}{
+		"verification_sent_at": &now,
+	})
+	return err
+}
+
+// ResendVerificationEmail sends a new link at most once per EMAIL_VERIFICATION_RESEND_INTERVAL,
+// the returned duration is the time left before the next one is allowed.
+// Unknown emails are not reported so that the endpoint cannot be used to find accounts
+func (u *userUsecase) ResendVerificationEmail(
+	ctx context.Context,
+	req dtos.ResendVerificationRequestDto,
+) (time.Duration, error) {
+	user, err := u.userRepo.TakeByConditions(ctx, map[string]interface{}{
+		"email": req.Email,
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	if user.EmailVerified {
+		return 0, ResendVerificationAlreadyVerified
+	}
+
+	if user.VerificationSentAt != nil {
+		interval := utils.DurationFromEnv("EMAIL_VERIFICATION_RESEND_INTERVAL", time.Minute)
+		retryAfter := time.Until(user.VerificationSentAt.Add(interval))
+		if retryAfter > 0 {
+			return retryAfter, ResendVerificationTooSoon
+		}
+	}
+
+	return 0, u.SendVerificationEmail(ctx, user)
+}
+
+// VerifyEmail marks the account of the token as verified, the token is only valid
+// for the email it was sent to
+func (u *userUsecase) VerifyEmail(
+	ctx context.Context,
+	token string,
+) (entities.User, error) {
+	claims, err := auth.ParseSignedToken(emailVerificationPurpose, token)
+	if err != nil {
+		return entities.User{}, VerifyEmailTokenInvalid
+	}
+
+	userID, ok := claims["user_id"].(float64)
+	if !ok {
+		return entities.User{}, VerifyEmailTokenInvalid
+	}
+
+	user, err := u.userRepo.TakeByConditions(ctx, map[string]interface{}{
+		"id": int(userID),
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return entities.User{}, VerifyEmailTokenInvalid
+		}
+		return entities.User{}, err
+	}
+
+	if claims["email"] != user.Email {
+		return entities.User{}, VerifyEmailTokenInvalid
+	}
+	if user.EmailVerified {
+		return user, nil
+	}
+
+	now := time.Now()
+	user, err = u.userRepo.UpdateColumns(ctx, user, map[string]interface{}{
+		"email_verified_at": &now,
+	})
+	if err != nil {
+		return entities.User{}, err
+	}
+	user.EmailVerified = true
+
+	return user, nil
+}
+
+// ForgotPassword mails a single-use reset link to the email of the account, pending
+// links of the user stop working. The password is only changed by ResetPassword, an
+// unknown email sends nothing and succeeds the same
+func (u *userUsecase) ForgotPassword(
+	ctx context.Context,
+	db *gorm.DB,
+	req dtos.ForgotPasswordRequestDto,
+) error {
+	user, err := u.userRepo.TakeByConditions(ctx, map[string]interface{}{
+		"email": req.Email,
+	})
+	if err != nil {
+		// an unknown email is answered like a known one, accounts are not enumerated
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	token, hash, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		return err
+	}
+
+	ttl := utils.DurationFromEnv("PASSWORD_RESET_TTL", time.Hour)
+	err = database.Transaction(ctx, db, func(tx *gorm.DB) error {
+		err := u.passwordResetRepo.InvalidateByUserWithTx(tx, user.ID)
+		if err != nil {
+			return err
+		}
+
+		_, err = u.passwordResetRepo.CreateWithTx(tx, entities.PasswordResetToken{
+			UserID:    user.ID,
+			TokenHash: hash,
+			ExpiresAt: time.Now().Add(ttl),
+		})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	resetURL := os.Getenv("PASSWORD_RESET_URL")
+	if resetURL == "" {
+		resetURL = appURL() + "/reset_password"
+	}
+
+	return u.mailService.SendMail("password_reset_template.html", "Reset your password", map[string]interface{}{
+		"to":         user.Email,
+		"username":   user.Username,
+		"link":       resetURL + "?token=" + url.QueryEscape(token),
+		"expires_in": ttl.String(),
+		"year":       time.Now().Year(),
+	})
+}
+
+// ResetPassword consumes the reset token, sets the new password and revokes every session of the user
+func (u *userUsecase) ResetPassword(
+	ctx context.Context,
+	db *gorm.DB,
+	req dtos.ResetPasswordRequestDto,
+) error {
+	if req.NewPassword != req.ConfirmPassword {
+		return ResetPasswordNotMatch
+	}
+
+	token, err := u.passwordResetRepo.TakeByConditions(ctx, map[string]interface{}{
+		"token_hash": auth.HashToken(req.Token),
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ResetPasswordTokenInvalid
+		}
+		return err
+	}
+	if token.UsedAt != nil {
+		return ResetPasswordTokenInvalid
+	}
+	if time.Now().After(token.ExpiresAt) {
+		return ResetPasswordTokenExpired
+	}
+
+	hashedPass, err := utils.HashPassword(req.NewPassword)
+	if err != nil {
+		return err
+	}
+
+	err = database.Transaction(ctx, db, func(tx *gorm.DB) error {
+		ok, err := u.passwordResetRepo.MarkUsedWithTx(tx, token)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ResetPasswordTokenInvalid
+		}
+
+		err = u.passwordResetRepo.InvalidateByUserWithTx(tx, token.UserID)
+		if err != nil {
+			return err
+		}
+
+		_, err = u.userRepo.UpdateColumnsWithTx(tx, entities.User{ID: token.UserID}, map[string]interface{}{
+			"password": hashedPass,
+		})
+		if err != nil {
+			return err
+		}
+
+		return u.userTokenRepo.DeleteByConditionsWithTx(tx, map[string]interface{}{
+			"user_id": token.UserID,
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	u.sessions.CloseUser(token.UserID)
+	return nil
+}
+
+// appURL is the public base url of the links in the emails
+func appURL() string {
+	return strings.TrimRight(os.Getenv("APP_URL"), "/")
+}
+
+// UpdateStatus activates or deactivates the user, the last active super admin stays active
+func (u *userUsecase) UpdateStatus(
+	ctx context.Context,
+	db *gorm.DB,
+	user entities.User,
+	active bool,
+) error {
+	return database.Transaction(ctx, db, func(tx *gorm.DB) error {
+		if !active {
+			err := guardLastSuperAdminWithTx(tx, u.roleRepo, user.ID, UpdateStatusLastSuperAdmin)
+			if err != nil {
+				return err
+			}
+		}
+
+		_, err := u.userRepo.UpdateColumnsWithTx(tx, user, map[string]interface{}{
+			"active": active,
+		})
+		return err
+	})
+}
@@ -0,0 +1,27 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// apiKeyPrefix tells our keys apart in logs and secret scanners
+const apiKeyPrefix = "tvx_"
+
+// GenerateAPIKey returns a new key, the part of it shown to identify the key and its hash,
+// only the prefix and the hash are stored
+func GenerateAPIKey() (string, string, string, error) {
+	id := make([]byte, 4)
+	_, err := rand.Read(id)
+	if err != nil {
+		return "", "", "", err
+	}
+	secret, _, err := GenerateOpaqueToken()
+	if err != nil {
+		return "", "", "", err
+	}
+
+	prefix := apiKeyPrefix + hex.EncodeToString(id)
+	key := prefix + "_" + secret
+	return key, prefix, HashToken(key), nil
+}
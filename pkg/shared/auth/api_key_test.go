@@ -0,0 +1,38 @@
+package auth
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func TestGenerateAPIKey(t *testing.T) {
+	key, prefix, hash, err := GenerateAPIKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(prefix, apiKeyPrefix) || len(prefix) != len(apiKeyPrefix)+8 {
+		t.Fatalf("unexpected prefix %q", prefix)
+	}
+	if !strings.HasPrefix(key, prefix+"_") || len(key) <= len(prefix)+1 {
+		t.Fatalf("key %q does not start with its prefix %q", key, prefix)
+	}
+	if hash != HashToken(key) {
+		t.Fatal("hash is not the hash of the key")
+	}
+	if decoded, err := hex.DecodeString(hash); err != nil || len(decoded) != 32 {
+		t.Fatalf("hash %q is not a hex SHA-256", hash)
+	}
+	if strings.Contains(hash, strings.TrimPrefix(key, prefix+"_")) {
+		t.Fatal("hash contains the secret")
+	}
+
+	other, otherPrefix, otherHash, err := GenerateAPIKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other == key || otherPrefix == prefix || otherHash == hash {
+		t.Fatal("two keys share a value")
+	}
+}
@@ -1,46 +1,48 @@
 package auth
 
 import (
-	"os"
-	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
-// Decoding JWT to get payload, not verifying JWT
-func Decode(JWTToken string) (*jwt.Token, error) {
-	token, err := jwt.Parse(JWTToken, func(token *jwt.Token) (interface{}, error) {
-		return nil, nil
-	})
-	if strings.Contains(err.Error(), jwt.ErrInvalidKeyType.Error()) {
-		return token, nil
+// Generate JWT access token signed with the active key, valid from now for AccessTokenTTL, and return its expiry
+func GenerateAccessToken(payload map[string]interface{}) (string, time.Time, error) {
+	keys, err := Keys()
+	if err != nil {
+		return "", time.Time{}, err
 	}
-	return nil, err
-}
 
-// Generate HS256 JWT token
-func GenerateHS256JWT(payload map[string]interface{}) (string, error) {
 	claims := jwt.MapClaims{}
 	for key, val := range payload {
 		claims[key] = val
 	}
-	claims["exp"] = time.Now().Add(time.Hour * 24 * 7).Unix()
-	claims["iat"] = time.Now().Add(time.Hour * 24 * 7).Unix()
+	now := time.Now()
+	expiresAt := now.Add(AccessTokenTTL())
+	claims["exp"] = expiresAt.Unix()
+	claims["iat"] = now.Unix()
+	claims["nbf"] = now.Unix()
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	signedToken, err := token.SignedString([]byte(os.Getenv("JWT_KEY")))
-	return signedToken, err
+	signedToken, err := keys.Sign(claims)
+	return signedToken, expiresAt, err
 }
 
-// Verify JWT func
-func VerifyJWT(tokenString string) bool {
-	// Parse the token
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		return []byte(os.Getenv("JWT_KEY")), nil
-	})
+// ParseAccessToken verifies an access token and returns its claims, the tokens of
+// GenerateSignedToken are refused
+func ParseAccessToken(tokenString string) (jwt.MapClaims, error) {
+	keys, err := Keys()
+	if err != nil {
+		return nil, err
+	}
+
+	claims := jwt.MapClaims{}
+	err = keys.Parse(tokenString, claims)
 	if err != nil {
-		return false
+		return nil, err
 	}
-	return token.Valid
+	if _, ok := claims["purpose"]; ok {
+		return nil, ErrSignedTokenPurpose
+	}
+
+	return claims, nil
 }
@@ -0,0 +1,229 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"go-server/pkg/shared/utils"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const defaultKeyGracePeriod = 24 * time.Hour
+
+var (
+	ErrUnknownSigningKey = errors.New("unknown signing key")
+	ErrRetiredSigningKey = errors.New("signing key retired")
+)
+
+// SigningKey signs and verifies our JWTs, the ID is sent as the kid header
+type SigningKey struct {
+	ID     string
+	Method jwt.SigningMethod
+	// *rsa.PrivateKey, ed25519.PrivateKey or the HS256 secret
+	private interface{}
+	public  interface{}
+}
+
+// Keyring holds the key signing new tokens and the keys it replaced, which still verify
+// tokens until the grace period after the rotation is over
+type Keyring struct {
+	active       *SigningKey
+	keys         map[string]*SigningKey
+	retiredUntil time.Time
+}
+
+var (
+	keyringOnce sync.Once
+	keyring     *Keyring
+	keyringErr  error
+)
+
+// Keys loads the keyring once from the environment:
+//   - JWT_SIGNING_KEYS lists "kid=path" PEM private keys (RSA for RS256, Ed25519 for EdDSA),
+//     the first one signs and the others are retired
+//   - JWT_KEY_ROTATED_AT (RFC 3339) and JWT_KEY_GRACE_PERIOD bound the verification of the
+//     retired keys, the date is required once there is a retired key so that restarts do not
+//     extend their grace period
+//   - JWT_KEY is the legacy HS256 secret, it signs when no key is listed and is retired otherwise
+func Keys() (*Keyring, error) {
+	keyringOnce.Do(func() {
+		keyring, keyringErr = loadKeyring()
+	})
+	return keyring, keyringErr
+}
+
+func loadKeyring() (*Keyring, error) {
+	k := &Keyring{
+		keys: map[string]*SigningKey{},
+	}
+	for _, entry := range strings.Split(os.Getenv("JWT_SIGNING_KEYS"), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		kid, path, ok := strings.Cut(entry, "=")
+		if !ok || kid == "" || path == "" {
+			return nil, fmt.Errorf("JWT_SIGNING_KEYS: invalid entry %q, expected kid=path", entry)
+		}
+		if _, exists := k.keys[kid]; exists {
+			return nil, fmt.Errorf("JWT_SIGNING_KEYS: duplicate kid %q", kid)
+		}
+
+		key, err := readSigningKey(kid, path)
+		if err != nil {
+			return nil, err
+		}
+		k.keys[kid] = key
+		if k.active == nil {
+			k.active = key
+		}
+	}
+
+	// tokens signed with the secret carry no kid
+	if secret := os.Getenv("JWT_KEY"); secret != "" {
+		legacy := &SigningKey{
+			Method:  jwt.SigningMethodHS256,
+			private: []byte(secret),
+			public:  []byte(secret),
+		}
+		k.keys[""] = legacy
+		if k.active == nil {
+			k.active = legacy
+		}
+	}
+
+	if k.active == nil {
+		return nil, errors.New("no signing key, set JWT_SIGNING_KEYS or JWT_KEY")
+	}
+
+	value := os.Getenv("JWT_KEY_ROTATED_AT")
+	if value == "" {
+		if len(k.keys) > 1 {
+			return nil, errors.New("JWT_KEY_ROTATED_AT is required when keys are retired")
+		}
+		return k, nil
+	}
+	rotatedAt, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, fmt.Errorf("JWT_KEY_ROTATED_AT: %w", err)
+	}
+	k.retiredUntil = rotatedAt.Add(utils.DurationFromEnv("JWT_KEY_GRACE_PERIOD", defaultKeyGracePeriod))
+
+	return k, nil
+}
+
+func readSigningKey(kid string, path string) (*SigningKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("signing key %s: %w", kid, err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("signing key %s: no PEM block", kid)
+	}
+
+	var private interface{}
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		private, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		private, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		err = fmt.Errorf("unsupported PEM block %s", block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("signing key %s: %w", kid, err)
+	}
+
+	switch key := private.(type) {
+	case *rsa.PrivateKey:
+		return &SigningKey{ID: kid, Method: jwt.SigningMethodRS256, private: key, public: key.Public()}, nil
+	case ed25519.PrivateKey:
+		return &SigningKey{ID: kid, Method: jwt.SigningMethodEdDSA, private: key, public: key.Public()}, nil
+	}
+
+	return nil, fmt.Errorf("signing key %s: only RSA and Ed25519 keys are supported", kid)
+}
+
+// Sign signs the claims with the active key
+func (k *Keyring) Sign(claims jwt.MapClaims) (string, error) {
+	token := jwt.NewWithClaims(k.active.Method, claims)
+	if k.active.ID != "" {
+		token.Header["kid"] = k.active.ID
+	}
+
+	return token.SignedString(k.active.private)
+}
+
+// Parse verifies the signature and the time claims of the token and fills claims,
+// retired keys are refused once the grace period is over
+func (k *Keyring) Parse(tokenString string, claims jwt.MapClaims) error {
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, ok := k.keys[kid]
+		if !ok {
+			return nil, ErrUnknownSigningKey
+		}
+		if key.Method.Alg() != token.Method.Alg() {
+			return nil, jwt.ErrTokenSignatureInvalid
+		}
+		if key != k.active && time.Now().After(k.retiredUntil) {
+			return nil, ErrRetiredSigningKey
+		}
+
+		return key.public, nil
+	}, jwt.WithValidMethods([]string{
+		jwt.SigningMethodHS256.Alg(),
+		jwt.SigningMethodRS256.Alg(),
+		jwt.SigningMethodEdDSA.Alg(),
+	}))
+
+	return err
+}
+
+// JWKS publishes the public keys still verifying tokens, the HS256 secret never is
+func (k *Keyring) JWKS() JWKS {
+	jwks := JWKS{Keys: []JWK{}}
+	for _, key := range k.sortedKeys() {
+		if key.ID == "" {
+			continue
+		}
+		if key != k.active && time.Now().After(k.retiredUntil) {
+			continue
+		}
+
+		jwk, err := NewJWK(key.ID, key.Method.Alg(), key.public)
+		if err != nil {
+			continue
+		}
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+
+	return jwks
+}
+
+// sortedKeys lists the active key first so that the set is stable
+func (k *Keyring) sortedKeys() []*SigningKey {
+	keys := []*SigningKey{k.active}
+	ids := make([]string, 0, len(k.keys))
+	for id, key := range k.keys {
+		if key != k.active {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		keys = append(keys, k.keys[id])
+	}
+
+	return keys
+}
@@ -0,0 +1,162 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// writeSigningKey writes a new Ed25519 PEM private key and returns its "kid=path" entry
+func writeSigningKey(t *testing.T, kid string) string {
+	t.Helper()
+
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), kid+".pem")
+	err = os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return kid + "=" + path
+}
+
+func setKeyringEnv(t *testing.T, signingKeys []string, rotatedAt string, secret string) {
+	t.Helper()
+
+	t.Setenv("JWT_SIGNING_KEYS", strings.Join(signingKeys, ","))
+	t.Setenv("JWT_KEY_ROTATED_AT", rotatedAt)
+	t.Setenv("JWT_KEY_GRACE_PERIOD", "1h")
+	t.Setenv("JWT_KEY", secret)
+}
+
+func mustLoadKeyring(t *testing.T) *Keyring {
+	t.Helper()
+
+	keyring, err := loadKeyring()
+	if err != nil {
+		t.Fatalf("load keyring: %v", err)
+	}
+	return keyring
+}
+
+func TestKeyringSignsWithTheFirstKey(t *testing.T) {
+	setKeyringEnv(t, []string{writeSigningKey(t, "new")}, "", "")
+	keyring := mustLoadKeyring(t)
+
+	token, err := keyring.Sign(jwt.MapClaims{"sub": "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, _, err := jwt.NewParser().ParseUnverified(token, jwt.MapClaims{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Header["kid"] != "new" || parsed.Method.Alg() != jwt.SigningMethodEdDSA.Alg() {
+		t.Fatalf("signed with kid %v and %s", parsed.Header["kid"], parsed.Method.Alg())
+	}
+
+	claims := jwt.MapClaims{}
+	err = keyring.Parse(token, claims)
+	if err != nil || claims["sub"] != "1" {
+		t.Fatalf("parse: %v, claims %v", err, claims)
+	}
+}
+
+func TestKeyringRequiresRotationDateOfRetiredKeys(t *testing.T) {
+	setKeyringEnv(t, []string{writeSigningKey(t, "new"), writeSigningKey(t, "old")}, "", "")
+	_, err := loadKeyring()
+	if err == nil {
+		t.Fatal("two keys loaded without JWT_KEY_ROTATED_AT")
+	}
+
+	// the legacy secret is retired by a listed key too
+	setKeyringEnv(t, []string{writeSigningKey(t, "new")}, "", "secret")
+	_, err = loadKeyring()
+	if err == nil {
+		t.Fatal("key and legacy secret loaded without JWT_KEY_ROTATED_AT")
+	}
+
+	setKeyringEnv(t, []string{writeSigningKey(t, "new")}, "yesterday", "")
+	_, err = loadKeyring()
+	if err == nil {
+		t.Fatal("invalid JWT_KEY_ROTATED_AT accepted")
+	}
+}
+
+func TestKeyringRetiredKeysWithinGracePeriod(t *testing.T) {
+	oldKey := writeSigningKey(t, "old")
+	setKeyringEnv(t, []string{oldKey}, "", "")
+	oldToken, err := mustLoadKeyring(t).Sign(jwt.MapClaims{"sub": "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newKey := writeSigningKey(t, "new")
+	setKeyringEnv(t, []string{newKey, oldKey}, time.Now().Add(-30*time.Minute).Format(time.RFC3339), "")
+	keyring := mustLoadKeyring(t)
+	err = keyring.Parse(oldToken, jwt.MapClaims{})
+	if err != nil {
+		t.Fatalf("token of the retired key refused during the grace period: %v", err)
+	}
+	if len(keyring.JWKS().Keys) != 2 {
+		t.Fatalf("JWKS has %d keys, want the active and the retired one", len(keyring.JWKS().Keys))
+	}
+
+	setKeyringEnv(t, []string{newKey, oldKey}, time.Now().Add(-2*time.Hour).Format(time.RFC3339), "")
+	keyring = mustLoadKeyring(t)
+	err = keyring.Parse(oldToken, jwt.MapClaims{})
+	if !errors.Is(err, ErrRetiredSigningKey) {
+		t.Fatalf("got %v, want ErrRetiredSigningKey", err)
+	}
+	jwks := keyring.JWKS()
+	if len(jwks.Keys) != 1 || jwks.Keys[0].Kid != "new" {
+		t.Fatalf("JWKS still publishes the retired key: %+v", jwks.Keys)
+	}
+}
+
+func TestKeyringRefusesUnknownKeys(t *testing.T) {
+	setKeyringEnv(t, []string{writeSigningKey(t, "other")}, "", "")
+	otherToken, err := mustLoadKeyring(t).Sign(jwt.MapClaims{"sub": "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	setKeyringEnv(t, []string{writeSigningKey(t, "new")}, "", "")
+	err = mustLoadKeyring(t).Parse(otherToken, jwt.MapClaims{})
+	if !errors.Is(err, ErrUnknownSigningKey) {
+		t.Fatalf("got %v, want ErrUnknownSigningKey", err)
+	}
+}
+
+func TestKeyringLegacySecretHasNoKid(t *testing.T) {
+	setKeyringEnv(t, nil, "", "secret")
+	keyring := mustLoadKeyring(t)
+
+	token, err := keyring.Sign(jwt.MapClaims{"sub": "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = keyring.Parse(token, jwt.MapClaims{})
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if len(keyring.JWKS().Keys) != 0 {
+		t.Fatal("JWKS publishes the HS256 secret")
+	}
+}
@@ -0,0 +1,52 @@
+package auth
+
+import (
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var ErrSignedTokenPurpose = errors.New("token purpose mismatch")
+
+// GenerateSignedToken signs a short-lived token for a single purpose, e.g. an email link,
+// which cannot be used as an access token
+func GenerateSignedToken(purpose string, payload map[string]interface{}, ttl time.Duration) (string, time.Time, error) {
+	keys, err := Keys()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	claims := jwt.MapClaims{}
+	for key, val := range payload {
+		claims[key] = val
+	}
+	now := time.Now()
+	expiresAt := now.Add(ttl)
+	claims["purpose"] = purpose
+	claims["exp"] = expiresAt.Unix()
+	claims["iat"] = now.Unix()
+	claims["nbf"] = now.Unix()
+
+	signedToken, err := keys.Sign(claims)
+	return signedToken, expiresAt, err
+}
+
+// ParseSignedToken verifies a token of GenerateSignedToken and returns its claims
+func ParseSignedToken(purpose string, tokenString string) (jwt.MapClaims, error) {
+	keys, err := Keys()
+	if err != nil {
+		return nil, err
+	}
+
+	claims := jwt.MapClaims{}
+	err = keys.Parse(tokenString, claims)
+	if err != nil {
+		return nil, err
+	}
+	if claims["purpose"] != purpose {
+		return nil, ErrSignedTokenPurpose
+	}
+
+	return claims, nil
+}
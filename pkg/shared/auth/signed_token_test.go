@@ -0,0 +1,92 @@
+package auth
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	// Keys loads the keyring once, the tests of the signed tokens use the HS256 secret
+	os.Setenv("JWT_KEY", "test-secret")
+	os.Setenv("JWT_SIGNING_KEYS", "")
+
+	os.Exit(m.Run())
+}
+
+func TestSignedTokenRoundTrip(t *testing.T) {
+	token, expiresAt, err := GenerateSignedToken("email_verification", map[string]interface{}{
+		"user_id": 7,
+	}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if time.Until(expiresAt) <= 59*time.Minute {
+		t.Fatalf("unexpected expiry %v", expiresAt)
+	}
+
+	claims, err := ParseSignedToken("email_verification", token)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if claims["user_id"] != float64(7) || claims["purpose"] != "email_verification" {
+		t.Fatalf("unexpected claims %v", claims)
+	}
+}
+
+func TestSignedTokenIsBoundToItsPurpose(t *testing.T) {
+	token, _, err := GenerateSignedToken("email_verification", map[string]interface{}{"user_id": 7}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = ParseSignedToken("account_deletion", token)
+	if !errors.Is(err, ErrSignedTokenPurpose) {
+		t.Fatalf("token of another purpose returned %v", err)
+	}
+	_, err = ParseAccessToken(token)
+	if !errors.Is(err, ErrSignedTokenPurpose) {
+		t.Fatalf("signed token accepted as an access token: %v", err)
+	}
+}
+
+func TestAccessTokenIsNotASignedToken(t *testing.T) {
+	token, _, err := GenerateAccessToken(map[string]interface{}{"user_id": 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = ParseSignedToken("email_verification", token)
+	if !errors.Is(err, ErrSignedTokenPurpose) {
+		t.Fatalf("access token accepted as a signed token: %v", err)
+	}
+}
+
+func TestSignedTokenExpires(t *testing.T) {
+	token, _, err := GenerateSignedToken("email_verification", map[string]interface{}{"user_id": 7}, -time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = ParseSignedToken("email_verification", token)
+	if err == nil {
+		t.Fatal("expired token accepted")
+	}
+}
+
+func TestSignedTokenRejectsTampering(t *testing.T) {
+	token, _, err := GenerateSignedToken("email_verification", map[string]interface{}{"user_id": 7}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tampered := token[:len(token)-2] + "xx"
+	if tampered == token {
+		tampered = token[:len(token)-2] + "yy"
+	}
+	_, err = ParseSignedToken("email_verification", tampered)
+	if err == nil {
+		t.Fatal("tampered token accepted")
+	}
+}
@@ -0,0 +1,113 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfc6238Secret is the SHA1 seed of the test vectors of RFC 6238, "12345678901234567890"
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestValidateTOTPMatchesRFC6238(t *testing.T) {
+	// the last 6 digits of the 8 digit codes of the RFC
+	vectors := map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1234567890: "005924",
+		2000000000: "279037",
+	}
+	for unix, code := range vectors {
+		step, ok := ValidateTOTP(rfc6238Secret, code, time.Unix(unix, 0))
+		if !ok || step != unix/totpPeriod {
+			t.Errorf("code %s at %d: step %d, ok %v", code, unix, step, ok)
+		}
+	}
+}
+
+func TestValidateTOTPToleratesOnePeriodOfDrift(t *testing.T) {
+	now := time.Unix(1111111109, 0)
+
+	for _, drift := range []time.Duration{-totpPeriod * time.Second, totpPeriod * time.Second} {
+		step, ok := ValidateTOTP(rfc6238Secret, "081804", now.Add(drift))
+		if !ok || step != now.Unix()/totpPeriod {
+			t.Errorf("drift %v: step %d, ok %v", drift, step, ok)
+		}
+	}
+	for _, drift := range []time.Duration{-2 * totpPeriod * time.Second, 2 * totpPeriod * time.Second} {
+		_, ok := ValidateTOTP(rfc6238Secret, "081804", now.Add(drift))
+		if ok {
+			t.Errorf("drift %v accepted", drift)
+		}
+	}
+}
+
+func TestValidateTOTPRejectsMalformedInput(t *testing.T) {
+	now := time.Unix(1111111109, 0)
+
+	for _, code := range []string{"", "81804", "0081804", "08180a"} {
+		if _, ok := ValidateTOTP(rfc6238Secret, code, now); ok {
+			t.Errorf("code %q accepted", code)
+		}
+	}
+	if _, ok := ValidateTOTP("not base32!", "081804", now); ok {
+		t.Error("invalid secret accepted")
+	}
+	if _, ok := ValidateTOTP(strings.ToLower(rfc6238Secret), "081804", now); !ok {
+		t.Error("lower case secret rejected")
+	}
+}
+
+func TestGenerateTOTPSecretValidatesItsCodes(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := totpEncoding.DecodeString(secret)
+	if err != nil || len(key) != 20 {
+		t.Fatalf("secret %q decodes to %d bytes: %v", secret, len(key), err)
+	}
+
+	now := time.Now()
+	code := totpCode(key, now.Unix()/totpPeriod)
+	if _, ok := ValidateTOTP(secret, code, now); !ok {
+		t.Fatalf("code %s of the generated secret rejected", code)
+	}
+}
+
+func TestTOTPURI(t *testing.T) {
+	t.Setenv("TOTP_ISSUER", "Travelix")
+
+	uri := TOTPURI(rfc6238Secret, "owner@example.com")
+	if !strings.HasPrefix(uri, "otpauth://totp/Travelix:owner@example.com?") {
+		t.Fatalf("unexpected label in %s", uri)
+	}
+	for _, param := range []string{"secret=" + rfc6238Secret, "issuer=Travelix", "digits=6", "period=30"} {
+		if !strings.Contains(uri, param) {
+			t.Errorf("%s misses %s", uri, param)
+		}
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, err := GenerateRecoveryCodes(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seen := map[string]bool{}
+	for _, code := range codes {
+		if len(code) != 11 || code[5] != '-' {
+			t.Errorf("code %q not formatted as xxxxx-xxxxx", code)
+		}
+		if seen[code] {
+			t.Errorf("code %q generated twice", code)
+		}
+		seen[code] = true
+	}
+
+	typed := " " + strings.ToUpper(strings.Replace(codes[0], "-", " ", 1))
+	if HashRecoveryCode(typed) != HashRecoveryCode(codes[0]) {
+		t.Fatalf("code typed as %q does not match", typed)
+	}
+}
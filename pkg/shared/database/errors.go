@@ -0,0 +1,21 @@
+package database
+
+import (
+	"errors"
+
+	"github.com/go-sql-driver/mysql"
+	"gorm.io/gorm"
+)
+
+// mysqlDuplicateEntry is ER_DUP_ENTRY, a unique index rejected the row
+const mysqlDuplicateEntry = 1062
+
+// IsDuplicateKey tells whether err is the violation of a unique index
+func IsDuplicateKey(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return mysqlErr.Number == mysqlDuplicateEntry
+	}
+
+	return errors.Is(err, gorm.ErrDuplicatedKey)
+}
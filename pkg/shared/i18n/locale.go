@@ -0,0 +1,100 @@
+package i18n
+
+import (
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultLocale    = "vi"
+	defaultSupported = "vi,en"
+)
+
+// DefaultLocale returns the locale of the untranslated content, configured by DEFAULT_LOCALE
+func DefaultLocale() string {
+	if locale := Normalize(os.Getenv("DEFAULT_LOCALE")); locale != "" {
+		return locale
+	}
+	return defaultLocale
+}
+
+// SupportedLocales returns the locales configured by SUPPORTED_LOCALES (comma separated)
+func SupportedLocales() []string {
+	value := os.Getenv("SUPPORTED_LOCALES")
+	if value == "" {
+		value = defaultSupported
+	}
+
+	var locales []string
+	for _, locale := range strings.Split(value, ",") {
+		if locale = Normalize(locale); locale != "" {
+			locales = append(locales, locale)
+		}
+	}
+	return locales
+}
+
+func IsSupported(locale string) bool {
+	locale = Normalize(locale)
+	if locale == DefaultLocale() {
+		return true
+	}
+	for _, supported := range SupportedLocales() {
+		if supported == locale {
+			return true
+		}
+	}
+	return false
+}
+
+// Resolve picks the response locale from the lang query parameter first, then from the
+// Accept-Language header by quality, and falls back to the default locale.
+func Resolve(lang string, acceptLanguage string) string {
+	if lang = Normalize(lang); lang != "" && IsSupported(lang) {
+		return lang
+	}
+
+	type weighted struct {
+		locale  string
+		quality float64
+	}
+	var candidates []weighted
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		quality := 1.0
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(q, 64)
+			if err != nil {
+				continue
+			}
+			quality = parsed
+		}
+		if locale := Normalize(tag); locale != "" && quality > 0 {
+			candidates = append(candidates, weighted{locale, quality})
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].quality > candidates[j].quality
+	})
+	for _, candidate := range candidates {
+		if IsSupported(candidate.locale) {
+			return candidate.locale
+		}
+	}
+
+	return DefaultLocale()
+}
+
+// Normalize keeps the primary language subtag, e.g. "en-US" -> "en"
+func Normalize(locale string) string {
+	locale = strings.ToLower(strings.TrimSpace(locale))
+	locale, _, _ = strings.Cut(locale, "-")
+	locale, _, _ = strings.Cut(locale, "_")
+	if locale == "*" {
+		return ""
+	}
+	return locale
+}
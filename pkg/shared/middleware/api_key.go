@@ -0,0 +1,112 @@
+package middleware
+
+import (
+	"go-server/internal/pkg/domains/models/dtos"
+	"go-server/internal/pkg/domains/models/entities"
+	"go-server/pkg/shared/auth"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const APIKeyHeader = "X-API-Key"
+
+const (
+	CheckAPIKeyNotSet = iota + 1
+	CheckAPIKeyInvalid
+	CheckAPIKeyScopeMissing
+)
+
+// CheckAPIKey authenticates partner servers by the X-API-Key header, the key must be
+// live and granted the scope. The key is stored in the context under "api_key_id"
+func CheckAPIKey(db *gorm.DB, scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(APIKeyHeader)
+		if key == "" {
+			c.JSON(http.StatusUnauthorized, dtos.BaseResponse{
+				Code:    CheckAPIKeyNotSet,
+				Message: "Unauthorized",
+				Error: &dtos.ErrorResponse{
+					ErrorDetails: "API key is not set",
+				},
+			})
+			c.Abort()
+			return
+		}
+
+		var apiKey entities.APIKey
+		err := db.WithContext(c).
+			Where("key_hash = ? AND revoked_at IS NULL", auth.HashToken(key)).
+			Where("expires_at IS NULL OR expires_at > ?", time.Now()).
+			Take(&apiKey).Error
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, dtos.BaseResponse{
+				Code:    CheckAPIKeyInvalid,
+				Message: "Unauthorized",
+				Error: &dtos.ErrorResponse{
+					ErrorDetails: "API key invalid",
+				},
+			})
+			c.Abort()
+			return
+		}
+		if !apiKey.HasScope(scope) {
+			c.JSON(http.StatusForbidden, dtos.BaseResponse{
+				Code:    CheckAPIKeyScopeMissing,
+				Message: "Forbidden",
+				Error: &dtos.ErrorResponse{
+					ErrorDetails: "Scope " + scope + " required",
+				},
+			})
+			c.Abort()
+			return
+		}
+
+		recordAPIKeyUsage(db, apiKey)
+
+		c.Set("api_key_id", apiKey.ID)
+		c.Next()
+	}
+}
+
+// CheckAuthenticationOrAPIKey opens a route to partner servers as well as to logged in users,
+// a request with an API key is never checked as a user one
+func CheckAuthenticationOrAPIKey(db *gorm.DB, scope string) gin.HandlerFunc {
+	checkAPIKey := CheckAPIKey(db, scope)
+	checkAuthentication := CheckAuthentication(db)
+
+	return func(c *gin.Context) {
+		if c.GetHeader(APIKeyHeader) != "" {
+			checkAPIKey(c)
+			return
+		}
+
+		checkAuthentication(c)
+	}
+}
+
+// recordAPIKeyUsage counts the request in the totals and the daily counters of the key
+func recordAPIKeyUsage(db *gorm.DB, apiKey entities.APIKey) {
+	now := time.Now()
+	year, month, day := now.Date()
+
+	// failed writes do not reject the request
+	db.Model(&entities.APIKey{}).Where("id = ?", apiKey.ID).UpdateColumns(map[string]interface{}{
+		"last_used_at":  now,
+		"request_count": gorm.Expr("request_count + 1"),
+	})
+	db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "api_key_id"}, {Name: "date"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"requests":   gorm.Expr("requests + 1"),
+			"updated_at": now,
+		}),
+	}).Create(&entities.APIKeyDailyUsage{
+		APIKeyID: apiKey.ID,
+		Date:     time.Date(year, month, day, 0, 0, 0, 0, now.Location()),
+		Requests: 1,
+	})
+}
@@ -6,9 +6,10 @@ import (
 	"go-server/pkg/shared/auth"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
+	"github.com/gorilla/websocket"
 	"gorm.io/gorm"
 )
 
@@ -22,6 +23,10 @@ func CheckAuthentication(
 ) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authorization := c.Request.Header.Get("Authorization")
+		// browsers cannot set headers on a websocket handshake, the token comes in the query
+		if authorization == "" && websocket.IsWebSocketUpgrade(c.Request) {
+			authorization = c.Query("access_token")
+		}
 		if authorization == "" {
 			c.JSON(http.StatusUnauthorized, dtos.BaseResponse{
 				Code:    CheckAuthenticationTokenNotSet,
@@ -35,14 +40,9 @@ func CheckAuthentication(
 		}
 
 		token := strings.Replace(authorization, "Bearer ", "", -1)
-		fields := strings.Split(token, ".")
-
-		decodedToken, err := auth.Decode(token)
-		userID := decodedToken.Claims.(jwt.MapClaims)["user_id"]
-		isAdmin := decodedToken.Claims.(jwt.MapClaims)["is_admin"]
-		tokenID := decodedToken.Claims.(jwt.MapClaims)["token_id"]
 
-		if len(fields) != 3 || !auth.VerifyJWT(token) || err != nil {
+		claims, err := auth.ParseAccessToken(token)
+		if err != nil {
 			c.JSON(http.StatusUnauthorized, dtos.BaseResponse{
 				Code:    CheckAuthenticationTokenInvalid,
 				Message: "Unauthorized",
@@ -53,8 +53,15 @@ func CheckAuthentication(
 			c.Abort()
 			return
 		}
+		userID := claims["user_id"]
+		isAdmin := claims["is_admin"]
+		tokenID := claims["token_id"]
+		twoFactor, _ := claims["mfa"].(bool)
+		expiresAt, _ := claims.GetExpirationTime()
 
-		err = db.Where("token_id = ?", tokenID).First(&entities.UserToken{}).Error
+		// rotated sessions no longer authenticate, see entities.UserToken
+		var userToken entities.UserToken
+		err = db.Where("token_id = ? AND rotated_at IS NULL", tokenID).First(&userToken).Error
 		if err != nil {
 			c.JSON(http.StatusUnauthorized, dtos.BaseResponse{
 				Code:    CheckAuthenticationTokenInvalid,
@@ -67,9 +74,29 @@ func CheckAuthentication(
 			return
 		}
 
+		touchSession(db, userToken)
+
 		c.Set("user_id", userID)
 		c.Set("is_admin", isAdmin)
 		c.Set("token_id", tokenID)
+		c.Set("two_factor", twoFactor)
+		if expiresAt != nil {
+			c.Set("token_expires_at", expiresAt.Time)
+		}
 		c.Next()
 	}
 }
+
+// lastUsedPrecision bounds the writes of the last used time to one per session and minute
+const lastUsedPrecision = time.Minute
+
+// touchSession records that the session is being used
+func touchSession(db *gorm.DB, userToken entities.UserToken) {
+	now := time.Now()
+	if userToken.LastUsedAt != nil && now.Sub(*userToken.LastUsedAt) < lastUsedPrecision {
+		return
+	}
+
+	// a failed write does not reject the request
+	db.Model(&entities.UserToken{}).Where("id = ?", userToken.ID).UpdateColumn("last_used_at", now)
+}
@@ -0,0 +1,17 @@
+package middleware
+
+import (
+	"go-server/pkg/shared/i18n"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Locale resolves the response language from the lang query parameter or the
+// Accept-Language header and stores it in the context under "locale".
+func Locale() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set("locale", i18n.Resolve(c.Query("lang"), c.GetHeader("Accept-Language")))
+
+		c.Next()
+	}
+}
@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// secretQueryParams are credentials some routes take in the query string: the access token of
+// the websocket handshake, the tokens of the emailed links and the code of the OAuth callbacks
+var secretQueryParams = regexp.MustCompile(`([?&](?:access_token|token|code)=)[^&]*`)
+
+// Logger is gin.Logger with the credentials of the query string redacted
+func Logger() gin.HandlerFunc {
+	return gin.LoggerWithConfig(gin.LoggerConfig{
+		Formatter: logFormatter,
+	})
+}
+
+// logFormatter is the default format of gin
+func logFormatter(param gin.LogFormatterParams) string {
+	var statusColor, methodColor, resetColor string
+	if param.IsOutputColor() {
+		statusColor = param.StatusCodeColor()
+		methodColor = param.MethodColor()
+		resetColor = param.ResetColor()
+	}
+
+	if param.Latency > time.Minute {
+		param.Latency = param.Latency.Truncate(time.Second)
+	}
+	return fmt.Sprintf("[GIN] %v |%s %3d %s| %13v | %15s |%s %-7s %s %#v\n%s",
+		param.TimeStamp.Format("2006/01/02 - 15:04:05"),
+		statusColor, param.StatusCode, resetColor,
+		param.Latency,
+		param.ClientIP,
+		methodColor, param.Method, resetColor,
+		redactQuery(param.Path),
+		param.ErrorMessage,
+	)
+}
+
+func redactQuery(path string) string {
+	return secretQueryParams.ReplaceAllString(path, "${1}REDACTED")
+}
@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"go-server/internal/pkg/domains/models/dtos"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// HasPermission reports whether one of the roles of the user grants the permission
+func HasPermission(db *gorm.DB, userID interface{}, permission string) (bool, error) {
+	var count int64
+	err := db.Table("user_roles").
+		Joins("JOIN role_permissions ON role_permissions.role_id = user_roles.role_id").
+		Joins("JOIN permissions ON permissions.id = role_permissions.permission_id AND permissions.deleted_at IS NULL").
+		Where("user_roles.user_id = ? AND permissions.name = ?", userID, permission).
+		Count(&count).Error
+
+	return count > 0, err
+}
+
+// CheckPermission rejects users without the permission, it runs after CheckAuthentication and CheckRole.
+// Roles are read on every request so that a change applies to the current sessions
+func CheckPermission(db *gorm.DB, permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ok, err := HasPermission(db.WithContext(c), c.MustGet("user_id"), permission)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, dtos.BaseResponse{
+				Message: "Internal Server Error",
+				Error: &dtos.ErrorResponse{
+					ErrorDetails: err.Error(),
+				},
+			})
+			c.Abort()
+			return
+		}
+		if !ok {
+			c.JSON(http.StatusForbidden, dtos.BaseResponse{
+				Code:    3,
+				Message: "Forbidden",
+				Error: &dtos.ErrorResponse{
+					ErrorDetails: "Permission " + permission + " required",
+				},
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
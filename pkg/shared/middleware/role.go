@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"go-server/internal/pkg/domains/models/dtos"
+	"go-server/pkg/shared/auth"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -21,6 +22,18 @@ func CheckRole() gin.HandlerFunc {
 			c.Abort()
 			return
 		}
+		// admins enroll from /api/app/user/2fa and log in again
+		if auth.AdminTwoFactorRequired() && !c.GetBool("two_factor") {
+			c.JSON(http.StatusForbidden, dtos.BaseResponse{
+				Code:    2,
+				Message: "Forbidden",
+				Error: &dtos.ErrorResponse{
+					ErrorDetails: "Two-factor authentication required",
+				},
+			})
+			c.Abort()
+			return
+		}
 
 		c.Next()
 	}
@@ -0,0 +1,75 @@
+package moderation
+
+import (
+	"os"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// ContentFilter decides whether a user text must be held for review
+type ContentFilter interface {
+	// Match returns the offending terms found in text, none if the text is clean
+	Match(text string) []string
+}
+
+// defaultBannedWords is used when COMMENT_BANNED_WORDS is not set
+var defaultBannedWords = []string{
+	// Vietnamese
+	"địt", "đụ", "đéo", "lồn", "cặc", "buồi", "đĩ", "đm", "đmm", "vcl", "vkl", "clm", "cmm",
+	"óc chó", "đồ chó", "con chó", "mẹ mày", "bố mày",
+	// English
+	"fuck", "fucking", "motherfucker", "shit", "bitch", "asshole", "bastard", "cunt", "dick", "whore", "slut",
+}
+
+type bannedWordFilter struct {
+	terms []string
+}
+
+// NewBannedWordFilter matches whole words and phrases, case insensitive. Vietnamese tone
+// marks are significant so that "đụ" does not match "du lịch".
+func NewBannedWordFilter(words []string) ContentFilter {
+	var terms []string
+	for _, word := range words {
+		if term := normalize(word); term != "" {
+			terms = append(terms, term)
+		}
+	}
+
+	return &bannedWordFilter{terms}
+}
+
+// NewFilterFromEnv builds the banned word filter from COMMENT_BANNED_WORDS (comma separated),
+// falling back to the built-in Vietnamese and English list
+func NewFilterFromEnv() ContentFilter {
+	value := os.Getenv("COMMENT_BANNED_WORDS")
+	if value == "" {
+		return NewBannedWordFilter(defaultBannedWords)
+	}
+
+	return NewBannedWordFilter(strings.Split(value, ","))
+}
+
+func (f *bannedWordFilter) Match(text string) []string {
+	// padded so that terms only match on word boundaries
+	padded := " " + normalize(text) + " "
+
+	var matched []string
+	for _, term := range f.terms {
+		if strings.Contains(padded, " "+term+" ") {
+			matched = append(matched, term)
+		}
+	}
+
+	return matched
+}
+
+// normalize lowercases s and keeps its words separated by single spaces
+func normalize(s string) string {
+	words := strings.FieldsFunc(norm.NFC.String(strings.ToLower(s)), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,148 @@
+package realtime
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Actions a client can send, e.g. {"action": "subscribe", "topic": "trip:12"}
+const (
+	ActionSubscribe   = "subscribe"
+	ActionUnsubscribe = "unsubscribe"
+)
+
+type clientMessage struct {
+	Action string `json:"action"`
+	Topic  string `json:"topic"`
+}
+
+// client is a connection served by the hub. Only writePump writes to the connection
+type client struct {
+	hub       *Hub
+	conn      *websocket.Conn
+	userID    int
+	tokenID   string
+	authorize func(topic string) bool
+	send      chan []byte
+	topics    map[string]struct{} // guarded by hub.mu
+
+	done      chan struct{}
+	closeOnce sync.Once
+	closeCode int
+	closeText string
+}
+
+// close asks writePump to send the close frame and close the connection, the first call wins
+func (c *client) close(code int, text string) {
+	c.closeOnce.Do(func() {
+		c.closeCode = code
+		c.closeText = text
+		close(c.done)
+	})
+}
+
+// enqueue queues the message without blocking, a client whose buffer is full is disconnected
+func (c *client) enqueue(message []byte) {
+	select {
+	case c.send <- message:
+	case <-c.done:
+	default:
+		c.hub.logger.Warnf("websocket of user %d is too slow, disconnecting", c.userID)
+		c.close(websocket.CloseTryAgainLater, "send buffer full")
+	}
+}
+
+func (c *client) reply(eventType string, topic string, data interface{}) {
+	message, err := json.Marshal(Event{
+		Type:  eventType,
+		Topic: topic,
+		Data:  data,
+	})
+	if err != nil {
+		return
+	}
+	c.enqueue(message)
+}
+
+func (c *client) writePump() {
+	config := c.hub.config
+	ticker := time.NewTicker(config.PingPeriod)
+	defer func() {
+		ticker.Stop()
+		_ = c.conn.Close()
+	}()
+
+	for {
+		select {
+		case message := <-c.send:
+			_ = c.conn.SetWriteDeadline(time.Now().Add(config.WriteWait))
+			err := c.conn.WriteMessage(websocket.TextMessage, message)
+			if err != nil {
+				c.close(websocket.CloseAbnormalClosure, "")
+				return
+			}
+		case <-ticker.C:
+			err := c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(config.WriteWait))
+			if err != nil {
+				c.close(websocket.CloseAbnormalClosure, "")
+				return
+			}
+		case <-c.done:
+			_ = c.conn.WriteControl(
+				websocket.CloseMessage,
+				websocket.FormatCloseMessage(c.closeCode, c.closeText),
+				time.Now().Add(config.WriteWait),
+			)
+			return
+		}
+	}
+}
+
+// readPump handles the messages of the client until the connection fails, a client which
+// stops answering the heartbeats hits the read deadline
+func (c *client) readPump() {
+	config := c.hub.config
+	c.conn.SetReadLimit(config.MaxMessageSize)
+	_ = c.conn.SetReadDeadline(time.Now().Add(config.PongWait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(config.PongWait))
+	})
+
+	for {
+		_, data, err := c.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		var message clientMessage
+		err = json.Unmarshal(data, &message)
+		if err != nil || message.Topic == "" {
+			c.reply(EventError, "", "Message must be a JSON object with an action and a topic")
+			continue
+		}
+
+		switch message.Action {
+		case ActionSubscribe:
+			if !c.authorize(message.Topic) {
+				c.reply(EventError, message.Topic, "Topic not found")
+				continue
+			}
+			if !c.hub.subscribe(c, message.Topic) {
+				c.reply(EventError, message.Topic, "Too many subscriptions")
+				continue
+			}
+			c.reply(EventSubscribed, message.Topic, nil)
+		case ActionUnsubscribe:
+			// the topic of the user stays subscribed for the life of the connection
+			if message.Topic != UserTopic(c.userID) {
+				c.hub.unsubscribe(c, message.Topic)
+			}
+			c.reply(EventUnsubscribed, message.Topic, nil)
+		default:
+			c.reply(EventError, message.Topic, "Action must be subscribe or unsubscribe")
+		}
+	}
+}
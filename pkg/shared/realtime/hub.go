@@ -0,0 +1,349 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"sync"
+	"time"
+
+	"go-server/pkg/shared/utils"
+
+	"github.com/gorilla/websocket"
+	"github.com/sirupsen/logrus"
+)
+
+// Events pushed to the clients
+const (
+	EventNotificationCreated = "notification.created"
+	EventTripUpdated         = "trip.updated"
+	EventTripDeleted         = "trip.deleted"
+
+	// replies to the messages of the client
+	EventSubscribed   = "subscribed"
+	EventUnsubscribed = "unsubscribed"
+	EventError        = "error"
+)
+
+// Event is the JSON message pushed to the subscribers of a topic
+type Event struct {
+	Type  string      `json:"type"`
+	Topic string      `json:"topic,omitempty"`
+	Data  interface{} `json:"data,omitempty"`
+}
+
+// UserTopic receives the events of a user, every connection of the user is subscribed to it
+func UserTopic(userID int) string {
+	return "user:" + strconv.Itoa(userID)
+}
+
+// TripTopic receives the changes of a trip, clients viewing it subscribe to it
+func TripTopic(tripID int) string {
+	return "trip:" + strconv.Itoa(tripID)
+}
+
+// Publisher pushes events to the clients subscribed to a topic
+type Publisher interface {
+	Publish(topic string, eventType string, data interface{})
+}
+
+// Broker is a Publisher which also maintains who may stay subscribed to a topic
+type Broker interface {
+	Publisher
+	// Revalidate drops the subscribers of the topic no longer authorized to it
+	Revalidate(topic string)
+	// DropTopic unsubscribes every client of the topic, e.g. once its subject is deleted
+	DropTopic(topic string)
+}
+
+// SessionCloser disconnects the clients of the sessions ended elsewhere, the access
+// token of a connection is only checked at the handshake
+type SessionCloser interface {
+	// CloseSession closes the connections opened with the access token of the session
+	CloseSession(tokenID string)
+	// CloseUser closes every connection of the user
+	CloseUser(userID int)
+}
+
+// Session identifies the access token a connection was opened with
+type Session struct {
+	UserID  int
+	TokenID string
+	// ExpiresAt is the expiry of the access token, the connection is closed at that time
+	ExpiresAt time.Time
+}
+
+// Config tunes the connections, see ConfigFromEnv
+type Config struct {
+	// PingPeriod is the interval of the heartbeats, a client not answering within PongWait is dropped
+	PingPeriod time.Duration
+	PongWait   time.Duration
+	WriteWait  time.Duration
+	// SendBuffer is the number of events queued per connection, a client falling further
+	// behind is disconnected rather than slowing down the others
+	SendBuffer int
+	// MaxTopics bounds the subscriptions of a connection
+	MaxTopics int
+	// MaxMessageSize bounds the messages read from a client
+	MaxMessageSize int64
+}
+
+// ConfigFromEnv reads WS_PING_PERIOD, WS_PONG_WAIT, WS_WRITE_WAIT, WS_SEND_BUFFER and WS_MAX_TOPICS
+func ConfigFromEnv() Config {
+	config := Config{
+		PingPeriod:     utils.DurationFromEnv("WS_PING_PERIOD", 30*time.Second),
+		PongWait:       utils.DurationFromEnv("WS_PONG_WAIT", 60*time.Second),
+		WriteWait:      utils.DurationFromEnv("WS_WRITE_WAIT", 10*time.Second),
+		SendBuffer:     utils.IntFromEnv("WS_SEND_BUFFER", 32),
+		MaxTopics:      utils.IntFromEnv("WS_MAX_TOPICS", 50),
+		MaxMessageSize: 4096,
+	}
+	// the pong of a ping must be able to arrive before the read deadline
+	if config.PongWait <= config.PingPeriod {
+		config.PongWait = config.PingPeriod * 2
+	}
+
+	return config
+}
+
+// Hub routes the published events to the connections subscribed to their topic.
+// It is safe for concurrent use
+type Hub struct {
+	config Config
+	logger *logrus.Logger
+
+	mu       sync.RWMutex
+	topics   map[string]map[*client]struct{}
+	sessions map[string]map[*client]struct{}
+	clients  map[*client]struct{}
+	closed   bool
+	serving  sync.WaitGroup
+}
+
+func NewHub(config Config, logger *logrus.Logger) *Hub {
+	return &Hub{
+		config:   config,
+		logger:   logger,
+		topics:   make(map[string]map[*client]struct{}),
+		sessions: make(map[string]map[*client]struct{}),
+		clients:  make(map[*client]struct{}),
+	}
+}
+
+// Serve runs the connection of the session until it is closed by either side or the access
+// token expires. The connection is subscribed to the topic of the user, authorize decides on
+// the other topics it asks for and is asked again by Revalidate
+func (h *Hub) Serve(conn *websocket.Conn, session Session, authorize func(topic string) bool) {
+	c := &client{
+		hub:       h,
+		conn:      conn,
+		userID:    session.UserID,
+		tokenID:   session.TokenID,
+		authorize: authorize,
+		send:      make(chan []byte, h.config.SendBuffer),
+		topics:    make(map[string]struct{}),
+		done:      make(chan struct{}),
+	}
+
+	if !h.register(c) {
+		_ = conn.WriteControl(
+			websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down"),
+			time.Now().Add(h.config.WriteWait),
+		)
+		_ = conn.Close()
+		return
+	}
+	defer h.serving.Done()
+	defer h.unregister(c)
+
+	expiry := time.AfterFunc(time.Until(session.ExpiresAt), func() {
+		c.close(websocket.ClosePolicyViolation, "token expired")
+	})
+	defer expiry.Stop()
+
+	h.subscribe(c, UserTopic(session.UserID))
+
+	written := make(chan struct{})
+	go func() {
+		defer close(written)
+		c.writePump()
+	}()
+
+	c.readPump()
+	c.close(websocket.CloseNormalClosure, "")
+	<-written
+}
+
+// Publish pushes the event to the subscribers of the topic without waiting for them
+func (h *Hub) Publish(topic string, eventType string, data interface{}) {
+	message, err := json.Marshal(Event{
+		Type:  eventType,
+		Topic: topic,
+		Data:  data,
+	})
+	if err != nil {
+		h.logger.Errorf("marshal %s event of %s failed: %v", eventType, topic, err)
+		return
+	}
+
+	for _, c := range h.subscribers(topic) {
+		c.enqueue(message)
+	}
+}
+
+// Revalidate asks authorize again for every subscriber of the topic, the ones refused are
+// unsubscribed and told so. Call it before publishing a change which may revoke access
+func (h *Hub) Revalidate(topic string) {
+	for _, c := range h.subscribers(topic) {
+		if c.authorize(topic) {
+			continue
+		}
+		h.unsubscribe(c, topic)
+		c.reply(EventUnsubscribed, topic, nil)
+	}
+}
+
+// DropTopic unsubscribes every client of the topic and tells them so
+func (h *Hub) DropTopic(topic string) {
+	for _, c := range h.subscribers(topic) {
+		h.unsubscribe(c, topic)
+		c.reply(EventUnsubscribed, topic, nil)
+	}
+}
+
+// CloseSession closes the connections of the session, e.g. on logout or revocation
+func (h *Hub) CloseSession(tokenID string) {
+	h.mu.RLock()
+	clients := make([]*client, 0, len(h.sessions[tokenID]))
+	for c := range h.sessions[tokenID] {
+		clients = append(clients, c)
+	}
+	h.mu.RUnlock()
+
+	for _, c := range clients {
+		c.close(websocket.ClosePolicyViolation, "session ended")
+	}
+}
+
+// CloseUser closes every connection of the user, e.g. on a password reset
+func (h *Hub) CloseUser(userID int) {
+	h.mu.RLock()
+	clients := make([]*client, 0)
+	for c := range h.clients {
+		if c.userID == userID {
+			clients = append(clients, c)
+		}
+	}
+	h.mu.RUnlock()
+
+	for _, c := range clients {
+		c.close(websocket.ClosePolicyViolation, "session ended")
+	}
+}
+
+// Shutdown closes every connection with a going away close frame and waits for them to end,
+// connections opened afterwards are refused
+func (h *Hub) Shutdown(ctx context.Context) error {
+	h.mu.Lock()
+	h.closed = true
+	clients := make([]*client, 0, len(h.clients))
+	for c := range h.clients {
+		clients = append(clients, c)
+	}
+	h.mu.Unlock()
+
+	for _, c := range clients {
+		c.close(websocket.CloseGoingAway, "server shutting down")
+	}
+
+	served := make(chan struct{})
+	go func() {
+		h.serving.Wait()
+		close(served)
+	}()
+
+	select {
+	case <-served:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (h *Hub) register(c *client) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return false
+	}
+	h.clients[c] = struct{}{}
+	if h.sessions[c.tokenID] == nil {
+		h.sessions[c.tokenID] = make(map[*client]struct{})
+	}
+	h.sessions[c.tokenID][c] = struct{}{}
+	h.serving.Add(1)
+	return true
+}
+
+func (h *Hub) unregister(c *client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for topic := range c.topics {
+		h.removeSubscriber(topic, c)
+	}
+	delete(h.sessions[c.tokenID], c)
+	if len(h.sessions[c.tokenID]) == 0 {
+		delete(h.sessions, c.tokenID)
+	}
+	delete(h.clients, c)
+}
+
+func (h *Hub) subscribers(topic string) []*client {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	subscribers := make([]*client, 0, len(h.topics[topic]))
+	for c := range h.topics[topic] {
+		subscribers = append(subscribers, c)
+	}
+	return subscribers
+}
+
+// subscribe returns false when the client reached MaxTopics
+func (h *Hub) subscribe(c *client, topic string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := c.topics[topic]; ok {
+		return true
+	}
+	if len(c.topics) >= h.config.MaxTopics {
+		return false
+	}
+
+	c.topics[topic] = struct{}{}
+	if h.topics[topic] == nil {
+		h.topics[topic] = make(map[*client]struct{})
+	}
+	h.topics[topic][c] = struct{}{}
+	return true
+}
+
+func (h *Hub) unsubscribe(c *client, topic string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	delete(c.topics, topic)
+	h.removeSubscriber(topic, c)
+}
+
+// removeSubscriber must be called with the lock held
+func (h *Hub) removeSubscriber(topic string, c *client) {
+	delete(h.topics[topic], c)
+	if len(h.topics[topic]) == 0 {
+		delete(h.topics, topic)
+	}
+}
@@ -0,0 +1,240 @@
+package realtime
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/sirupsen/logrus"
+)
+
+func newTestHub(t *testing.T) *Hub {
+	t.Helper()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	hub := NewHub(Config{
+		PingPeriod:     time.Minute,
+		PongWait:       2 * time.Minute,
+		WriteWait:      time.Second,
+		SendBuffer:     8,
+		MaxTopics:      4,
+		MaxMessageSize: 4096,
+	}, logger)
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = hub.Shutdown(ctx)
+	})
+
+	return hub
+}
+
+// connect serves a connection of the session on the hub and returns the client side of it
+func connect(t *testing.T, hub *Hub, session Session, authorize func(topic string) bool) *websocket.Conn {
+	t.Helper()
+
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		hub.Serve(conn, session, authorize)
+	}))
+	t.Cleanup(server.Close)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+
+	// the user topic is subscribed once the hub registered the client
+	waitFor(t, func() bool {
+		hub.mu.RLock()
+		defer hub.mu.RUnlock()
+		return len(hub.sessions[session.TokenID]) > 0
+	})
+
+	return conn
+}
+
+func waitFor(t *testing.T, condition func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func readEvent(t *testing.T, conn *websocket.Conn) Event {
+	t.Helper()
+
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var event Event
+	err := conn.ReadJSON(&event)
+	if err != nil {
+		t.Fatalf("read event: %v", err)
+	}
+	return event
+}
+
+// readClose reads until the server closes the connection and returns the close frame
+func readClose(t *testing.T, conn *websocket.Conn) *websocket.CloseError {
+	t.Helper()
+
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		_, _, err := conn.ReadMessage()
+		if err == nil {
+			continue
+		}
+		var closeErr *websocket.CloseError
+		if !errors.As(err, &closeErr) {
+			t.Fatalf("connection not closed by the server: %v", err)
+		}
+		return closeErr
+	}
+}
+
+func subscribe(t *testing.T, conn *websocket.Conn, topic string) Event {
+	t.Helper()
+
+	err := conn.WriteJSON(clientMessage{Action: ActionSubscribe, Topic: topic})
+	if err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	return readEvent(t, conn)
+}
+
+func session(userID int, tokenID string) Session {
+	return Session{UserID: userID, TokenID: tokenID, ExpiresAt: time.Now().Add(time.Hour)}
+}
+
+func allowAll(string) bool { return true }
+
+func TestHubPublishesToTheUserTopic(t *testing.T) {
+	hub := newTestHub(t)
+	conn := connect(t, hub, session(1, "token-1"), allowAll)
+
+	hub.Publish(UserTopic(2), EventNotificationCreated, "other user")
+	hub.Publish(UserTopic(1), EventNotificationCreated, "hello")
+
+	event := readEvent(t, conn)
+	if event.Type != EventNotificationCreated || event.Topic != UserTopic(1) || event.Data != "hello" {
+		t.Fatalf("unexpected event %+v", event)
+	}
+}
+
+func TestHubRefusesUnauthorizedTopic(t *testing.T) {
+	hub := newTestHub(t)
+	conn := connect(t, hub, session(1, "token-1"), func(topic string) bool {
+		return topic != TripTopic(7)
+	})
+
+	event := subscribe(t, conn, TripTopic(7))
+	if event.Type != EventError {
+		t.Fatalf("subscription to a refused topic answered %+v", event)
+	}
+	event = subscribe(t, conn, TripTopic(8))
+	if event.Type != EventSubscribed {
+		t.Fatalf("subscription to an allowed topic answered %+v", event)
+	}
+}
+
+func TestHubCloseSession(t *testing.T) {
+	hub := newTestHub(t)
+	revoked := connect(t, hub, session(1, "token-1"), allowAll)
+	other := connect(t, hub, session(1, "token-2"), allowAll)
+
+	hub.CloseSession("token-1")
+
+	closeErr := readClose(t, revoked)
+	if closeErr.Code != websocket.ClosePolicyViolation {
+		t.Fatalf("closed with %d", closeErr.Code)
+	}
+
+	hub.Publish(UserTopic(1), EventNotificationCreated, "still there")
+	if event := readEvent(t, other); event.Data != "still there" {
+		t.Fatalf("other session got %+v", event)
+	}
+}
+
+func TestHubCloseUser(t *testing.T) {
+	hub := newTestHub(t)
+	first := connect(t, hub, session(1, "token-1"), allowAll)
+	second := connect(t, hub, session(1, "token-2"), allowAll)
+	other := connect(t, hub, session(2, "token-3"), allowAll)
+
+	hub.CloseUser(1)
+
+	readClose(t, first)
+	readClose(t, second)
+
+	hub.Publish(UserTopic(2), EventNotificationCreated, "still there")
+	if event := readEvent(t, other); event.Data != "still there" {
+		t.Fatalf("other user got %+v", event)
+	}
+}
+
+func TestHubClosesExpiredToken(t *testing.T) {
+	hub := newTestHub(t)
+	conn := connect(t, hub, Session{UserID: 1, TokenID: "token-1", ExpiresAt: time.Now().Add(50 * time.Millisecond)}, allowAll)
+
+	closeErr := readClose(t, conn)
+	if closeErr.Code != websocket.ClosePolicyViolation || closeErr.Text != "token expired" {
+		t.Fatalf("closed with %d %q", closeErr.Code, closeErr.Text)
+	}
+}
+
+func TestHubRevalidateDropsRefusedSubscribers(t *testing.T) {
+	hub := newTestHub(t)
+	var allowed atomic.Bool
+	allowed.Store(true)
+	conn := connect(t, hub, session(1, "token-1"), func(string) bool {
+		return allowed.Load()
+	})
+	subscribe(t, conn, TripTopic(7))
+
+	allowed.Store(false)
+	hub.Revalidate(TripTopic(7))
+	hub.Publish(TripTopic(7), EventTripUpdated, "private now")
+	hub.Publish(UserTopic(1), EventNotificationCreated, "after")
+
+	event := readEvent(t, conn)
+	if event.Type != EventUnsubscribed || event.Topic != TripTopic(7) {
+		t.Fatalf("refused subscriber not told, got %+v", event)
+	}
+	if event := readEvent(t, conn); event.Data != "after" {
+		t.Fatalf("refused subscriber still received %+v", event)
+	}
+}
+
+func TestHubDropTopic(t *testing.T) {
+	hub := newTestHub(t)
+	conn := connect(t, hub, session(1, "token-1"), allowAll)
+	subscribe(t, conn, TripTopic(7))
+
+	hub.DropTopic(TripTopic(7))
+	hub.Publish(TripTopic(7), EventTripUpdated, "dropped")
+	hub.Publish(UserTopic(1), EventNotificationCreated, "after")
+
+	if event := readEvent(t, conn); event.Type != EventUnsubscribed {
+		t.Fatalf("subscriber not told, got %+v", event)
+	}
+	if event := readEvent(t, conn); event.Data != "after" {
+		t.Fatalf("dropped subscriber still received %+v", event)
+	}
+}
@@ -0,0 +1,37 @@
+package utils
+
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
+// DurationFromEnv parses the duration of the env variable key (e.g. "15m"), fallback when unset or invalid
+func DurationFromEnv(key string, fallback time.Duration) time.Duration {
+	duration, err := time.ParseDuration(os.Getenv(key))
+	if err != nil || duration <= 0 {
+		return fallback
+	}
+
+	return duration
+}
+
+// BoolFromEnv parses the boolean of the env variable key, fallback when unset or invalid
+func BoolFromEnv(key string, fallback bool) bool {
+	value, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+
+	return value
+}
+
+// IntFromEnv parses the positive integer of the env variable key, fallback when unset or invalid
+func IntFromEnv(key string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return fallback
+	}
+
+	return value
+}
@@ -4,6 +4,8 @@ import (
 	"crypto/rand"
 	"encoding/base64"
 	"net/http"
+	"os"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -11,21 +13,45 @@ import (
 	"golang.org/x/oauth2/google"
 )
 
-const OauthGoogleUrlAPI = "https://www.googleapis.com/oauth2/v2/userinfo?access_token="
+const defaultGoogleUserInfoURL = "https://www.googleapis.com/oauth2/v2/userinfo"
 
+// SetupConfig is the oauth2 client of the google login read from the GOOGLE_* env variables,
+// GOOGLE_AUTH_URL and GOOGLE_TOKEN_URL replace the google endpoints (e.g. by a local fake server)
 func SetupConfig() *oauth2.Config {
+	endpoint := google.Endpoint
+	if authURL := os.Getenv("GOOGLE_AUTH_URL"); authURL != "" {
+		endpoint.AuthURL = authURL
+	}
+	if tokenURL := os.Getenv("GOOGLE_TOKEN_URL"); tokenURL != "" {
+		endpoint.TokenURL = tokenURL
+	}
+
+	redirectURL := os.Getenv("GOOGLE_REDIRECT_URL")
+	if redirectURL == "" {
+		redirectURL = strings.TrimRight(os.Getenv("APP_URL"), "/") + "/api/auth/google/callback"
+	}
+
 	return &oauth2.Config{
-		ClientID:     "507025329306-07bihn57phj6t1c750ahea66t5v0mjsm.apps.googleusercontent.com",
-		ClientSecret: "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",
-		RedirectURL:  "http://localhost:8080/api/auth/google/callback",
+		ClientID:     os.Getenv("GOOGLE_CLIENT_ID"),
+		ClientSecret: os.Getenv("GOOGLE_CLIENT_SECRET"),
+		RedirectURL:  redirectURL,
 		Scopes: []string{
 			"https://www.googleapis.com/auth/userinfo.email",
 			"https://www.googleapis.com/auth/userinfo.profile",
 		},
-		Endpoint: google.Endpoint,
+		Endpoint: endpoint,
 	}
 }
 
+// GoogleUserInfoURL is the userinfo endpoint of the google login, replaced by GOOGLE_USERINFO_URL
+func GoogleUserInfoURL() string {
+	if userInfoURL := os.Getenv("GOOGLE_USERINFO_URL"); userInfoURL != "" {
+		return userInfoURL
+	}
+
+	return defaultGoogleUserInfoURL
+}
+
 func GenerateStateOauthCookie(c *gin.Context) string {
 	var expiration = time.Now().Add(2 * time.Minute)
 	b := make([]byte, 16)
@@ -0,0 +1,65 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// FoldDiacritics lowercases s, strips combining marks (e.g. Vietnamese tone marks)
+// and collapses whitespace so that "Hồ Hoàn  Kiếm" and "ho hoan kiem" compare equal.
+func FoldDiacritics(s string) string {
+	var b strings.Builder
+	for _, r := range norm.NFD.String(strings.ToLower(s)) {
+		if unicode.Is(unicode.Mn, r) {
+			continue
+		}
+		// đ has no decomposition in unicode
+		if r == 'đ' {
+			r = 'd'
+		}
+		b.WriteRune(r)
+	}
+
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// NameSimilarity returns a score between 0 and 1 based on the levenshtein distance
+// of the diacritic folded names, 1 meaning identical.
+func NameSimilarity(a, b string) float64 {
+	ra := []rune(FoldDiacritics(a))
+	rb := []rune(FoldDiacritics(b))
+
+	maxLen := len(ra)
+	if len(rb) > maxLen {
+		maxLen = len(rb)
+	}
+	if maxLen == 0 {
+		return 1
+	}
+
+	return 1 - float64(levenshtein(ra, rb))/float64(maxLen)
+}
+
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(b)]
+}